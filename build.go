@@ -0,0 +1,161 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/lSimul/php2go/p"
+)
+
+// runtimeModule is the module containing std, generated code imports it.
+const runtimeModule = "github.com/lSimul/php2go"
+
+// build transpiles every PHP file in the directory into one
+// Go project, go.mod is generated for it.
+func build(args []string) {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	output := fs.String("o", "", "Output folder.")
+	module := fs.String("module", "", "Import path of the generated project, name of the output folder by default.")
+	overwrite := fs.Bool("overwrite", false, "Replace the output folder if it exists.")
+	cli := fs.Bool("cli", false, "Disable the server behaviour.")
+	unchecked := fs.Bool("unchecked", false, "Do not promote overflowing int arithmetic to floats.")
+	local := fs.String("php2go", "", "Folder with php2go sources, used instead of the released runtime.")
+
+	// Flags can follow the directory.
+	var dir string
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+		fs.Parse(fs.Args()[1:])
+	}
+	if dir == "" || *output == "" || fs.NArg() != 0 {
+		fmt.Println("Usage: php2go build <directory> -o <output folder> [-module <path>] [-overwrite] [-cli] [-unchecked] [-php2go <folder>]")
+		os.Exit(2)
+	}
+
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	entries, err := p.Entries(root)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(os.Stderr, "No PHP files found in %s.\n", dir)
+		os.Exit(1)
+	}
+
+	if _, err := os.Stat(*output); err == nil && !*overwrite {
+		fmt.Fprintf(os.Stderr, "Output folder %s already exists, use -overwrite to replace it.\n", *output)
+		os.Exit(1)
+	}
+	if *overwrite && contains(*output, root) {
+		fmt.Fprintf(os.Stderr, "Output folder %s contains the sources, it cannot be overwritten.\n", *output)
+		os.Exit(1)
+	}
+
+	if *module == "" {
+		abs, err := filepath.Abs(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		*module = filepath.Base(abs)
+	}
+	parser := p.NewParser(p.NewNameTranslator(), p.NewFunctionTranslator())
+	parser.Module(*module)
+	parser.CheckOverflow(!*unchecked)
+	gc, diags, err := parser.RunProject(entries, !*cli)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, d := range diags {
+		fmt.Fprintln(os.Stderr, d)
+	}
+
+	if *overwrite {
+		if err := os.RemoveAll(*output); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	toFiles(gc, *output)
+	if err := goMod(*output, *module, *local); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if p.HasErrors(diags) {
+		os.Exit(1)
+	}
+}
+
+// contains reports if the path is the folder or is inside it.
+func contains(folder, path string) bool {
+	abs, err := filepath.Abs(folder)
+	if err != nil {
+		return true
+	}
+	rel, err := filepath.Rel(abs, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// goMod writes go.mod of the generated project. Released php2go
+// requires the same version of the runtime, the one built from
+// sources uses them instead, go.sum is copied from them too.
+// Only versions downloaded by go have checksums, builds of
+// checkouts get pseudo-versions, which cannot be downloaded.
+func goMod(output, module, local string) error {
+	version := "v0.0.0"
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Path == runtimeModule && info.Main.Sum != "" {
+		version = info.Main.Version
+	} else if local == "" {
+		local = sources()
+	}
+
+	s := strings.Builder{}
+	s.WriteString(fmt.Sprintf("module %s\n\ngo 1.13\n\nrequire %s %s\n", module, runtimeModule, version))
+	if local != "" {
+		abs, err := filepath.Abs(local)
+		if err != nil {
+			return err
+		}
+		s.WriteString(fmt.Sprintf("\nreplace %s => %s\n", runtimeModule, abs))
+
+		sum, err := ioutil.ReadFile(filepath.Join(abs, "go.sum"))
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(output, "go.sum"), sum, 0644); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintf(os.Stderr, "Run \"go mod tidy\" in %s to download the runtime.\n", output)
+	}
+	return ioutil.WriteFile(filepath.Join(output, "go.mod"), []byte(s.String()), 0644)
+}
+
+// sources finds php2go sources, this binary was built from.
+// Empty string is returned when they are not available.
+func sources() string {
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		return ""
+	}
+	dir := filepath.Dir(file)
+	mod, err := ioutil.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil || !strings.HasPrefix(string(mod), "module "+runtimeModule+"\n") {
+		return ""
+	}
+	return dir
+}
@@ -0,0 +1,321 @@
+package lang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lSimul/php2go/std/array/gen"
+)
+
+// Class is PHP class lowered to the Go struct.
+// Properties are fields of the struct, methods
+// have pointer to the struct as a receiver.
+type Class struct {
+	parent *File
+
+	Name   string
+	Fields []*Variable
+
+	// Package is set for classes outside of the main package,
+	// their names are qualified by it. Classes from the runtime
+	// are not printed, only used or extended.
+	Package string
+
+	// Extends is the parent class, it is embedded
+	// in the struct.
+	Extends    *Class
+	Implements []*Interface
+	Abstract   bool
+
+	// Interface is set for classes with subclasses,
+	// it is used instead of the class in type declarations.
+	// Overridden methods are dispatched through it.
+	Interface *Interface
+
+	// Constructor is a function returning pointer
+	// to the newly created struct, it is defined
+	// even if the PHP class does not have any.
+	Constructor *Function
+	Methods     []*Function
+
+	// Array is set when the array of objects is used,
+	// typed array is generated next to the struct.
+	Array bool
+}
+
+func NewClass(name string) *Class {
+	c := &Class{
+		Name:       name,
+		Fields:     make([]*Variable, 0),
+		Implements: make([]*Interface, 0),
+		Methods:    make([]*Function, 0),
+	}
+	c.Constructor = NewFunc("New" + name)
+	c.Constructor.Return = c.Type()
+	c.Constructor.Class = c
+	return c
+}
+
+func (c Class) Parent() Node {
+	return c.parent
+}
+
+func (c *Class) SetParent(n Node) {
+	c.parent = n.(*File)
+	c.Constructor.SetParent(n)
+	for _, m := range c.Methods {
+		m.SetParent(n)
+	}
+}
+
+// Type returns type of the value created by the constructor,
+// objects are always passed as pointers.
+func (c Class) Type() Typ {
+	return NewTyp(c.QualifiedName(), true)
+}
+
+// QualifiedName returns name of the struct, including
+// the package for classes outside of the main package.
+func (c Class) QualifiedName() string {
+	if c.Package != "" {
+		return c.Package + "." + c.Name
+	}
+	return c.Name
+}
+
+// NeedsGlobal reports if at least one method uses
+// global context. Struct then keeps reference to it.
+func (c Class) NeedsGlobal() bool {
+	return c.Constructor.NeedsGlobal
+}
+
+// Root returns the class at the top of the hierarchy,
+// classes from other packages are not included.
+func (c *Class) Root() *Class {
+	for !c.isRoot() {
+		c = c.Extends
+	}
+	return c
+}
+
+func (c Class) isRoot() bool {
+	return c.Extends == nil || c.Extends.Package != c.Package
+}
+
+// SelfField is a name of the field, which keeps the object
+// created by the constructor. Overridden methods are called
+// through it, embedded struct knows nothing about its parent.
+// Classes outside of the main package export it, subclasses
+// from other packages have to set it.
+func (c Class) SelfField() string {
+	if c.Package != "" {
+		return "Self" + c.Name
+	}
+	return "self" + c.Name
+}
+
+// BaseMethod is a name of the method returning the struct,
+// it is a part of the interface of the class.
+func (c Class) BaseMethod() string {
+	if c.Package != "" {
+		return "As" + c.Name
+	}
+	return "as" + c.Name
+}
+
+// NewInterface creates the interface of the class. Besides
+// public methods, it contains method returning the struct, so
+// classes with the same methods cannot be mixed up.
+func (c *Class) NewInterface(name string) *Interface {
+	i := NewInterface(name)
+	i.Package = c.Package
+	if c.Extends != nil && c.Extends.Interface != nil {
+		i.Embeds = append(i.Embeds, c.Extends.Interface)
+	}
+
+	m := NewFunc(c.BaseMethod())
+	m.Return = c.Type()
+	c.AddMethod(m)
+	m.Body.AddStatement(&Return{Expression: NewVarRef(m.Receiver, m.Receiver.Type())})
+	i.Methods = append(i.Methods, m)
+
+	c.Interface = i
+	return i
+}
+
+// Field returns field with the given name, or nil.
+func (c Class) Field(name string) *Variable {
+	for _, f := range c.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// AddMethod adds method with the receiver "this".
+func (c *Class) AddMethod(f *Function) {
+	f.Class = c
+	f.Receiver = NewVariable("this", c.Type(), false)
+	if c.parent != nil {
+		f.SetParent(c.parent)
+	}
+	c.Methods = append(c.Methods, f)
+}
+
+// AssignField records that the value of the type t is
+// assigned to the field. Field without a type gets it
+// from the value, conflicting types turn it to interface{}.
+func (c *Class) AssignField(f *Variable, t Typ) {
+	if f.typ.Equal(Void) {
+		f.typ = t
+		f.CurrentType = t
+	} else if !f.typ.Eq(t) {
+		f.typ = NewTyp(Anything, false)
+		f.CurrentType = f.typ
+	}
+}
+
+func (c Class) String() string {
+	s := strings.Builder{}
+	s.WriteString(fmt.Sprintf("type %s struct {\n", c.Name))
+	if c.Extends != nil {
+		s.WriteString(c.Extends.QualifiedName() + "\n")
+	}
+	if c.isRoot() && c.NeedsGlobal() {
+		s.WriteString("g *global\n")
+	}
+	if c.Interface != nil {
+		s.WriteString(fmt.Sprintf("%s %s\n", c.SelfField(), c.Interface.Name))
+	}
+	for _, f := range c.Fields {
+		t := f.typ
+		if t.Equal(Void) {
+			t = NewTyp(Anything, false)
+		}
+		s.WriteString(fmt.Sprintf("%s %s\n", f.Name, t))
+	}
+	s.WriteString("}\n\n")
+
+	for _, m := range c.Methods {
+		s.WriteString(m.String())
+		s.WriteString("\n")
+	}
+
+	if c.Interface != nil {
+		s.WriteString(c.Interface.String())
+	}
+	if !c.Abstract {
+		for _, i := range c.Implements {
+			s.WriteString(fmt.Sprintf("var _ %s = (*%s)(nil)\n\n", i.Type(), c.Name))
+		}
+	}
+	if c.Array {
+		s.WriteString(gen.Array(c.Name+"Array", "*"+c.Name, "array."))
+		s.WriteString("\n")
+	}
+	return s.String()
+}
+
+// Interface is PHP interface lowered to the Go interface.
+type Interface struct {
+	parent *File
+
+	Name string
+	// Package qualifies the name, see Class.
+	Package string
+
+	Embeds  []*Interface
+	Methods []*Function
+}
+
+func NewInterface(name string) *Interface {
+	return &Interface{
+		Name:    name,
+		Embeds:  make([]*Interface, 0),
+		Methods: make([]*Function, 0),
+	}
+}
+
+func (i Interface) Parent() Node {
+	return i.parent
+}
+
+func (i *Interface) SetParent(n Node) {
+	i.parent = n.(*File)
+}
+
+// Type returns type used in the type declarations,
+// interfaces are never passed as pointers.
+func (i Interface) Type() Typ {
+	if i.Package != "" {
+		return NewTyp(i.Package+"."+i.Name, false)
+	}
+	return NewTyp(i.Name, false)
+}
+
+// Method returns method with the given name, embedded
+// interfaces are searched too.
+func (i Interface) Method(name string) *Function {
+	for _, m := range i.Methods {
+		if m.Name == name {
+			return m
+		}
+	}
+	for _, e := range i.Embeds {
+		if m := e.Method(name); m != nil {
+			return m
+		}
+	}
+	return nil
+}
+
+func (i Interface) String() string {
+	s := strings.Builder{}
+	s.WriteString(fmt.Sprintf("type %s interface {\n", i.Name))
+	for _, e := range i.Embeds {
+		s.WriteString(e.Type().String() + "\n")
+	}
+	for _, m := range i.Methods {
+		s.WriteString(m.Signature() + "\n")
+	}
+	s.WriteString("}\n\n")
+	return s.String()
+}
+
+// StructLiteral creates new empty struct,
+// it is used in the constructor.
+type StructLiteral struct {
+	parent Node
+
+	Class *Class
+}
+
+func (l StructLiteral) Parent() Node {
+	return l.parent
+}
+
+func (l *StructLiteral) SetParent(n Node) {
+	l.parent = n
+}
+
+func (l StructLiteral) Type() Typ {
+	return l.Class.Type()
+}
+
+func (l StructLiteral) String() string {
+	return "&" + literal(l.Class)
+}
+
+// literal fills the global context, which is kept
+// by the struct at the top of the hierarchy.
+func literal(c *Class) string {
+	if !c.isRoot() {
+		if p := literal(c.Extends); p != c.Extends.Name+"{}" {
+			return fmt.Sprintf("%s{%s: %s}", c.Name, c.Extends.Name, p)
+		}
+	} else if c.NeedsGlobal() {
+		return fmt.Sprintf("%s{g: g}", c.Name)
+	}
+	return c.Name + "{}"
+}
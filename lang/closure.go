@@ -0,0 +1,212 @@
+package lang
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FuncType describes the type of function values,
+// so they can be called and passed around.
+type FuncType struct {
+	Args   []Typ
+	Return Typ
+}
+
+// NewFuncTyp creates type of the function
+// with given arguments and return type.
+func NewFuncTyp(args []Typ, ret Typ) Typ {
+	s := strings.Builder{}
+	s.WriteString("func(")
+	for i, a := range args {
+		s.WriteString(a.String())
+		if i < len(args)-1 {
+			s.WriteString(", ")
+		}
+	}
+	s.WriteString(")")
+	if !ret.Equal(Void) {
+		s.WriteString(" " + ret.String())
+	}
+
+	t := NewTyp(s.String(), false)
+	t.Func = &FuncType{
+		Args:   args,
+		Return: ret,
+	}
+	return t
+}
+
+// Capture is a variable captured by value, V is
+// the copy used in the body of the literal.
+type Capture struct {
+	V     *Variable
+	Value Expression
+	// Used is set when the body references the copy.
+	Used bool
+}
+
+// FuncLit is PHP closure or arrow function lowered
+// to the Go function literal.
+type FuncLit struct {
+	// Scope is the block the literal is created in.
+	Scope Block
+	// Arrow functions capture every variable of the scope
+	// they read, closures only those from use () and $this.
+	Arrow bool
+
+	Args     []*Variable
+	Captures []*Capture
+	// References are captured by reference, Go
+	// closure shares them with the scope.
+	References []*Variable
+
+	Body Code
+
+	Return Typ
+	// Inferred is set when the return type was not
+	// declared, it is taken from the return statements.
+	Inferred bool
+}
+
+func NewFuncLit(scope Block) *FuncLit {
+	f := &FuncLit{
+		Scope:      scope,
+		Args:       make([]*Variable, 0),
+		Captures:   make([]*Capture, 0),
+		References: make([]*Variable, 0),
+		Body: Code{
+			Vars:       make([]*Variable, 0),
+			Statements: make([]Node, 0),
+
+			withBrackets: true,
+		},
+		Return: NewTyp(Void, false),
+	}
+	f.Body.SetParent(f)
+	return f
+}
+
+// Parent returns the enclosing block, so the
+// function containing the literal can be found.
+func (f FuncLit) Parent() Node {
+	return f.Scope
+}
+
+func (f *FuncLit) SetParent(n Node) {
+	if b, ok := n.(Block); ok {
+		f.Scope = b
+	}
+}
+
+func (f *FuncLit) HasVariable(name string, oos bool) *Variable {
+	if v := f.definesVariable(name); v != nil {
+		return v
+	}
+	if f.Scope == nil {
+		return nil
+	}
+	if name == "this" {
+		return f.Scope.HasVariable(name, oos)
+	}
+	if !f.Arrow {
+		return nil
+	}
+	// Arrow functions capture by value, when they are created.
+	v := f.Scope.HasVariable(name, oos)
+	if v == nil {
+		return nil
+	}
+	c := &Capture{
+		V:     NewVariable(strings.TrimPrefix(name, "g."), v.Type(), false),
+		Value: NewVarRef(v, v.Type()),
+		Used:  true,
+	}
+	f.Captures = append(f.Captures, c)
+	return c.V
+}
+
+func (f *FuncLit) DefineVariable(v *Variable) {
+	for _, vr := range f.Args {
+		if vr.Name == v.Name {
+			panic("'" + v.Name + "' redeclaration.")
+		}
+	}
+	f.Args = append(f.Args, v)
+}
+
+func (f FuncLit) definesVariable(name string) *Variable {
+	for _, a := range f.Args {
+		if a.Name == name {
+			return a
+		}
+	}
+	for _, c := range f.Captures {
+		if c.V.Name == name {
+			c.Used = true
+			return c.V
+		}
+	}
+	for _, r := range f.References {
+		if strings.TrimPrefix(r.Name, "g.") == name {
+			return r
+		}
+	}
+	return nil
+}
+
+func (f FuncLit) unset(index int) {}
+
+func (f *FuncLit) AddStatement(n Node) {
+	f.Body.AddStatement(n)
+}
+
+// SetReturn records type of the returned value,
+// conflicting types turn the return type to interface{}.
+func (f *FuncLit) SetReturn(t Typ) {
+	if !f.Inferred {
+		return
+	}
+	if f.Return.Equal(Void) {
+		f.Return = t
+	} else if !f.Return.Eq(t) {
+		f.Return = NewTyp(Anything, false)
+	}
+}
+
+func (f FuncLit) Type() Typ {
+	args := make([]Typ, len(f.Args))
+	for i, a := range f.Args {
+		args[i] = a.typ
+	}
+	return NewFuncTyp(args, f.Return)
+}
+
+// String prints the literal, values captured by value are
+// copied by the wrapping function called right away.
+func (f FuncLit) String() string {
+	s := strings.Builder{}
+	s.WriteString("func(")
+	for i, a := range f.Args {
+		s.WriteString(fmt.Sprintf("%s %s", a.Name, a.typ))
+		if i < len(f.Args)-1 {
+			s.WriteString(", ")
+		}
+	}
+	s.WriteString(")")
+	if !f.Return.Equal(Void) {
+		s.WriteString(" " + f.Return.String())
+	}
+	s.WriteString(" " + f.Body.String())
+	if len(f.Captures) == 0 {
+		return s.String()
+	}
+
+	params := make([]string, len(f.Captures))
+	values := make([]string, len(f.Captures))
+	for i, c := range f.Captures {
+		params[i] = fmt.Sprintf("%s %s", c.V.Name, c.V.typ)
+		values[i] = c.Value.String()
+	}
+	return fmt.Sprintf("func(%s) %s {\nreturn %s\n}(%s)",
+		strings.Join(params, ", "), f.Type(), s.String(), strings.Join(values, ", "))
+}
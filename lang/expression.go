@@ -11,11 +11,19 @@ type Function struct {
 
 	Args          []*Variable
 	VariadicCount bool
+	// Defaults are values of the trailing arguments,
+	// they are added to the calls omitting them.
+	Defaults []Expression
 
 	Body Code
 
 	NeedsGlobal bool
 
+	// Class is set for methods and constructors,
+	// only methods have the Receiver.
+	Class    *Class
+	Receiver *Variable
+
 	Name   string
 	Return Typ
 }
@@ -49,6 +57,9 @@ func (f *Function) DefineVariable(v *Variable) {
 }
 
 func (f Function) definesVariable(name string) *Variable {
+	if f.Receiver != nil && f.Receiver.Name == name {
+		return f.Receiver
+	}
 	for _, a := range f.Args {
 		if a.Name == name {
 			return a
@@ -62,9 +73,26 @@ func (f Function) unset(index int) {}
 func (f Function) String() string {
 	s := strings.Builder{}
 	s.WriteString("func ")
-	if f.NeedsGlobal {
+	if f.Receiver != nil {
+		s.WriteString(fmt.Sprintf("(%s %s) ", f.Receiver.Name, f.Receiver.typ))
+	} else if f.NeedsGlobal {
 		s.WriteString("(g *global) ")
 	}
+	s.WriteString(f.Signature() + " ")
+	body := f.Body.String()
+	if f.Receiver != nil && f.NeedsGlobal {
+		// Global context is reachable only through the struct.
+		body = fmt.Sprintf("{\ng := %s.g\n%s", f.Receiver.Name, strings.TrimPrefix(body, "{\n"))
+	}
+	s.WriteString(body)
+	s.WriteString("\n")
+	return s.String()
+}
+
+// Signature returns name, arguments and return type of
+// the function, as it is written in the interface.
+func (f Function) Signature() string {
+	s := strings.Builder{}
 	s.WriteString(f.Name + "(")
 	for i := 0; i < len(f.Args); i++ {
 		a := f.Args[i]
@@ -74,13 +102,11 @@ func (f Function) String() string {
 			s.WriteString(", ")
 		}
 	}
-	s.WriteString(") ")
+	s.WriteString(")")
 
 	if !f.Return.Equal(Void) {
-		s.WriteString(f.Return.String() + " ")
+		s.WriteString(" " + f.Return.String())
 	}
-	s.WriteString(f.Body.String())
-	s.WriteString("\n")
 	return s.String()
 }
 
@@ -95,6 +121,9 @@ func (f Function) Type() Typ {
 type VarRef struct {
 	parent Node
 	V      *Variable
+	// Field is set for properties, V is then
+	// only the path to the field of the object.
+	Field *Variable
 
 	typ Typ
 }
@@ -110,7 +139,11 @@ func (v *VarRef) SetParent(n Node) {
 func (v VarRef) String() string {
 	s := strings.Builder{}
 	s.WriteString(v.V.String())
-	if v.V.typ.Equal(Anything) {
+	declared := v.V.typ
+	if v.Field != nil {
+		declared = v.Field.typ
+	}
+	if declared.Equal(Anything) && !v.typ.Equal(Anything) {
 		s.WriteString(fmt.Sprintf(".(%s)", v.typ))
 	}
 	return s.String()
@@ -121,6 +154,11 @@ func (v VarRef) Type() Typ {
 }
 
 func (v *VarRef) ByReference() error {
+	if d := v.V.typ; d.IsPointer && d.typ == v.typ.typ {
+		// The variable holds the pointer already, see Declared.
+		v.typ = d
+		return nil
+	}
 	v.typ.reference = true
 	return nil
 }
@@ -132,6 +170,16 @@ func NewVarRef(v *Variable, t Typ) *VarRef {
 	}
 }
 
+// NewFieldRef refers to the field of the object. Fields
+// turned to interface{} later are asserted, like variables.
+func NewFieldRef(obj string, f *Variable) *VarRef {
+	return &VarRef{
+		V:     NewVariable(obj+"."+f.Name, f.typ, false),
+		Field: f,
+		typ:   f.CurrentType,
+	}
+}
+
 type VarDef struct {
 	parent Node
 	V      *Variable
@@ -228,11 +276,33 @@ func (r Return) Type() Typ {
 	return r.Expression.Type()
 }
 
+// String prints the return statement. Return from the try
+// statement passes the value out of the function literal,
+// catch clauses and finally set the named results.
 func (r Return) String() string {
 	s := strings.Builder{}
-	s.WriteString("return")
-	if r.Expression != nil {
-		s.WriteString(" " + r.Expression.String())
+	t, body := tryOf(r.parent)
+	switch {
+	case t == nil:
+		s.WriteString("return")
+		if r.Expression != nil {
+			s.WriteString(" " + r.Expression.String())
+		}
+
+	case body:
+		s.WriteString("return ")
+		if r.Expression != nil {
+			s.WriteString(r.Expression.String() + ", ")
+		}
+		s.WriteString("true")
+
+	default:
+		if r.Expression != nil {
+			s.WriteString(fmt.Sprintf("ret, returned = %s, true\n", r.Expression))
+		} else {
+			s.WriteString("returned = true\n")
+		}
+		s.WriteString("return")
 	}
 	return s.String()
 }
@@ -263,7 +333,15 @@ func (a Assign) Type() Typ {
 }
 
 func (a Assign) String() string {
+	if _, ok := (*a.Right).(*Nil); ok && a.FirstDefinition {
+		// Untyped nil cannot define variables.
+		return fmt.Sprintf("var %s %s", a.left, Anything)
+	}
 	s := strings.Builder{}
+	if l := a.left; l.typ.IsPointer && !l.CurrentType.IsPointer && l.typ.typ == l.CurrentType.typ {
+		// The value is read through the pointer, see Declared.
+		s.WriteString("*")
+	}
 	s.WriteString(a.left.String())
 	if a.FirstDefinition {
 		s.WriteString(" := ")
@@ -320,6 +398,27 @@ func (nb Number) String() string {
 	return nb.Value
 }
 
+// Nil is PHP null, only values without static types can hold it.
+type Nil struct {
+	parent Node
+}
+
+func (n Nil) Parent() Node {
+	return n.parent
+}
+
+func (n *Nil) SetParent(p Node) {
+	n.parent = p
+}
+
+func (n Nil) Type() Typ {
+	return NewTyp(Anything, false)
+}
+
+func (n Nil) String() string {
+	return "nil"
+}
+
 // Float and Number can be merged, only Type is different.
 // There is no way how can I write down 0.0 as some nice string
 // for := operator.
@@ -442,6 +541,14 @@ func (p BinaryOp) Type() Typ {
 	return p.typ
 }
 
+func (p BinaryOp) Left() Expression {
+	return p.left
+}
+
+func (p BinaryOp) Right() Expression {
+	return p.right
+}
+
 // See https://golang.org/ref/spec#Operator_precedence
 func (p BinaryOp) OperatorPrecedence() int {
 	switch p.Operation {
@@ -486,9 +593,6 @@ var boolOps = map[string]bool{
 	"!=": true,
 	"&&": true,
 	"||": true,
-	"^":  true,
-	"&":  true,
-	"|":  true,
 }
 
 func NewBinaryOp(op string, left, right Expression) (*BinaryOp, error) {
@@ -556,7 +660,7 @@ func (f FunctionCall) Type() Typ {
 
 func (f *FunctionCall) String() string {
 	s := strings.Builder{}
-	if f.Func != nil && f.Func.NeedsGlobal {
+	if f.Func != nil && f.Func.NeedsGlobal && f.Func.Receiver == nil {
 		s.WriteString("g.")
 	}
 	s.WriteString(f.Name)
@@ -574,3 +678,83 @@ func (f *FunctionCall) String() string {
 	s.WriteString(")")
 	return s.String()
 }
+
+// TypeAssertion checks if the value has the type,
+// it is used for PHP instanceof.
+type TypeAssertion struct {
+	parent Node
+
+	Expr Expression
+	Typ  Typ
+}
+
+func (t TypeAssertion) Parent() Node {
+	return t.parent
+}
+
+func (t *TypeAssertion) SetParent(n Node) {
+	t.parent = n
+}
+
+func (t TypeAssertion) Type() Typ {
+	return NewTyp(Bool, false)
+}
+
+func (t TypeAssertion) String() string {
+	return fmt.Sprintf("func() bool {\n_, ok := interface{}(%s).(%s)\nreturn ok\n}()", t.Expr, t.Typ)
+}
+
+// Ternary is lowered to the function literal called right
+// away, so only the chosen branch is evaluated.
+type Ternary struct {
+	parent Node
+
+	// V keeps the Value, it is evaluated before the
+	// condition. Short ternary uses it in both.
+	V     *Variable
+	Value Expression
+
+	Cond  Expression
+	True  Expression
+	False Expression
+
+	typ Typ
+}
+
+func NewTernary(cond, t, f Expression) *Ternary {
+	tr := &Ternary{
+		Cond:  cond,
+		True:  t,
+		False: f,
+		typ:   t.Type(),
+	}
+	if !t.Type().Eq(f.Type()) {
+		tr.typ = NewTyp(Anything, false)
+	}
+	cond.SetParent(tr)
+	t.SetParent(tr)
+	f.SetParent(tr)
+	return tr
+}
+
+func (t Ternary) Parent() Node {
+	return t.parent
+}
+
+func (t *Ternary) SetParent(n Node) {
+	t.parent = n
+}
+
+func (t Ternary) Type() Typ {
+	return t.typ
+}
+
+func (t Ternary) String() string {
+	s := strings.Builder{}
+	s.WriteString(fmt.Sprintf("func() %s {\nif ", t.typ))
+	if t.V != nil {
+		s.WriteString(fmt.Sprintf("%s := %s; ", t.V.Name, t.Value))
+	}
+	s.WriteString(fmt.Sprintf("%s {\nreturn %s\n}\nreturn %s\n}()", t.Cond, t.True, t.False))
+	return s.String()
+}
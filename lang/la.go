@@ -4,20 +4,35 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+
+	"github.com/lSimul/php2go/std/array/gen"
 )
 
 type GlobalContext struct {
 	Path string
 
+	// Module is the import path of the generated project,
+	// packages are imported relative to it.
+	Module string
+	// Packages maps names of the generated packages
+	// to their directories inside of the module.
+	Packages map[string]string
+
 	Files []*File
 
 	Vars []*Variable
+
+	// arrays are the nested arrays of each package,
+	// they are indexed by the type of their items.
+	arrays map[string]map[string]Typ
 }
 
 func NewGlobalContext() *GlobalContext {
 	return &GlobalContext{
-		Files: make([]*File, 0),
-		Vars:  make([]*Variable, 0),
+		Packages: make(map[string]string),
+		Files:    make([]*File, 0),
+		Vars:     make([]*Variable, 0),
+		arrays:   make(map[string]map[string]Typ),
 	}
 }
 
@@ -53,11 +68,16 @@ type File struct {
 	parent *GlobalContext
 
 	Name string
+	// Package is empty for files of the main package.
+	Package string
 
-	vars    []*Variable
-	vardefs []*VarDef
-	Funcs   map[string]*Function
-	imports []string
+	vars       []*Variable
+	vardefs    []*VarDef
+	Funcs      map[string]*Function
+	Classes    []*Class
+	Interfaces []*Interface
+	Arrays     []Typ
+	imports    []string
 
 	server   bool
 	withMain bool
@@ -74,8 +94,11 @@ func NewFile(gc *GlobalContext, name string, server, withMain bool) *File {
 		vars:    make([]*Variable, 0),
 		vardefs: make([]*VarDef, 0),
 
-		Funcs:   make(map[string]*Function, 0),
-		imports: make([]string, 0),
+		Funcs:      make(map[string]*Function, 0),
+		Classes:    make([]*Class, 0),
+		Interfaces: make([]*Interface, 0),
+		Arrays:     make([]Typ, 0),
+		imports:    make([]string, 0),
 
 		server:   server,
 		withMain: withMain,
@@ -121,6 +144,16 @@ func (f *File) Add(fc *Function) {
 	f.Funcs[fc.Name] = fc
 }
 
+func (f *File) AddClass(c *Class) {
+	c.SetParent(f)
+	f.Classes = append(f.Classes, c)
+}
+
+func (f *File) AddInterface(i *Interface) {
+	i.SetParent(f)
+	f.Interfaces = append(f.Interfaces, i)
+}
+
 func (f *File) AddImport(name string) {
 	if name == "" {
 		return
@@ -133,23 +166,99 @@ func (f *File) AddImport(name string) {
 	f.imports = append(f.imports, name)
 }
 
-func (f *File) String() string {
-	s := strings.Builder{}
+// Imports returns paths of the imported packages
+// in the order they were added.
+func (f File) Imports() []string {
+	return f.imports
+}
 
-	fn := strings.Builder{}
-	for _, f := range f.Funcs {
-		fn.WriteString(f.String())
+// DropImports removes imports added after the first n.
+func (f *File) DropImports(n int) {
+	if n < len(f.imports) {
+		f.imports = f.imports[:n]
 	}
+}
 
-	s.WriteString("package main\n\n")
+// NestedArray returns type of the array of arrays of the type
+// elem. It is declared in the file, unless the package of the
+// file already has it.
+func (f *File) NestedArray(elem Typ) Typ {
+	arrays, ok := f.parent.arrays[f.Package]
+	if !ok {
+		arrays = make(map[string]Typ)
+		f.parent.arrays[f.Package] = arrays
+	}
+	if t, ok := arrays[elem.String()]; ok {
+		return t
+	}
 
-	if len(f.imports) > 0 {
+	e := elem.String()
+	base := e[strings.LastIndex(e, ".")+1:]
+	name := base + "Array"
+	for i := 1; f.arrayDeclared(name); i++ {
+		name = fmt.Sprintf("%s%dArray", base, i)
+	}
+	if f.Package != "" {
+		name = f.Package + "." + name
+	}
+	t := NewTyp(name, false)
+	t.Elem = &elem
+	arrays[e] = t
+	f.Arrays = append(f.Arrays, t)
+	return t
+}
+
+// arrayDeclared reports if the package of the file
+// has the nested array of the name.
+func (f *File) arrayDeclared(name string) bool {
+	for _, t := range f.parent.arrays[f.Package] {
+		if n := t.String(); n[strings.LastIndex(n, ".")+1:] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Dir returns directory of the file's package
+// inside of the module, main package is in the root.
+func (f File) Dir() string {
+	if f.Package == "" || f.parent == nil {
+		return ""
+	}
+	return f.parent.Packages[f.Package]
+}
+
+func (f *File) String() string {
+	body, used := f.parent.qualify(f.body(), f.Package)
+
+	s := strings.Builder{}
+	if f.Package == "" {
+		s.WriteString("package main\n\n")
+	} else {
+		s.WriteString(fmt.Sprintf("package %s\n\n", f.Package))
+	}
+
+	if len(f.imports) > 0 || len(used) > 0 {
 		s.WriteString("import (\n")
 		for _, n := range f.imports {
 			s.WriteString("\"" + n + "\"\n")
 		}
+		for _, n := range used {
+			s.WriteString(f.parent.importSpec(n) + "\n")
+		}
 		s.WriteString(")\n\n")
 	}
+	s.WriteString(body)
+	return s.String()
+}
+
+func (f *File) body() string {
+	s := strings.Builder{}
+
+	fn := strings.Builder{}
+	for _, f := range f.Funcs {
+		fn.WriteString(f.String())
+	}
 
 	for _, v := range f.vardefs {
 		s.WriteString(v.String() + "\n")
@@ -174,6 +283,9 @@ func main() {
 			simpleIndex := true
 			main := ""
 			for _, fl := range gc.Files {
+				if fl.Main == nil {
+					continue
+				}
 				p := strings.TrimPrefix(fl.Name, gc.Path)
 				if p == "index.php" {
 					main = fl.Main.Name
@@ -248,6 +360,9 @@ func mainCLI() {
 	switch *file {
 `)
 			for _, fl := range gc.Files {
+				if fl.Main == nil {
+					continue
+				}
 				p := strings.TrimPrefix(fl.Name, gc.Path)
 				s.WriteString(`
 	case "` + p + `":
@@ -263,10 +378,15 @@ func mainCLI() {
 		} else {
 			s.WriteString(`
 func main() {
+	flag.Parse()
+
 	g := &global{}
 	switch *file {
 `)
 			for _, fl := range gc.Files {
+				if fl.Main == nil {
+					continue
+				}
 				p := strings.TrimPrefix(fl.Name, gc.Path)
 				s.WriteString(`
 	case "` + p + `":
@@ -282,6 +402,19 @@ func main() {
 		}
 	}
 
+	for _, i := range f.Interfaces {
+		s.WriteString(i.String())
+	}
+	for _, c := range f.Classes {
+		s.WriteString(c.String())
+	}
+	for _, a := range f.Arrays {
+		n, e := a.String(), a.Elem.String()
+		i := strings.LastIndex(e, ".") + 1
+		s.WriteString(gen.NestedArray(n[strings.LastIndex(n, ".")+1:], e, "array.", e[:i]+"New"+e[i:]))
+		s.WriteString("\n")
+	}
+
 	s.WriteString(fn.String())
 
 	return s.String()
@@ -992,3 +1125,24 @@ func NewIf(parent Block) *If {
 		parent: parent,
 	}
 }
+
+// Comment is a single line comment, used
+// to mark the place in the code, where
+// something could not be transpiled.
+type Comment struct {
+	parent Node
+
+	Value string
+}
+
+func (c Comment) Parent() Node {
+	return c.parent
+}
+
+func (c *Comment) SetParent(n Node) {
+	c.parent = n
+}
+
+func (c Comment) String() string {
+	return "// " + strings.ReplaceAll(c.Value, "\n", " ")
+}
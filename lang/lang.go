@@ -69,10 +69,16 @@ type Typ struct {
 
 	Addressable bool
 	Tiles       map[string]Typ
+
+	// Func is set for function values.
+	Func *FuncType
+	// Elem is set for arrays of arrays, type of
+	// their items cannot be derived from the name.
+	Elem *Typ
 }
 
 func NewTyp(typ string, IsPointer bool) Typ {
-	return Typ{typ, IsPointer, false, false, make(map[string]Typ)}
+	return Typ{typ, IsPointer, false, false, make(map[string]Typ), nil, nil}
 }
 
 func (t Typ) Format() string {
@@ -135,6 +141,12 @@ func (v Variable) Type() Typ {
 	return v.CurrentType
 }
 
+// Declared returns the type from the declaration of the variable,
+// parameters are passed by it.
+func (v Variable) Declared() Typ {
+	return v.typ
+}
+
 func NewVariable(name string, typ Typ, isConst bool) *Variable {
 	return &Variable{
 		Name:  name,
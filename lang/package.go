@@ -0,0 +1,72 @@
+package lang
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
+)
+
+// qualify removes the package name from identifiers declared in
+// the package pkg, the rest of the packages stays qualified and
+// their names are returned, so the file can import them. Names are
+// kept qualified until the file is printed, types from different
+// packages can be compared that way.
+func (gc *GlobalContext) qualify(src, pkg string) (string, []string) {
+	if gc == nil || len(gc.Packages) == 0 {
+		return src, nil
+	}
+
+	fs := token.NewFileSet()
+	f := fs.AddFile("", fs.Base(), len(src))
+	var sc scanner.Scanner
+	sc.Init(f, []byte(src), nil, 0)
+
+	s := strings.Builder{}
+	used := make(map[string]bool)
+	last := 0
+	prev := token.ILLEGAL
+	// Identifier waiting for the period, which
+	// makes it a package qualifier.
+	ident, start := "", 0
+	for {
+		pos, tok, lit := sc.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.PERIOD && ident != "" {
+			if ident == pkg {
+				s.WriteString(src[last:start])
+				last = f.Offset(pos) + 1
+			} else {
+				used[ident] = true
+			}
+		}
+		ident = ""
+		if _, ok := gc.Packages[lit]; ok && tok == token.IDENT && prev != token.PERIOD {
+			ident, start = lit, f.Offset(pos)
+		}
+		prev = tok
+	}
+	s.WriteString(src[last:])
+
+	names := make([]string, 0, len(used))
+	for n := range used {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return s.String(), names
+}
+
+// importSpec returns import of the generated package, it is
+// named when the package differs from its directory.
+func (gc *GlobalContext) importSpec(pkg string) string {
+	dir := gc.Packages[pkg]
+	p := fmt.Sprintf("%q", gc.Module+"/"+dir)
+	if path.Base(dir) != pkg {
+		return pkg + " " + p
+	}
+	return p
+}
@@ -0,0 +1,238 @@
+package lang
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Try is PHP try statement lowered to the function literal
+// called right away. Catch clauses and finally are deferred,
+// so they run even when the body panics.
+type Try struct {
+	parent Block
+
+	Body    *Code
+	Catches []*Catch
+	Finally *Code
+
+	// Returns is set when a return statement is used inside,
+	// the literal then reports the returned value.
+	Returns bool
+}
+
+func NewTry(parent Block) *Try {
+	t := &Try{
+		parent:  parent,
+		Catches: make([]*Catch, 0),
+	}
+	t.Body = NewCode(t)
+	return t
+}
+
+func (t Try) Parent() Node {
+	return t.parent
+}
+
+func (t *Try) SetParent(n Node) {
+	t.parent = n.(Block)
+}
+
+func (t Try) HasVariable(name string, oos bool) *Variable {
+	if t.parent != nil {
+		return t.parent.HasVariable(name, oos)
+	}
+	return nil
+}
+
+func (t *Try) DefineVariable(v *Variable) {
+	t.Body.DefineVariable(v)
+}
+
+func (t Try) definesVariable(name string) *Variable {
+	return nil
+}
+
+func (t Try) unset(index int) {}
+
+func (t *Try) AddStatement(n Node) {
+	t.Body.AddStatement(n)
+}
+
+func (t *Try) String() string {
+	ret := returnType(t.parent)
+	// Go requires the terminating statement, try at the end
+	// of the function returns whatever the literal returned.
+	last := false
+	if c, ok := t.parent.(*Code); ok && !ret.Equal(Void) {
+		switch c.Parent().(type) {
+		case *Function, *FuncLit:
+			last = c.Statements[len(c.Statements)-1] == Node(t)
+		}
+	}
+
+	results := "(returned bool)"
+	if !ret.Equal(Void) {
+		results = fmt.Sprintf("(ret %s, returned bool)", ret)
+	}
+
+	s := strings.Builder{}
+	if t.Returns {
+		if last {
+			s.WriteString("ret, _ := ")
+		} else if ret.Equal(Void) {
+			s.WriteString("if ")
+		} else {
+			s.WriteString("if ret, returned := ")
+		}
+		s.WriteString(fmt.Sprintf("func() %s {\n", results))
+	} else {
+		s.WriteString("func() {\n")
+	}
+
+	if t.Finally != nil {
+		s.WriteString(fmt.Sprintf("defer func() %s()\n", t.Finally))
+	}
+	if len(t.Catches) > 0 {
+		s.WriteString("defer func() {\nthrown := recover()\nif thrown == nil {\nreturn\n}\n")
+		s.WriteString("switch thrown.(type) {\n")
+		for _, c := range t.Catches {
+			s.WriteString(c.String())
+			s.WriteString("\n")
+		}
+		s.WriteString("default:\npanic(thrown)\n}\n}()\n")
+	}
+
+	body := strings.TrimPrefix(t.Body.String(), "{\n")
+	if !t.Returns {
+		s.WriteString(body)
+		s.WriteString("()")
+		return s.String()
+	}
+
+	// Named results require the terminating statement.
+	s.WriteString(strings.TrimSuffix(body, "}"))
+	if st := t.Body.Statements; len(st) == 0 || !isReturn(st[len(st)-1]) {
+		s.WriteString("return\n")
+	}
+	r := &Return{parent: t.parent}
+	if !ret.Equal(Void) {
+		r.Expression = NewVarRef(NewVariable("ret", ret, false), ret)
+	}
+	if last {
+		s.WriteString(fmt.Sprintf("}()\n%s", r))
+		return s.String()
+	}
+	s.WriteString(fmt.Sprintf("}(); returned {\n%s\n}", r))
+	return s.String()
+}
+
+// Catch is a case of the type switch over the recovered
+// value, the variable is defined only if it is used.
+type Catch struct {
+	parent *Try
+
+	Types []Typ
+	Var   *Variable
+
+	Body *Code
+}
+
+func NewCatch(parent *Try, types []Typ) *Catch {
+	c := &Catch{
+		parent: parent,
+		Types:  types,
+	}
+	c.Body = NewCode(c)
+	return c
+}
+
+func (c Catch) Parent() Node {
+	return c.parent
+}
+
+func (c *Catch) SetParent(n Node) {
+	c.parent = n.(*Try)
+}
+
+func (c Catch) HasVariable(name string, oos bool) *Variable {
+	if v := c.definesVariable(name); v != nil {
+		return v
+	}
+	return c.parent.HasVariable(name, oos)
+}
+
+func (c *Catch) DefineVariable(v *Variable) {
+	c.Body.DefineVariable(v)
+}
+
+func (c Catch) definesVariable(name string) *Variable {
+	if c.Var != nil && c.Var.Name == name {
+		return c.Var
+	}
+	return nil
+}
+
+func (c Catch) unset(index int) {}
+
+func (c *Catch) AddStatement(n Node) {
+	c.Body.AddStatement(n)
+}
+
+func (c Catch) String() string {
+	types := make([]string, len(c.Types))
+	for i, t := range c.Types {
+		types[i] = t.String()
+	}
+
+	s := strings.Builder{}
+	s.WriteString(fmt.Sprintf("case %s:\n", strings.Join(types, ", ")))
+	if c.Var != nil {
+		s.WriteString(fmt.Sprintf("%s := thrown.(%s)\n", c.Var.Name, c.Var.typ))
+	}
+	body := strings.TrimPrefix(c.Body.String(), "{\n")
+	s.WriteString(strings.TrimSuffix(body, "}"))
+	return s.String()
+}
+
+// MarkReturn marks every try statement left
+// by the return statement in the block.
+func MarkReturn(b Block) {
+	for t, _ := tryOf(b); t != nil; t, _ = tryOf(t) {
+		t.Returns = true
+	}
+}
+
+func isReturn(n Node) bool {
+	_, ok := n.(*Return)
+	return ok
+}
+
+// returnType finds the return type of the function
+// containing the node.
+func returnType(n Node) Typ {
+	for ; n != nil; n = n.Parent() {
+		switch f := n.(type) {
+		case *Function:
+			return f.Return
+		case *FuncLit:
+			return f.Return
+		}
+	}
+	return NewTyp(Void, false)
+}
+
+// tryOf finds the try statement the node is part of,
+// nil is returned when the function is reached first.
+func tryOf(n Node) (*Try, bool) {
+	for ; n != nil; n = n.Parent() {
+		switch t := n.Parent().(type) {
+		case *Function, *FuncLit, nil:
+			return nil, false
+		case *Try:
+			return t, n == t.Body
+		case *Catch:
+			return t.parent, false
+		}
+	}
+	return nil, false
+}
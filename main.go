@@ -8,48 +8,114 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 
-	"github.com/z7zmey/php-parser/node"
-	"github.com/z7zmey/php-parser/visitor"
-
 	"github.com/lSimul/php2go/lang"
 	"github.com/lSimul/php2go/p"
 )
 
+var (
+	dumpAST   = flag.Bool("dump-ast", false, "Dump parsed PHP tree to stderr.")
+	module    = flag.String("module", "", "Import path of the generated project, name of the output folder by default.")
+	unchecked = flag.Bool("unchecked", false, "Do not promote overflowing int arithmetic to floats.")
+)
+
 func main() {
 	flag.Parse()
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: php2go <php file> [<output folder>] [<anything-to-disable-server-behaviour>]")
+	args := flag.Args()
+	if len(args) < 1 {
+		fmt.Println("Usage: php2go [-dump-ast] [-module <path>] [-unchecked] <php file> [<output folder>] [<anything-to-disable-server-behaviour>]")
+		fmt.Println("       php2go check [-json] <directory>")
+		fmt.Println("       php2go build <directory> -o <output folder> [-module <path>] [-overwrite] [-cli] [-unchecked] [-php2go <folder>]")
+		return
+	}
+	if args[0] == "check" {
+		check(args[1:])
+		return
+	}
+	if args[0] == "build" {
+		build(args[1:])
 		return
 	}
 
-	p := p.NewParser(p.NewNameTranslator(), p.NewFunctionTranslator())
-	f := os.Args[1]
-	if !strings.HasPrefix(f, "./") {
+	parser := p.NewParser(p.NewNameTranslator(), p.NewFunctionTranslator())
+	if *dumpAST {
+		parser.DumpAST(os.Stderr)
+	}
+	parser.CheckOverflow(!*unchecked)
+	if *module != "" {
+		parser.Module(*module)
+	} else if len(args) > 1 {
+		parser.Module(filepath.Base(args[1]))
+	}
+	f := args[0]
+	if !strings.HasPrefix(f, "./") && !strings.HasPrefix(f, "/") {
 		f = "./" + f
 	}
-	gc := p.RunFromString(f, len(os.Args) != 4)
+	gc, diags, err := parser.RunFromString(f, len(args) != 3)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, d := range diags {
+		fmt.Fprintln(os.Stderr, d)
+	}
 
-	if len(os.Args) < 3 {
+	if len(args) < 2 {
 		for _, f := range gc.Files {
 			fmt.Println(f.String())
 		}
 	} else {
-		toFiles(gc)
+		toFiles(gc, args[1])
+	}
+
+	if p.HasErrors(diags) {
+		os.Exit(1)
 	}
 }
 
-func toFiles(gc *lang.GlobalContext) {
-	output := os.Args[2]
+// check prints compatibility report of every PHP file
+// in the directory.
+func check(args []string) {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the report in JSON.")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Println("Usage: php2go check [-json] <directory>")
+		os.Exit(2)
+	}
+
+	r, err := p.Check(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *asJSON {
+		err = r.WriteJSON(os.Stdout)
+	} else {
+		err = r.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func toFiles(gc *lang.GlobalContext, output string) {
 	if err := os.Mkdir(output, 0755); err != nil {
 		fmt.Print(err)
 		os.Exit(1)
 	}
 	for _, f := range gc.Files {
-		i := strings.LastIndex(f.Name, "/")
-		n := f.Name[i:]
-		n = strings.ReplaceAll(n, ".php", ".go")
+		n := goFile(gc, f)
+
+		// Packages created from namespaces have their own folders.
+		dir := filepath.Join(output, f.Dir())
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			fmt.Print(err)
+			os.Exit(1)
+		}
 
 		var writer bytes.Buffer
 		writer.WriteString(f.String())
@@ -58,17 +124,27 @@ func toFiles(gc *lang.GlobalContext) {
 			log.Fatal(err)
 		}
 
-		if err := ioutil.WriteFile(output+n, b, 0644); err != nil {
+		if err := ioutil.WriteFile(filepath.Join(dir, n), b, 0644); err != nil {
 			fmt.Printf("Writing output file: %v\n", err)
 			os.Exit(1)
 		}
 	}
 }
 
-func print(n node.Node) {
-	visitor := visitor.Dumper{
-		Writer: os.Stderr,
-		Indent: "",
+// goFile returns name of the Go file. Main package is in one folder,
+// its files keep the PHP path in their names. Packages have their own
+// folders, so the PHP file name is enough.
+func goFile(gc *lang.GlobalContext, f *lang.File) string {
+	n := strings.TrimPrefix(f.Name, gc.Path)
+	if f.Package != "" {
+		n = filepath.Base(n)
+	}
+	for strings.HasPrefix(n, "../") {
+		n = strings.TrimPrefix(n, "../")
 	}
-	n.Walk(&visitor)
+	n = strings.TrimPrefix(n, "/")
+	n = strings.ReplaceAll(strings.TrimSuffix(n, ".php"), "/", "_")
+	// Go ignores files starting with these.
+	n = strings.TrimLeft(n, "._")
+	return n + ".go"
 }
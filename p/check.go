@@ -0,0 +1,217 @@
+package p
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileReport contains every diagnostic found in one PHP file.
+// File is transpilable, if none of them is an error.
+type FileReport struct {
+	File         string       `json:"file"`
+	Transpilable bool         `json:"transpilable"`
+	Diagnostics  []Diagnostic `json:"diagnostics"`
+}
+
+// Totals summarize the whole report.
+type Totals struct {
+	Files        int `json:"files"`
+	Transpilable int `json:"transpilable"`
+	Errors       int `json:"errors"`
+	Warnings     int `json:"warnings"`
+
+	// UnsupportedNodes counts PHP node types,
+	// which are not supported at all.
+	UnsupportedNodes map[string]int `json:"unsupportedNodes"`
+	// UnknownFunctions counts called functions which
+	// are not known to the transpiler.
+	UnknownFunctions map[string]int `json:"unknownFunctions"`
+	// Codes counts diagnostics by their code.
+	Codes map[string]int `json:"codes"`
+}
+
+// Report is a result of the compatibility check,
+// it answers how much of the PHP code can be transpiled.
+type Report struct {
+	Files  []FileReport `json:"files"`
+	Totals Totals       `json:"totals"`
+}
+
+// Check analyses every PHP file found in the directory root.
+// Transpilation does not stop on the first issue in the
+// statement, it tries to find as many issues as possible.
+func Check(root string) (*Report, error) {
+	paths := make([]string, 0)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ".php") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string][]Diagnostic)
+	for _, path := range paths {
+		found[filepath.Clean(path)] = make([]Diagnostic, 0)
+	}
+	seen := make(map[Diagnostic]bool)
+	for _, path := range paths {
+		for _, d := range checkFile(path) {
+			d.File = filepath.Clean(d.File)
+			if seen[d] {
+				continue
+			}
+			seen[d] = true
+			found[d.File] = append(found[d.File], d)
+		}
+	}
+
+	r := &Report{
+		Files: make([]FileReport, 0, len(found)),
+		Totals: Totals{
+			UnsupportedNodes: make(map[string]int),
+			UnknownFunctions: make(map[string]int),
+			Codes:            make(map[string]int),
+		},
+	}
+	for f, ds := range found {
+		sort.Slice(ds, func(i, j int) bool {
+			if ds[i].Line != ds[j].Line {
+				return ds[i].Line < ds[j].Line
+			}
+			return ds[i].Column < ds[j].Column
+		})
+		fr := FileReport{
+			File:         f,
+			Transpilable: !HasErrors(ds),
+			Diagnostics:  ds,
+		}
+		r.Files = append(r.Files, fr)
+		r.Totals.add(fr)
+	}
+	sort.Slice(r.Files, func(i, j int) bool {
+		return r.Files[i].File < r.Files[j].File
+	})
+	return r, nil
+}
+
+func checkFile(path string) []Diagnostic {
+	parser := NewParser(NewNameTranslator(), NewFunctionTranslator())
+	parser.analysis = true
+
+	_, diags, err := parser.RunFromString(path, true)
+	switch err := err.(type) {
+	case nil:
+		return diags
+
+	case SyntaxErrors:
+		for _, e := range err {
+			diags = append(diags, Diagnostic{
+				File:     e.File,
+				Line:     e.Line,
+				Column:   e.Column,
+				Severity: Error,
+				Code:     SyntaxFailed,
+				Message:  e.Message,
+			})
+		}
+		return diags
+
+	default:
+		return []Diagnostic{{
+			File:     path,
+			Severity: Error,
+			Code:     IncludeFailed,
+			Message:  err.Error(),
+		}}
+	}
+}
+
+func (t *Totals) add(fr FileReport) {
+	t.Files++
+	if fr.Transpilable {
+		t.Transpilable++
+	}
+	for _, d := range fr.Diagnostics {
+		switch d.Severity {
+		case Error:
+			t.Errors++
+		case Warning:
+			t.Warnings++
+		}
+		t.Codes[d.Code]++
+
+		switch d.Code {
+		case UnsupportedStatement, UnsupportedExpression:
+			t.UnsupportedNodes[d.Node]++
+		case UnknownFunction:
+			t.UnknownFunctions[d.Symbol]++
+		}
+	}
+}
+
+// WriteJSON writes the report in JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "\t")
+	return e.Encode(r)
+}
+
+// WriteText writes the report in a human readable form,
+// diagnostics are grouped by file.
+func (r *Report) WriteText(w io.Writer) error {
+	s := strings.Builder{}
+	for _, f := range r.Files {
+		verdict := "transpilable"
+		if !f.Transpilable {
+			verdict = "not transpilable"
+		}
+		s.WriteString(fmt.Sprintf("%s: %s\n", f.File, verdict))
+		for _, d := range f.Diagnostics {
+			s.WriteString(fmt.Sprintf("\t%d:%d: %s: %s (%s)\n", d.Line, d.Column, d.Severity, d.Message, d.Code))
+		}
+	}
+
+	t := r.Totals
+	s.WriteString(fmt.Sprintf("\n%d files, %d transpilable, %d not transpilable\n",
+		t.Files, t.Transpilable, t.Files-t.Transpilable))
+	s.WriteString(fmt.Sprintf("%d errors, %d warnings\n", t.Errors, t.Warnings))
+	writeCounts(&s, "Unsupported nodes", t.UnsupportedNodes)
+	writeCounts(&s, "Unknown functions", t.UnknownFunctions)
+	writeCounts(&s, "Diagnostics by code", t.Codes)
+
+	_, err := io.WriteString(w, s.String())
+	return err
+}
+
+// writeCounts writes counts ordered from the most common one.
+func writeCounts(s *strings.Builder, title string, counts map[string]int) {
+	if len(counts) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	s.WriteString("\n" + title + ":\n")
+	for _, k := range keys {
+		s.WriteString(fmt.Sprintf("\t%-30s %d\n", k, counts[k]))
+	}
+}
@@ -0,0 +1,818 @@
+package p
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/z7zmey/php-parser/node"
+	"github.com/z7zmey/php-parser/node/expr"
+	"github.com/z7zmey/php-parser/node/name"
+	"github.com/z7zmey/php-parser/node/stmt"
+
+	"github.com/lSimul/php2go/lang"
+)
+
+// class keeps the PHP view of the lowered class or interface,
+// members can be found using their PHP names.
+type class struct {
+	// Class is nil for PHP interfaces.
+	*lang.Class
+
+	// iface is used in type declarations instead of the struct,
+	// it is set for PHP interfaces and classes with subclasses.
+	iface *lang.Interface
+
+	name string
+	node node.Node
+
+	parent     *class
+	interfaces []*class
+
+	members *memberTranslator
+
+	// fields are indexed by PHP names, methods by
+	// translated names, so FunctionCaller can be used.
+	// Inherited members are included.
+	fields  map[string]*lang.Variable
+	methods map[string][]*lang.Function
+
+	// typed fields have declared types, they are not
+	// widened, assigned values are converted to them.
+	typed map[*lang.Variable]bool
+
+	// private methods cannot be overridden,
+	// they are called directly.
+	private map[string]bool
+	// declared contains lowercased names of the methods
+	// declared in the class, inherited ones are missing.
+	declared map[string]bool
+
+	// construct is PHP constructor of the class with subclasses,
+	// it is a method, so subclasses can call it.
+	construct *lang.Function
+
+	defaults []propertyDefault
+	bodies   []methodBody
+}
+
+type propertyDefault struct {
+	field *lang.Variable
+	value node.Node
+}
+
+type methodBody struct {
+	f     *lang.Function
+	stmts []node.Node
+}
+
+func newClass(name string, n node.Node) *class {
+	return &class{
+		name:     name,
+		node:     n,
+		fields:   make(map[string]*lang.Variable),
+		methods:  make(map[string][]*lang.Function),
+		typed:    make(map[*lang.Variable]bool),
+		private:  make(map[string]bool),
+		declared: make(map[string]bool),
+	}
+}
+
+// inherit takes over methods of the parent.
+func (c *class) inherit(parent *class) {
+	c.members.inherit(parent.members)
+	for k, v := range parent.methods {
+		c.methods[k] = v
+	}
+}
+
+// typedField reports if the field, possibly inherited,
+// has the declared type.
+func (c *class) typedField(f *lang.Variable) bool {
+	for ; c != nil; c = c.parent {
+		if c.typed[f] {
+			return true
+		}
+	}
+	return false
+}
+
+// typ returns type used in type declarations.
+func (c *class) typ() lang.Typ {
+	if c.iface != nil {
+		return c.iface.Type()
+	}
+	return c.Type()
+}
+
+// implements reports if the values of the class
+// can be used where the type t is expected.
+func (c *class) implements(t lang.Typ) bool {
+	if c.iface != nil && c.iface.Type().Eq(t) {
+		return true
+	}
+	for _, i := range c.interfaces {
+		if i.implements(t) {
+			return true
+		}
+	}
+	return c.parent != nil && c.parent.implements(t)
+}
+
+// constructor returns PHP constructor used by the class,
+// it can be inherited.
+func (c *class) constructor() *lang.Function {
+	for ; c != nil; c = c.parent {
+		if c.construct != nil {
+			return c.construct
+		}
+	}
+	return nil
+}
+
+// hierarchy returns the class with all its
+// parents, the top most class goes first.
+func (c *class) hierarchy() []*class {
+	if c.parent == nil {
+		return []*class{c}
+	}
+	return append(c.parent.hierarchy(), c)
+}
+
+// object returns expression used to access fields of the object,
+// struct is hidden behind the interface for classes with subclasses.
+func (c *class) object(obj lang.Expression) string {
+	if c.iface != nil && obj.Type().Eq(c.iface.Type()) {
+		return fmt.Sprintf("%s.%s()", obj, c.BaseMethod())
+	}
+	return obj.String()
+}
+
+// classOf finds the class for the type of an object.
+func (p *parser) classOf(t lang.Typ) *class {
+	for _, c := range p.classes {
+		if c.typ().Eq(t) || (c.Class != nil && c.Type().Eq(t)) {
+			return c
+		}
+	}
+	return nil
+}
+
+// implements reports if the value of the type t can be
+// used where the interface is expected.
+func (p *parser) implements(t, iface lang.Typ) bool {
+	c := p.classOf(t)
+	return c != nil && c.implements(iface)
+}
+
+func modifiers(ms []node.Node) map[string]bool {
+	res := make(map[string]bool)
+	for _, m := range ms {
+		if id, ok := m.(*node.Identifier); ok {
+			res[strings.ToLower(id.Value)] = true
+		}
+	}
+	return res
+}
+
+func declarationName(n node.Node) string {
+	switch s := n.(type) {
+	case *stmt.Class:
+		return s.ClassName.(*node.Identifier).Value
+	case *stmt.Interface:
+		return s.InterfaceName.(*node.Identifier).Value
+	}
+	return ""
+}
+
+// dependencies returns lowercased qualified names of the classes
+// and interfaces the declaration builds on.
+func (p *fileParser) dependencies(n node.Node) []string {
+	names := make([]node.Node, 0)
+	switch s := n.(type) {
+	case *stmt.Class:
+		if s.Extends != nil {
+			names = append(names, s.Extends.ClassName)
+		}
+		if s.Implements != nil {
+			names = append(names, s.Implements.InterfaceNames...)
+		}
+	case *stmt.Interface:
+		if s.Extends != nil {
+			names = append(names, s.Extends.InterfaceNames...)
+		}
+	}
+
+	res := make([]string, 0, len(names))
+	for _, n := range names {
+		if isName(n) {
+			res = append(res, strings.ToLower(p.resolve(n, p.imports.classes)))
+		}
+	}
+	return res
+}
+
+// declareTypes declares classes and interfaces, so they can be used
+// in type declarations. Parents are declared before their children,
+// order in the file does not matter.
+func (p *fileParser) declareTypes(types []node.Node) []*class {
+	names := make(map[string]bool)
+	extended := make(map[string]bool)
+	for _, t := range types {
+		names[strings.ToLower(declarationName(t))] = true
+		if s, ok := t.(*stmt.Class); ok && s.Extends != nil && isName(s.Extends.ClassName) {
+			extended[strings.ToLower(p.resolve(s.Extends.ClassName, p.imports.classes))] = true
+		}
+	}
+
+	declared := make([]*class, 0, len(types))
+	pending := types
+	for len(pending) > 0 {
+		waiting := make(map[string]bool)
+		for _, t := range pending {
+			waiting[strings.ToLower(p.qualified(declarationName(t)))] = true
+		}
+
+		rest := make([]node.Node, 0)
+		for _, t := range pending {
+			ready := true
+			for _, d := range p.dependencies(t) {
+				ready = ready && !waiting[d]
+			}
+			if !ready {
+				rest = append(rest, t)
+				continue
+			}
+			p.guard(t, func() {
+				var c *class
+				switch s := t.(type) {
+				case *stmt.Class:
+					c = p.declareClass(s, extended[strings.ToLower(p.qualified(declarationName(s)))], names)
+				case *stmt.Interface:
+					c = p.declareInterface(s)
+				}
+				declared = append(declared, c)
+			})
+		}
+
+		if len(rest) == len(pending) {
+			for _, t := range rest {
+				p.guard(t, func() {
+					p.fail(t, InvalidConstruct, "%s is a part of the inheritance cycle.", declarationName(t))
+				})
+			}
+			break
+		}
+		pending = rest
+	}
+	return declared
+}
+
+// declaredClass finds already declared class or interface.
+func (p *fileParser) declaredClass(n node.Node) *class {
+	if !isName(n) {
+		p.fail(n, UnsupportedExpression, "Class name %T is not supported.", n)
+	}
+	c := p.resolveClass(n)
+	if c == nil {
+		p.fail(n, UnknownClass, "Class %s is not defined.", p.resolve(n, p.imports.classes))
+	}
+	return c
+}
+
+func (p *fileParser) declaredInterface(n node.Node) *class {
+	c := p.declaredClass(n)
+	if c.Class != nil {
+		p.fail(n, InvalidConstruct, "%s is not an interface.", c.name)
+	}
+	return c
+}
+
+// declareClass declares the struct. Class with subclasses
+// gets an interface too, it is used in type declarations.
+func (p *fileParser) declareClass(s *stmt.Class, extended bool, names map[string]bool) *class {
+	pn := s.ClassName.(*node.Identifier).Value
+	if _, ok := p.classes[strings.ToLower(p.qualified(pn))]; ok {
+		p.fail(s, InvalidConstruct, "Class %s is already declared.", pn)
+	}
+
+	c := newClass(pn, s)
+	c.Class = lang.NewClass(FirstUpper(pn))
+	c.Package = p.file.Package
+	c.Constructor.Return = c.Type()
+	c.Abstract = modifiers(s.Modifiers)["abstract"]
+	c.members = newMemberTranslator()
+	// Subclasses from other packages have to reach protected members.
+	c.members.exported = c.Package != ""
+
+	if s.Extends != nil {
+		c.parent = p.declaredClass(s.Extends.ClassName)
+		if c.parent.Class == nil {
+			p.fail(s.Extends, InvalidConstruct, "Class %s cannot extend interface %s.", pn, c.parent.name)
+		}
+		if c.parent.iface == nil {
+			p.fail(s.Extends, InvalidConstruct, "Class %s cannot be extended outside of its file.", c.parent.name)
+		}
+		c.Extends = c.parent.Class
+	}
+	if s.Implements != nil {
+		for _, n := range s.Implements.InterfaceNames {
+			i := p.declaredInterface(n)
+			c.interfaces = append(c.interfaces, i)
+			c.Implements = append(c.Implements, i.iface)
+		}
+	}
+
+	p.classes[strings.ToLower(p.qualified(pn))] = c
+	p.file.AddClass(c.Class)
+	// Classes of packages can be extended from any file,
+	// they are always prepared for subclasses.
+	if extended || c.Abstract || c.Package != "" {
+		n := FirstUpper(pn) + "Interface"
+		for i := 1; names[strings.ToLower(n)]; i++ {
+			n = fmt.Sprintf("%sInterface%d", FirstUpper(pn), i)
+		}
+		names[strings.ToLower(n)] = true
+
+		c.iface = c.NewInterface(n)
+		c.members.Reserve(c.SelfField())
+		c.members.Reserve(c.BaseMethod())
+	}
+	if !c.Abstract {
+		p.funcs.Add(c.Constructor.Name, c.Constructor, 0)
+	}
+	return c
+}
+
+// declareInterface declares the Go interface,
+// extended interfaces are embedded.
+func (p *fileParser) declareInterface(s *stmt.Interface) *class {
+	pn := s.InterfaceName.(*node.Identifier).Value
+	if _, ok := p.classes[strings.ToLower(p.qualified(pn))]; ok {
+		p.fail(s, InvalidConstruct, "Interface %s is already declared.", pn)
+	}
+
+	c := newClass(pn, s)
+	c.iface = lang.NewInterface(FirstUpper(pn))
+	c.iface.Package = p.file.Package
+	c.members = newMemberTranslator()
+	c.members.exported = c.iface.Package != ""
+	if s.Extends != nil {
+		for _, n := range s.Extends.InterfaceNames {
+			i := p.declaredInterface(n)
+			c.interfaces = append(c.interfaces, i)
+			c.iface.Embeds = append(c.iface.Embeds, i.iface)
+		}
+	}
+
+	p.classes[strings.ToLower(p.qualified(pn))] = c
+	p.file.AddInterface(c.iface)
+	return c
+}
+
+// classMembers declares fields and method signatures,
+// bodies are created by classBody. Parents have to
+// have their members declared already.
+func (p *fileParser) classMembers(c *class) {
+	var stmts []node.Node
+	switch s := c.node.(type) {
+	case *stmt.Class:
+		stmts = s.Stmts
+		if c.parent != nil {
+			c.inherit(c.parent)
+			for k, v := range c.parent.fields {
+				c.fields[k] = v
+			}
+			for k, v := range c.parent.private {
+				c.private[k] = v
+			}
+		}
+	case *stmt.Interface:
+		stmts = s.Stmts
+		for _, i := range c.interfaces {
+			c.inherit(i)
+		}
+	}
+
+	for _, st := range stmts {
+		p.guard(st, func() {
+			switch st := st.(type) {
+			case *stmt.PropertyList:
+				p.propertyDef(c, st)
+
+			case *stmt.ClassMethod:
+				p.methodDef(c, st)
+
+			default:
+				p.fail(st, UnsupportedStatement, "%T is not supported in the class.", st)
+			}
+		})
+	}
+
+	if c.Class == nil || !c.Abstract {
+		return
+	}
+	// Abstract class does not have to implement methods
+	// of its interfaces, they can be called anyway.
+	for _, i := range c.interfaces {
+		names := make([]string, 0, len(i.methods))
+		for n := range i.methods {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			if _, ok := c.methods[n]; ok {
+				continue
+			}
+			c.methods[n] = i.methods[n]
+			if c.iface.Method(n) == nil {
+				c.iface.Methods = append(c.iface.Methods, i.methods[n][0])
+			}
+		}
+	}
+}
+
+func (p *fileParser) propertyDef(c *class, pl *stmt.PropertyList) {
+	if c.Class == nil {
+		p.fail(pl, InvalidConstruct, "Interfaces cannot have properties.")
+	}
+	mods := modifiers(pl.Modifiers)
+	if mods["static"] {
+		p.fail(pl, UnsupportedStatement, "Static properties are not supported.")
+	}
+	private := mods["private"]
+	public := !private && !mods["protected"]
+
+	typ := lang.NewTyp(lang.Void, false)
+	if pl.Type != nil {
+		typ = p.typeName(pl.Type, false)
+	}
+
+	for _, pr := range pl.Properties {
+		pr := pr.(*stmt.Property)
+		pn := pr.Variable.(*expr.Variable).VarName.(*node.Identifier).Value
+
+		// Redeclared inherited property keeps the field
+		// of the parent, only the default value changes.
+		f, ok := c.fields[pn]
+		if !ok {
+			f = lang.NewVariable(c.members.Field(pn, public, private), typ, false)
+			c.Fields = append(c.Fields, f)
+			c.fields[pn] = f
+			c.typed[f] = pl.Type != nil
+		} else if c.parent == nil || c.parent.fields[pn] != f {
+			p.fail(pr, InvalidConstruct, "Property $%s is already declared.", pn)
+		}
+
+		if pr.Expr != nil {
+			c.defaults = append(c.defaults, propertyDefault{f, pr.Expr})
+		}
+	}
+}
+
+func (p *fileParser) methodDef(c *class, m *stmt.ClassMethod) {
+	mods := modifiers(m.Modifiers)
+	if mods["static"] {
+		p.fail(m, UnsupportedStatement, "Static methods are not supported.")
+	}
+	if mods["abstract"] && (c.Class == nil || !c.Abstract) {
+		p.fail(m, InvalidConstruct, "Abstract method in %s, which is not an abstract class.", c.name)
+	}
+	abstract := mods["abstract"] || c.Class == nil
+	private := mods["private"]
+	public := !private && !mods["protected"]
+
+	pn := m.MethodName.(*node.Identifier).Value
+	if c.declared[strings.ToLower(pn)] {
+		p.fail(m, InvalidConstruct, "Method %s is already declared.", pn)
+	}
+	c.declared[strings.ToLower(pn)] = true
+
+	if strings.ToLower(pn) == "__construct" {
+		if abstract {
+			p.fail(m, UnsupportedStatement, "Abstract constructors are not supported.")
+		}
+		p.constructorDef(c, m)
+		return
+	}
+
+	f := lang.NewFunc(c.members.Method(pn, public, private))
+	if defaults := p.params(f, m.Params); len(defaults) > 0 {
+		p.fail(m, UnsupportedStatement, "Default parameters are not supported in methods.")
+	}
+	if m.ReturnType != nil {
+		f.Return = p.returnType(m.ReturnType)
+	}
+	if inherited, ok := c.methods[f.Name]; ok && !sameSignature(inherited[0], f) {
+		p.fail(m, TypeMismatch, "Method %s has to keep the signature of the overridden method.", pn)
+	}
+
+	c.methods[f.Name] = []*lang.Function{f}
+	c.private[f.Name] = private
+	if c.iface != nil && !private && c.iface.Method(f.Name) == nil {
+		c.iface.Methods = append(c.iface.Methods, f)
+	}
+	if abstract {
+		return
+	}
+	c.AddMethod(f)
+	c.bodies = append(c.bodies, methodBody{f, nodeList(m.Stmt)})
+}
+
+// constructorDef defines PHP constructor. Class without subclasses
+// has it inlined in the Go constructor, the rest gets a method.
+func (p *fileParser) constructorDef(c *class, m *stmt.ClassMethod) {
+	f := c.Constructor
+	if c.iface != nil {
+		f = lang.NewFunc(c.members.Method("__construct", false, false))
+		c.AddMethod(f)
+		c.construct = f
+	}
+
+	if defaults := p.params(f, m.Params); len(defaults) > 0 {
+		p.fail(m, UnsupportedStatement, "Default parameters are not supported in methods.")
+	}
+	c.bodies = append(c.bodies, methodBody{f, nodeList(m.Stmt)})
+}
+
+func sameSignature(f, g *lang.Function) bool {
+	if len(f.Args) != len(g.Args) || !f.Return.Eq(g.Return) {
+		return false
+	}
+	for i := range f.Args {
+		if !f.Args[i].Type().Eq(g.Args[i].Type()) {
+			return false
+		}
+	}
+	return true
+}
+
+// classBody creates the constructor and bodies of the methods.
+// Constructor goes first, it usually defines types of the fields.
+func (p *fileParser) classBody(c *class) {
+	if c.Class == nil {
+		return
+	}
+	if !c.Abstract {
+		p.constructorBody(c)
+	}
+	for _, m := range c.bodies {
+		if m.f != c.Constructor {
+			p.createFunction(&m.f.Body, m.stmts)
+		}
+	}
+}
+
+// constructorBody creates the struct, sets default values of the
+// properties and calls the PHP constructor, if there is any.
+func (p *fileParser) constructorBody(c *class) {
+	ctor := c.Constructor
+	this := lang.NewVariable("this", c.Type(), false)
+	a, _ := lang.NewAssign(this, &lang.StructLiteral{Class: c.Class})
+	a.FirstDefinition = true
+	this.FirstDefinition = a
+	ctor.Body.AddStatement(a)
+	ctor.Body.DefineVariable(this)
+	ref := lang.NewVarRef(this, this.Type())
+
+	for _, h := range c.hierarchy() {
+		for _, d := range h.defaults {
+			p.guard(d.value, func() {
+				e := p.declaredValue(&ctor.Body, d.value, d.field.Type())
+				ctor.Body.AddStatement(p.assignField(h, d.field, ref.String(), e))
+			})
+		}
+	}
+	for _, h := range c.hierarchy() {
+		// Methods of std classes cannot be overridden.
+		if h.iface == nil || h.Package == "std" {
+			continue
+		}
+		s, _ := lang.NewAssign(lang.NewVariable(ref.String()+"."+h.SelfField(), h.iface.Type(), false), ref)
+		ctor.Body.AddStatement(s)
+	}
+
+	inlined := false
+	for _, m := range c.bodies {
+		if m.f == ctor {
+			p.createFunction(&ctor.Body, m.stmts)
+			inlined = true
+		}
+	}
+	if construct := c.constructor(); !inlined && construct != nil {
+		args := make([]lang.Expression, 0, len(construct.Args))
+		for _, a := range construct.Args {
+			v := lang.NewVariable(a.Name, a.Type(), false)
+			ctor.Args = append(ctor.Args, v)
+			args = append(args, lang.NewVarRef(v, v.Type()))
+		}
+		ctor.Defaults = construct.Defaults
+		ctor.Body.AddStatement(&lang.FunctionCall{
+			Func:   construct,
+			Name:   ref.String() + "." + construct.Name,
+			Args:   args,
+			Return: construct.Return,
+		})
+	}
+
+	st := ctor.Body.Statements
+	if _, ok := st[len(st)-1].(*lang.Return); !ok {
+		ctor.Body.AddStatement(&lang.Return{Expression: ref})
+	}
+}
+
+func (p *fileParser) newObject(b lang.Block, e *expr.New) lang.Expression {
+	c := p.declaredClass(e.Class)
+	if c.Class == nil {
+		p.fail(e, InvalidConstruct, "Cannot instantiate interface %s.", c.name)
+	}
+	if c.Abstract {
+		p.fail(e, InvalidConstruct, "Cannot instantiate abstract class %s.", c.name)
+	}
+
+	var args []lang.Expression
+	if e.ArgumentList != nil {
+		args = p.arguments(b, e.ArgumentList)
+	}
+	f, err := p.funcs.Namespace(c.Package).Call(c.Constructor.Name, args)
+	if err != nil {
+		p.fail(e, TypeMismatch, "%v", err)
+	}
+	if c.NeedsGlobal() {
+		p.requireGlobal(b)
+	}
+	f.SetParent(b)
+	return f
+}
+
+// propertyFetch finds the property of the object, it returns
+// expression used to access the struct too.
+func (p *fileParser) propertyFetch(b lang.Block, e *expr.PropertyFetch) (*class, *lang.Variable, string) {
+	obj := p.expression(b, e.Variable)
+	c := p.classOf(obj.Type())
+	if c == nil || c.Class == nil {
+		p.fail(e.Variable, TypeMismatch, "Trying to get property of non-object '%s'.", obj.Type())
+	}
+
+	id, ok := e.Property.(*node.Identifier)
+	if !ok {
+		p.fail(e.Property, UnsupportedExpression, "Only simple property names are supported.")
+	}
+	f, ok := c.fields[id.Value]
+	if !ok {
+		p.fail(e.Property, UndefinedVariable, "Undefined property %s::$%s.", c.name, id.Value)
+	}
+	return c, f, c.object(obj)
+}
+
+func (p *fileParser) property(b lang.Block, e *expr.PropertyFetch) lang.Expression {
+	_, f, obj := p.propertyFetch(b, e)
+	if f.Type().Equal(lang.Void) {
+		p.fail(e, TypeMismatch, "Type of the property $%s is not known yet.", e.Property.(*node.Identifier).Value)
+	}
+	ref := lang.NewFieldRef(obj, f)
+	ref.SetParent(b)
+	return ref
+}
+
+// assignField infers type of the field from the assigned
+// value, the same way it is done with variables.
+func (p *fileParser) assignField(c *class, f *lang.Variable, obj string, value lang.Expression) *lang.Assign {
+	if c.typedField(f) {
+		if v, ok := p.funcs.coerce(value, f.Type()); ok {
+			value = v
+		}
+	}
+	if f.Type().Equal(lang.Void) || !p.implements(value.Type(), f.Type()) {
+		c.AssignField(f, value.Type())
+	}
+	a, err := lang.NewAssign(lang.NewVariable(obj+"."+f.Name, f.Type(), false), value)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// fieldStep lowers ++ and -- of the property. Checked
+// arithmetic and values without static types are assigned
+// back to the property, like with $this->n += 1.
+func (p *fileParser) fieldStep(b lang.Block, op string, e *expr.PropertyFetch) lang.Node {
+	c, f, obj := p.propertyFetch(b, e)
+	ref := p.property(b, e).(*lang.VarRef)
+	if t := ref.Type(); !t.IsPointer && (t.Equal(lang.Anything) || p.checkOverflow && t.Equal(lang.Int)) {
+		a := p.assignField(c, f, obj, p.arithmetic(b, op, ref, &lang.Number{Value: "1"}))
+		a.SetParent(b)
+		return a
+	}
+
+	strV := func(fn string) func(lang.Expression) (lang.Expression, error) {
+		return func(e lang.Expression) (lang.Expression, error) {
+			return p.funcs.Namespace("std").Call(fn, []lang.Expression{e})
+		}
+	}
+	if op == "+" {
+		return lang.NewInc(b, ref, strV("StrInc"))
+	}
+	return lang.NewDec(b, ref, strV("StrDec"))
+}
+
+func (p *fileParser) methodCall(b lang.Block, e *expr.MethodCall) lang.Expression {
+	obj := p.expression(b, e.Variable)
+	c := p.classOf(obj.Type())
+	if c == nil {
+		p.fail(e.Variable, TypeMismatch, "Call to a method on non-object '%s'.", obj.Type())
+	}
+
+	id, ok := e.Method.(*node.Identifier)
+	if !ok {
+		p.fail(e.Method, UnsupportedExpression, "Only simple method names are supported.")
+	}
+	n, _ := c.members.LookupMethod(id.Value)
+	if _, ok := c.methods[n]; !ok {
+		p.fail(e, UnknownFunction, "Call to undefined method %s::%s().", c.name, id.Value)
+	}
+
+	ns := obj.String()
+	// Embedded struct does not know about overridden methods,
+	// they are called through the object created by constructor.
+	if v, ok := e.Variable.(*expr.Variable); ok && c.Class != nil && c.iface != nil &&
+		!c.private[n] && obj.Type().Eq(c.Type()) && p.identifierName(v) == "this" {
+		ns += "." + c.SelfField()
+	}
+
+	caller := &FunctionCaller{
+		namespace:  ns,
+		Func:       &c.methods,
+		implements: p.implements,
+		coerce:     p.funcs.coerce,
+	}
+	f, err := caller.Call(n, p.arguments(b, e.ArgumentList))
+	if err != nil {
+		p.fail(e, TypeMismatch, "%v", err)
+	}
+	f.SetParent(b)
+	return f
+}
+
+// staticCall supports only parent::method(), the method
+// of the embedded struct is called.
+func (p *fileParser) staticCall(b lang.Block, e *expr.StaticCall) lang.Expression {
+	nm, ok := e.Class.(*name.Name)
+	if !ok || strings.ToLower(nameOf(nm)) != "parent" {
+		p.fail(e, UnsupportedExpression, "Only parent:: calls are supported.")
+	}
+	f := functionOf(b)
+	var c *class
+	if f.Class != nil {
+		c = p.classOf(f.Class.Type())
+	}
+	if c == nil || c.parent == nil {
+		p.fail(e, InvalidConstruct, "Cannot use parent:: when current class scope has no parent.")
+	}
+	id, ok := e.Call.(*node.Identifier)
+	if !ok {
+		p.fail(e.Call, UnsupportedExpression, "Only simple method names are supported.")
+	}
+
+	methods := &c.parent.methods
+	n, ok := c.parent.members.LookupMethod(id.Value)
+	if strings.ToLower(id.Value) == "__construct" {
+		m := c.parent.constructor()
+		if m == nil {
+			p.fail(e, InvalidConstruct, "Cannot call constructor, %s does not have any.", c.parent.name)
+		}
+		n = m.Name
+		methods = &map[string][]*lang.Function{n: {m}}
+	} else if _, exists := (*methods)[n]; !ok || !exists {
+		p.fail(e, UnknownFunction, "Call to undefined method %s::%s().", c.parent.name, id.Value)
+	} else if (*methods)[n][0].Receiver == nil {
+		p.fail(e, InvalidConstruct, "Cannot call abstract method %s::%s().", c.parent.name, id.Value)
+	}
+
+	this := b.HasVariable("this", true)
+	caller := &FunctionCaller{
+		namespace:  this.Name + "." + c.parent.Name,
+		Func:       methods,
+		implements: p.implements,
+		coerce:     p.funcs.coerce,
+	}
+	fc, err := caller.Call(n, p.arguments(b, e.ArgumentList))
+	if err != nil {
+		p.fail(e, TypeMismatch, "%v", err)
+	}
+	fc.SetParent(b)
+	return fc
+}
+
+// instanceOf is lowered to the type assertion, classes with
+// subclasses are checked using their interface.
+func (p *fileParser) instanceOf(b lang.Block, e *expr.InstanceOf) lang.Expression {
+	c := p.declaredClass(e.Class)
+	t := &lang.TypeAssertion{
+		Expr: p.expression(b, e.Expr),
+		Typ:  c.typ(),
+	}
+	t.Expr.SetParent(t)
+	t.SetParent(b)
+	return t
+}
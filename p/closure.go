@@ -0,0 +1,174 @@
+package p
+
+import (
+	"github.com/lSimul/php2go/lang"
+	"github.com/z7zmey/php-parser/node"
+	"github.com/z7zmey/php-parser/node/expr"
+)
+
+// closure is lowered to the function literal. Variables
+// from use () captured by value are copied when the literal
+// is created and again on every call, so changes do not
+// survive the call. The ones captured by reference are shared.
+func (p *fileParser) closure(b lang.Block, e *expr.Closure) lang.Expression {
+	if e.ReturnsRef {
+		p.fail(e, UnsupportedExpression, "Closures returning reference are not supported.")
+	}
+	l := p.funcLit(b, e.Params, e.ReturnType)
+
+	if e.ClosureUse != nil {
+		for _, u := range e.ClosureUse.Uses {
+			byRef := false
+			if r, ok := u.(*expr.Reference); ok {
+				u = r.Variable
+				byRef = true
+			}
+			name := p.identifierName(u.(*expr.Variable))
+			v := b.HasVariable(name, true)
+			if v == nil {
+				p.fail(u, UndefinedVariable, "Using undefined variable \"%s\".", name)
+			}
+			if l.HasVariable(name, false) != nil {
+				p.fail(u, InvalidConstruct, "Cannot use variable $%s twice.", name)
+			}
+
+			if byRef {
+				l.References = append(l.References, v)
+				continue
+			}
+			l.Captures = append(l.Captures, &lang.Capture{
+				V:     lang.NewVariable(name, v.Type(), false),
+				Value: copyArray(lang.NewVarRef(v, v.Type())),
+			})
+		}
+	}
+
+	p.createFunction(&l.Body, e.Stmts)
+	rebindCaptures(l)
+	return l
+}
+
+// rebindCaptures copies captured values at the start
+// of the body, so changes do not survive the call.
+func rebindCaptures(l *lang.FuncLit) {
+	for i := len(l.Captures) - 1; i >= 0; i-- {
+		c := l.Captures[i]
+		if !c.Used {
+			continue
+		}
+		a, err := lang.NewAssign(c.V, copyArray(lang.NewVarRef(c.V, c.V.Type())))
+		if err != nil {
+			panic(err)
+		}
+		a.FirstDefinition = true
+		a.SetParent(&l.Body)
+		l.Body.Statements = append([]lang.Node{a}, l.Body.Statements...)
+	}
+}
+
+// arrowFunction is lowered to the function literal returning
+// the expression. Variables of the scope read by the expression
+// are captured by value when the literal is created, like
+// the ones from use () of closures.
+func (p *fileParser) arrowFunction(b lang.Block, e *expr.ArrowFunction) lang.Expression {
+	if e.ReturnsRef {
+		p.fail(e, UnsupportedExpression, "Arrow functions returning reference are not supported.")
+	}
+	l := p.funcLit(b, e.Params, e.ReturnType)
+	l.Arrow = true
+
+	r := &lang.Return{
+		Expression: p.expression(&l.Body, e.Expr),
+	}
+	// Values without static types, like results
+	// of arithmetic, get the declared type.
+	if !l.Inferred {
+		if c, ok := p.funcs.coerce(r.Expression, l.Return); ok {
+			r.Expression = c
+		}
+	}
+	l.SetReturn(r.Expression.Type())
+	l.AddStatement(r)
+	for _, c := range l.Captures {
+		c.Value = copyArray(c.Value)
+	}
+	rebindCaptures(l)
+	return l
+}
+
+// funcLit creates the literal with parameters, return type
+// is inferred from the body if it is not declared.
+func (p *fileParser) funcLit(b lang.Block, params []node.Node, ret node.Node) *lang.FuncLit {
+	l := lang.NewFuncLit(b)
+
+	f := lang.NewFunc("")
+	if defaults := p.params(f, params); len(defaults) > 0 {
+		p.fail(params[0], UnsupportedExpression, "Default parameters of closures are not supported.")
+	}
+	l.Args = f.Args
+
+	if ret != nil {
+		l.Return = p.returnType(ret)
+	} else {
+		l.Inferred = true
+	}
+	return l
+}
+
+// literalOf finds the function literal containing the block,
+// nil is returned for blocks directly in the function.
+func literalOf(b lang.Block) *lang.FuncLit {
+	var bl lang.Node = b
+	for bl != nil {
+		switch f := bl.(type) {
+		case *lang.FuncLit:
+			return f
+		case *lang.Function:
+			return nil
+		}
+		bl = bl.Parent()
+	}
+	return nil
+}
+
+// callValue calls the function stored in the variable,
+// arguments are checked against its type. Arrays passed
+// by value are copied, like by FunctionCaller.Call.
+func (p *fileParser) callValue(b lang.Block, e *expr.FunctionCall) lang.Expression {
+	fn := p.expression(b, e.Function)
+	t := fn.Type().Func
+	if t == nil {
+		p.fail(e, TypeMismatch, "Value of type %s is not callable.", fn.Type())
+	}
+
+	args := p.arguments(b, e.ArgumentList)
+	if len(args) != len(t.Args) {
+		p.fail(e, TypeMismatch, "Function expects %d arguments, %d given.", len(t.Args), len(args))
+	}
+	for i, a := range args {
+		if a.Type().Eq(t.Args[i]) || p.implements(a.Type(), t.Args[i]) {
+			continue
+		}
+		if c, ok := p.funcs.coerce(a, t.Args[i]); ok {
+			args[i] = c
+			continue
+		}
+		p.fail(e, TypeMismatch, "Argument %d: expected %s, got %s.", i+1, t.Args[i], a.Type())
+	}
+	for i := range args {
+		if !t.Args[i].IsPointer {
+			args[i] = copyArray(args[i])
+		}
+	}
+
+	f := &lang.FunctionCall{
+		Name:   fn.String(),
+		Args:   args,
+		Return: t.Return,
+	}
+	for _, a := range args {
+		a.SetParent(f)
+	}
+	f.SetParent(b)
+	return f
+}
@@ -0,0 +1,224 @@
+package p
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/z7zmey/php-parser/errors"
+	"github.com/z7zmey/php-parser/node"
+)
+
+// Severity tells how serious the found issue is.
+type Severity int
+
+const (
+	// Error means the construct was not transpiled,
+	// placeholder was emitted instead of it.
+	Error Severity = iota
+	// Warning means the construct was transpiled,
+	// but the result may behave differently.
+	Warning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// MarshalJSON writes severity as a readable string.
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + s.String() + `"`), nil
+}
+
+// Codes used in Diagnostic, they group issues of
+// the same kind together.
+const (
+	UnsupportedStatement  = "unsupported-statement"
+	UnsupportedExpression = "unsupported-expression"
+	UndefinedVariable     = "undefined-variable"
+	UnknownFunction       = "unknown-function"
+	UnknownClass          = "unknown-class"
+	TypeMismatch          = "type-mismatch"
+	InvalidConstruct      = "invalid-construct"
+	InvalidAnnotation     = "invalid-annotation"
+	IncludeFailed         = "include-failed"
+	SyntaxFailed          = "syntax-error"
+	Internal              = "internal"
+)
+
+// Diagnostic describes one issue found during the
+// transpilation, it points to the source PHP file.
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	// Node is a type of the PHP node which caused
+	// the issue, e.g. "*stmt.Class".
+	Node string `json:"node,omitempty"`
+	// Symbol is a name of the unknown function,
+	// if the code is UnknownFunction.
+	Symbol  string `json:"symbol,omitempty"`
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s (%s)", d.File, d.Line, d.Column, d.Severity, d.Message, d.Code)
+}
+
+// Error makes Diagnostic usable as a panic value,
+// which is recovered at the statement level.
+func (d *Diagnostic) Error() string {
+	return d.String()
+}
+
+// SyntaxError points to the place, where
+// the PHP source cannot be parsed.
+type SyntaxError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e SyntaxError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+}
+
+// SyntaxErrors contains every syntax error
+// found in one file.
+type SyntaxErrors []SyntaxError
+
+func (e SyntaxErrors) Error() string {
+	s := make([]string, 0, len(e))
+	for _, se := range e {
+		s = append(s, se.Error())
+	}
+	return strings.Join(s, "\n")
+}
+
+func newSyntaxErrors(path string, src []byte, errs []*errors.Error) SyntaxErrors {
+	res := make(SyntaxErrors, 0, len(errs))
+	for _, e := range errs {
+		se := SyntaxError{
+			File:    path,
+			Message: e.Msg,
+		}
+		if e.Pos != nil {
+			se.Line = e.Pos.StartLine
+			se.Column = column(src, e.Pos.StartPos)
+		}
+		res = append(res, se)
+	}
+	return res
+}
+
+// column converts byte offset to the column,
+// columns are counted from one.
+func column(src []byte, pos int) int {
+	if pos > len(src) {
+		return 0
+	}
+	return pos - bytes.LastIndexByte(src[:pos], '\n')
+}
+
+// HasErrors reports if at least one diagnostic
+// has severity Error.
+func HasErrors(ds []Diagnostic) bool {
+	for _, d := range ds {
+		if d.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// fail stops transpilation of the current statement,
+// the issue is recorded as a diagnostic located at n.
+func (p *fileParser) fail(n node.Node, code, format string, args ...interface{}) {
+	d := &Diagnostic{
+		Severity: Error,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	}
+	p.locate(d, n)
+	panic(d)
+}
+
+// record adds the diagnostic without stopping the transpilation.
+func (p *fileParser) record(n node.Node, s Severity, code, format string, args ...interface{}) *Diagnostic {
+	d := &Diagnostic{
+		Severity: s,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	}
+	p.locate(d, n)
+	p.diagnostics = append(p.diagnostics, *d)
+	return &p.diagnostics[len(p.diagnostics)-1]
+}
+
+// locate fills the position of the diagnostic from
+// the node, if the node has one.
+func (p *fileParser) locate(d *Diagnostic, n node.Node) {
+	if p.file != nil {
+		d.File = p.file.Name
+	}
+	if n == nil {
+		return
+	}
+	d.Node = fmt.Sprintf("%T", n)
+
+	pos := n.GetPosition()
+	if pos == nil {
+		return
+	}
+	d.Line = pos.StartLine
+	d.Column = column(p.src, pos.StartPos)
+}
+
+// diagnose turns recovered panic into a diagnostic.
+// Panics without any position are located at n.
+func (p *fileParser) diagnose(n node.Node, r interface{}) Diagnostic {
+	d, ok := r.(*Diagnostic)
+	if ok && d.Line != 0 {
+		return *d
+	}
+	if !ok {
+		d = &Diagnostic{
+			Severity: Error,
+			Code:     InvalidConstruct,
+			Message:  fmt.Sprint(r),
+		}
+		if _, ok := r.(error); ok {
+			if _, ok := r.(interface{ RuntimeError() }); ok {
+				d.Code = Internal
+			}
+		}
+	}
+	node := d.Node
+	p.locate(d, n)
+	if node != "" {
+		d.Node = node
+	}
+	return *d
+}
+
+// guard runs f, possible panic is recorded as a diagnostic
+// located at n. It reports if f finished successfully.
+func (p *fileParser) guard(n node.Node, f func()) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			p.diagnostics = append(p.diagnostics, p.diagnose(n, r))
+			ok = false
+		}
+	}()
+	f()
+	return true
+}
@@ -0,0 +1,220 @@
+package p
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/z7zmey/php-parser/node"
+	"github.com/z7zmey/php-parser/node/expr"
+	"github.com/z7zmey/php-parser/node/stmt"
+	"github.com/z7zmey/php-parser/walker"
+
+	"github.com/lSimul/php2go/lang"
+)
+
+// exceptions are PHP exceptions implemented in std,
+// every one of them is listed after its parent.
+var exceptions = []struct {
+	name, parent string
+}{
+	{"Exception", ""},
+	{"LogicException", "Exception"},
+	{"InvalidArgumentException", "LogicException"},
+	{"DomainException", "LogicException"},
+	{"LengthException", "LogicException"},
+	{"OutOfRangeException", "LogicException"},
+	{"RuntimeException", "Exception"},
+	{"OutOfBoundsException", "RuntimeException"},
+	{"OverflowException", "RuntimeException"},
+	{"RangeException", "RuntimeException"},
+	{"UnderflowException", "RuntimeException"},
+	{"UnexpectedValueException", "RuntimeException"},
+}
+
+// declareExceptions makes exceptions from std known, so they
+// can be thrown, caught and extended as PHP classes.
+func (p *parser) declareExceptions() {
+	throwable := newClass("Throwable", nil)
+	throwable.iface = lang.NewInterface("Throwable")
+	throwable.iface.Package = "std"
+	throwable.members = newMemberTranslator()
+	p.classes["throwable"] = throwable
+
+	tt := throwable.iface.Type()
+	properties := []struct {
+		name string
+		typ  lang.Typ
+	}{
+		{"message", lang.NewTyp(lang.String, false)},
+		{"code", lang.NewTyp(lang.Int, false)},
+		{"previous", tt},
+		{"file", lang.NewTyp(lang.String, false)},
+		{"line", lang.NewTyp(lang.Int, false)},
+	}
+	for _, pr := range properties {
+		f := lang.NewFunc(throwable.members.Method("get"+FirstUpper(pr.name), true, false))
+		f.Return = pr.typ
+		throwable.methods[f.Name] = []*lang.Function{f}
+		throwable.iface.Methods = append(throwable.iface.Methods, f)
+	}
+
+	args := []*lang.Variable{
+		lang.NewVariable("message", lang.NewTyp(lang.String, false), false),
+		lang.NewVariable("code", lang.NewTyp(lang.Int, false), false),
+		lang.NewVariable("previous", tt, false),
+	}
+	defaults := []lang.Expression{
+		&lang.Str{Value: `""`},
+		&lang.Number{Value: "0"},
+		lang.NewVarRef(lang.NewVariable("nil", tt, false), tt),
+	}
+	construct := lang.NewFunc("Construct")
+	construct.Args = args
+	construct.Defaults = defaults
+
+	for _, e := range exceptions {
+		c := newClass(e.name, nil)
+		c.Class = lang.NewClass(e.name)
+		c.Package = "std"
+		c.Constructor.Return = c.Type()
+		c.Constructor.Args = args
+		c.Constructor.Defaults = defaults
+		c.construct = construct
+		c.members = newMemberTranslator()
+		c.iface = lang.NewInterface(e.name + "Interface")
+		c.iface.Package = "std"
+		c.Interface = c.iface
+
+		if e.parent == "" {
+			c.interfaces = []*class{throwable}
+			c.inherit(throwable)
+			c.iface.Embeds = append(c.iface.Embeds, throwable.iface)
+			for _, pr := range properties {
+				f := lang.NewVariable(FirstUpper(pr.name), pr.typ, false)
+				c.members.Reserve(f.Name)
+				c.fields[pr.name] = f
+			}
+		} else {
+			c.parent = p.classes[strings.ToLower(e.parent)]
+			c.Extends = c.parent.Class
+			c.inherit(c.parent)
+			c.iface.Embeds = append(c.iface.Embeds, c.parent.iface)
+			for k, v := range c.parent.fields {
+				c.fields[k] = v
+			}
+		}
+
+		p.classes[strings.ToLower(e.name)] = c
+		p.funcs.funcs["std"].fn[c.Constructor.Name] = []*lang.Function{c.Constructor}
+	}
+}
+
+// tryStmt lowers the try statement. Only catch clauses
+// using the caught exception define the variable.
+func (p *fileParser) tryStmt(b lang.Block, s *stmt.Try) *lang.Try {
+	t := lang.NewTry(b)
+	p.createFunction(t.Body, s.Stmts)
+
+	for _, n := range s.Catches {
+		n := n.(*stmt.Catch)
+		types := make([]lang.Typ, 0, len(n.Types))
+		for _, tn := range n.Types {
+			c := p.declaredClass(tn)
+			if !c.implements(p.classes["throwable"].typ()) {
+				p.fail(tn, InvalidConstruct, "%s is not an exception.", c.name)
+			}
+			types = append(types, c.typ())
+		}
+
+		c := lang.NewCatch(t, types)
+		if v, ok := n.Variable.(*expr.Variable); ok && usesVariable(n.Stmts, v) {
+			typ := types[0]
+			if len(types) > 1 {
+				typ = p.classes["throwable"].typ()
+			}
+			c.Var = lang.NewVariable(p.identifierName(v), typ, false)
+		}
+		p.createFunction(c.Body, n.Stmts)
+		t.Catches = append(t.Catches, c)
+	}
+
+	if f, ok := s.Finally.(*stmt.Finally); ok {
+		t.Finally = lang.NewCode(t)
+		p.createFunction(t.Finally, f.Stmts)
+	}
+	return t
+}
+
+// throwStmt panics with the exception, the place
+// where it was thrown is recorded.
+func (p *fileParser) throwStmt(b lang.Block, s *stmt.Throw) lang.Node {
+	e := p.expression(b, s.Expr)
+	if c := p.classOf(e.Type()); c == nil || !c.implements(p.classes["throwable"].typ()) {
+		p.fail(s.Expr, TypeMismatch, "Can only throw objects, '%s' given.", e.Type())
+	}
+
+	thrown, err := p.funcs.Namespace("std").Call("Thrown", []lang.Expression{
+		e,
+		&lang.Str{Value: fmt.Sprintf("%q", p.file.Name)},
+		&lang.Number{Value: fmt.Sprint(s.Position.StartLine)},
+	})
+	if err != nil {
+		p.fail(s, TypeMismatch, "%v", err)
+	}
+	f := &lang.FunctionCall{
+		Name:   "panic",
+		Args:   []lang.Expression{thrown},
+		Return: lang.NewTyp(lang.Void, false),
+	}
+	thrown.SetParent(f)
+	f.SetParent(b)
+	return f
+}
+
+// tryJump fails when break or continue would leave the
+// try statement, Go cannot jump out of the function literal.
+func (p *fileParser) tryJump(b lang.Block, n node.Node, levels int) {
+	for bl := lang.Node(b); bl != nil && levels > 0; bl = bl.Parent() {
+		switch bl.(type) {
+		case *lang.Switch, *lang.For, *lang.Foreach:
+			levels--
+		case *lang.Try, *lang.Catch:
+			p.fail(n, UnsupportedStatement, "Jumping out of the try statement is not supported.")
+		case *lang.Function, *lang.FuncLit:
+			return
+		}
+	}
+}
+
+// usesVariable reports if the variable is used in the statements.
+func usesVariable(stmts []node.Node, v *expr.Variable) bool {
+	id, ok := v.VarName.(*node.Identifier)
+	if !ok {
+		return true
+	}
+	u := &variableUse{name: id.Value}
+	for _, s := range stmts {
+		s.Walk(u)
+	}
+	return u.used
+}
+
+type variableUse struct {
+	name string
+	used bool
+}
+
+func (u *variableUse) EnterNode(w walker.Walkable) bool {
+	if v, ok := w.(*expr.Variable); ok {
+		if id, ok := v.VarName.(*node.Identifier); ok && id.Value == u.name {
+			u.used = true
+		}
+	}
+	return !u.used
+}
+
+func (u *variableUse) LeaveNode(w walker.Walkable)                  {}
+func (u *variableUse) EnterChildNode(key string, w walker.Walkable) {}
+func (u *variableUse) LeaveChildNode(key string, w walker.Walkable) {}
+func (u *variableUse) EnterChildList(key string, w walker.Walkable) {}
+func (u *variableUse) LeaveChildList(key string, w walker.Walkable) {}
@@ -11,6 +11,10 @@ type Func struct {
 	funcs map[string]*funcs
 
 	gc *lang.GlobalContext
+
+	// implements reports if the value of the first type
+	// can be passed where the interface is expected.
+	implements func(t, iface lang.Typ) bool
 }
 
 func NewFunc(gc *lang.GlobalContext) *Func {
@@ -123,6 +127,67 @@ func NewFunc(gc *lang.GlobalContext) *Func {
 				Return: lang.NewTyp(lang.Bool, false),
 			},
 		},
+		"Thrown": {
+			{
+				Name: "Thrown",
+				Args: []*lang.Variable{
+					lang.NewVariable("t", lang.NewTyp("std.Throwable", false), false),
+					lang.NewVariable("file", lang.NewTyp(lang.String, false), false),
+					lang.NewVariable("line", lang.NewTyp(lang.Int, false), false),
+				},
+				VariadicCount: false,
+
+				Return: lang.NewTyp("std.Throwable", false),
+			},
+		},
+		"LooseEq": {
+			{
+				Name: "LooseEq",
+				Args: []*lang.Variable{
+					lang.NewVariable("a", lang.NewTyp(lang.Anything, false), false),
+					lang.NewVariable("b", lang.NewTyp(lang.Anything, false), false),
+				},
+				VariadicCount: false,
+
+				Return: lang.NewTyp(lang.Bool, false),
+			},
+		},
+		"Compare": {
+			{
+				Name: "Compare",
+				Args: []*lang.Variable{
+					lang.NewVariable("a", lang.NewTyp(lang.Anything, false), false),
+					lang.NewVariable("b", lang.NewTyp(lang.Anything, false), false),
+				},
+				VariadicCount: false,
+
+				Return: lang.NewTyp(lang.Int, false),
+			},
+		},
+		"Greater": {
+			{
+				Name: "Greater",
+				Args: []*lang.Variable{
+					lang.NewVariable("a", lang.NewTyp(lang.Anything, false), false),
+					lang.NewVariable("b", lang.NewTyp(lang.Anything, false), false),
+				},
+				VariadicCount: false,
+
+				Return: lang.NewTyp(lang.Bool, false),
+			},
+		},
+		"GreaterOrEqual": {
+			{
+				Name: "GreaterOrEqual",
+				Args: []*lang.Variable{
+					lang.NewVariable("a", lang.NewTyp(lang.Anything, false), false),
+					lang.NewVariable("b", lang.NewTyp(lang.Anything, false), false),
+				},
+				VariadicCount: false,
+
+				Return: lang.NewTyp(lang.Bool, false),
+			},
+		},
 		"ToInt": {
 			{
 				Name: "ToInt",
@@ -134,6 +199,50 @@ func NewFunc(gc *lang.GlobalContext) *Func {
 				Return: lang.NewTyp(lang.Int, false),
 			},
 		},
+		"ToFloat64": {
+			{
+				Name: "ToFloat64",
+				Args: []*lang.Variable{
+					lang.NewVariable("s", lang.NewTyp(lang.Anything, false), false),
+				},
+				VariadicCount: false,
+
+				Return: lang.NewTyp(lang.Float64, false),
+			},
+		},
+		"ToString": {
+			{
+				Name: "ToString",
+				Args: []*lang.Variable{
+					lang.NewVariable("s", lang.NewTyp(lang.Anything, false), false),
+				},
+				VariadicCount: false,
+
+				Return: lang.NewTyp(lang.String, false),
+			},
+		},
+		"Key": {
+			{
+				Name: "Key",
+				Args: []*lang.Variable{
+					lang.NewVariable("v", lang.NewTyp(lang.Anything, false), false),
+				},
+				VariadicCount: false,
+
+				Return: lang.NewTyp("array.Scalar", false),
+			},
+		},
+		"ToBool": {
+			{
+				Name: "ToBool",
+				Args: []*lang.Variable{
+					lang.NewVariable("s", lang.NewTyp(lang.Anything, false), false),
+				},
+				VariadicCount: false,
+
+				Return: lang.NewTyp(lang.Bool, false),
+			},
+		},
 		"FileExists": {
 			{
 				Name: "FileExists",
@@ -146,6 +255,32 @@ func NewFunc(gc *lang.GlobalContext) *Func {
 			},
 		},
 	}
+	// Arithmetic of PHP, see fileParser.arithmetic. Results
+	// of ints overflow to floats, so they have no static type.
+	for _, name := range []string{"Add", "Sub", "Mul", "Div"} {
+		for _, t := range []string{lang.Int, lang.Anything} {
+			n := name
+			if t == lang.Int {
+				n += "Int"
+			}
+			std[n] = []*lang.Function{{
+				Name: n,
+				Args: []*lang.Variable{
+					lang.NewVariable("a", lang.NewTyp(t, false), false),
+					lang.NewVariable("b", lang.NewTyp(t, false), false),
+				},
+				Return: lang.NewTyp(lang.Anything, false),
+			}}
+		}
+	}
+	std["Mod"] = []*lang.Function{{
+		Name: "Mod",
+		Args: []*lang.Variable{
+			lang.NewVariable("a", lang.NewTyp(lang.Anything, false), false),
+			lang.NewVariable("b", lang.NewTyp(lang.Anything, false), false),
+		},
+		Return: lang.NewTyp(lang.Int, false),
+	}}
 	fn.funcs["std"] = &funcs{
 		namespace: "github.com/lSimul/php2go/std",
 		fn:        std,
@@ -185,6 +320,39 @@ func NewFunc(gc *lang.GlobalContext) *Func {
 				Return: lang.NewTyp("array.String", false),
 			},
 		},
+		"NewFloat64": {
+			{
+				Name: "NewFloat64",
+				Args: []*lang.Variable{
+					lang.NewVariable("vals", lang.NewTyp(lang.Anything, false), false),
+				},
+				VariadicCount: true,
+
+				Return: lang.NewTyp("array.Float64", false),
+			},
+		},
+		"NewBool": {
+			{
+				Name: "NewBool",
+				Args: []*lang.Variable{
+					lang.NewVariable("vals", lang.NewTyp(lang.Anything, false), false),
+				},
+				VariadicCount: true,
+
+				Return: lang.NewTyp("array.Bool", false),
+			},
+		},
+		"NewAny": {
+			{
+				Name: "NewAny",
+				Args: []*lang.Variable{
+					lang.NewVariable("vals", lang.NewTyp(lang.Anything, false), false),
+				},
+				VariadicCount: true,
+
+				Return: lang.NewTyp("array.Any", false),
+			},
+		},
 	}
 	fn.funcs["array"] = &funcs{
 		namespace: "github.com/lSimul/php2go/std/array",
@@ -211,6 +379,13 @@ func NewFunc(gc *lang.GlobalContext) *Func {
 		fn:        flag,
 	}
 
+	// Functions of math are called directly, only
+	// the import is needed.
+	fn.funcs["math"] = &funcs{
+		namespace: "math",
+		fn:        make(map[string][]*lang.Function),
+	}
+
 	return fn
 }
 
@@ -230,14 +405,17 @@ type FileFunc struct {
 // First argument is a real name of the function. It goes well with the
 // missingArgs argument, goal is to found the correct function. Functions
 // in PHP can have variable amount of arguments.
+//
+// Functions are added to the package of the file.
 func (fn *FileFunc) Add(name string, f *lang.Function, missingArgs int) {
-	if _, ok := fn.funcs[""].fn[name]; !ok {
-		fn.funcs[""].fn[name] = make([]*lang.Function, 0)
+	pkg := fn.funcs[fn.file.Package]
+	if _, ok := pkg.fn[name]; !ok {
+		pkg.fn[name] = make([]*lang.Function, 0)
 	}
-	if len(fn.funcs[""].fn[name]) > missingArgs {
-		fn.funcs[""].fn[name][missingArgs] = f
+	if len(pkg.fn[name]) > missingArgs {
+		pkg.fn[name][missingArgs] = f
 	} else {
-		fn.funcs[""].fn[name] = append(fn.funcs[""].fn[name], f)
+		pkg.fn[name] = append(pkg.fn[name], f)
 	}
 	fn.file.Add(f)
 }
@@ -250,14 +428,65 @@ func (f *FileFunc) Namespace(n string) *FunctionCaller {
 
 	f.file.AddImport(fn.namespace)
 	return &FunctionCaller{
-		namespace: n,
-		Func:      &fn.fn,
+		namespace:  n,
+		Func:       &fn.fn,
+		implements: f.implements,
+		coerce:     f.coerce,
 	}
 }
 
+// scalarConversions are std functions converting
+// values without static types to the scalar types.
+var scalarConversions = map[string]string{
+	lang.Int:     "ToInt",
+	lang.Float64: "ToFloat64",
+	lang.String:  "ToString",
+	lang.Bool:    "ToBool",
+}
+
+// coerce converts the value without a static type to the scalar
+// type, like PHP converts arguments in the coercive typing mode.
+// Typed arrays are converted to array.Any, the type of PHP array
+// declarations. False is returned, when the value cannot be converted.
+func (f *FileFunc) coerce(e lang.Expression, t lang.Typ) (lang.Expression, bool) {
+	if et := e.Type(); t.String() == "array.Any" && !et.Eq(t) && IsArray(et.String()) && !et.Equal("array.Scalar") {
+		c := &lang.FunctionCall{
+			Name:   e.String() + ".Any",
+			Return: t,
+		}
+		c.SetParent(e.Parent())
+		e.SetParent(c)
+		return c, true
+	}
+	fn, ok := scalarConversions[t.String()]
+	if !ok || !e.Type().Equal(lang.Anything) {
+		return nil, false
+	}
+	c, err := f.Namespace("std").Call(fn, []lang.Expression{e})
+	if err != nil {
+		panic(err)
+	}
+	return c, true
+}
+
+// undefinedFunctionError is returned when the called
+// function is not known in the namespace.
+type undefinedFunctionError struct {
+	name string
+}
+
+func (e *undefinedFunctionError) Error() string {
+	return fmt.Sprintf("Function '%s' is not defined.", e.name)
+}
+
 type FunctionCaller struct {
 	namespace string
 	Func      *map[string][]*lang.Function
+
+	implements func(t, iface lang.Typ) bool
+	// coerce converts arguments without static types
+	// to the types of the parameters, if it is set.
+	coerce func(e lang.Expression, t lang.Typ) (lang.Expression, bool)
 }
 
 func (fc *FunctionCaller) NeedsGlobal(name string) {
@@ -273,10 +502,13 @@ func (fc *FunctionCaller) NeedsGlobal(name string) {
 func (fc *FunctionCaller) Call(name string, args []lang.Expression) (*lang.FunctionCall, error) {
 	funcs, ok := (*fc.Func)[name]
 	if !ok {
-		return nil, errors.New(fmt.Sprintf("Function '%s' is not defined.", name))
+		return nil, &undefinedFunctionError{name}
 	}
 
 	f := funcs[0]
+	if missing := len(f.Args) - len(args); missing > 0 && missing <= len(f.Defaults) {
+		args = append(args, f.Defaults[len(f.Defaults)-missing:]...)
+	}
 	if !f.VariadicCount {
 		i := len(f.Args) - len(args)
 		if i >= len(funcs) {
@@ -325,8 +557,17 @@ func (fc *FunctionCaller) Call(name string, args []lang.Expression) (*lang.Funct
 			if f.Args[i].Type().Equal(lang.Anything) {
 				continue
 			}
-			if t := f.Args[i].Type(); !args[i].Type().Eq(t) {
-				if t.IsPointer && !args[i].Type().IsPointer {
+			if t := f.Args[i].Declared(); !args[i].Type().Eq(t) {
+				if fc.implements != nil && fc.implements(args[i].Type(), t) {
+					continue
+				}
+				if fc.coerce != nil {
+					if c, ok := fc.coerce(args[i], t); ok {
+						args[i] = c
+						continue
+					}
+				}
+				if at := args[i].Type(); t.IsPointer && !at.IsPointer && t.String() == "*"+at.String() {
 					t, ok := args[i].(*lang.VarRef)
 					if !ok {
 						return nil, errors.New("Only variable can be send by reference.")
@@ -339,6 +580,12 @@ func (fc *FunctionCaller) Call(name string, args []lang.Expression) (*lang.Funct
 				}
 			}
 		}
+		// Arrays are passed by value.
+		for i := range args {
+			if !f.Args[i].Declared().IsPointer {
+				args[i] = copyArray(args[i])
+			}
+		}
 	}
 
 	n := ""
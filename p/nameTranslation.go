@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"strings"
+
+	"github.com/lSimul/php2go/lang"
 )
 
 var keywords = map[string]bool{
@@ -39,13 +41,26 @@ var keywords = map[string]bool{
 	// For globals implementation
 	"g":      true,
 	"global": true,
+
+	// For exceptions implementation
+	"thrown":   true,
+	"ret":      true,
+	"returned": true,
 }
 
 // ArrayType formats array type name
 // for given type.
 // The convention is simple, it is package
-// name "array" and exported s.
+// name "array" and exported s. Arrays of objects
+// are generated next to the class, they are
+// named after it.
 func ArrayType(s string) string {
+	if s == lang.Anything {
+		return "array.Any"
+	}
+	if strings.HasPrefix(s, "*") {
+		return s[1:] + "Array"
+	}
 	return "array." + FirstUpper(s)
 }
 
@@ -53,14 +68,41 @@ func ArrayType(s string) string {
 // to ArrayType, formating type
 // present in the array.
 func ArrayItem(s string) string {
-	s = strings.TrimLeft(s, "array.")
+	if !strings.HasPrefix(s, "array.") {
+		return "*" + strings.TrimSuffix(s, "Array")
+	}
+	s = strings.TrimPrefix(s, "array.")
+	if s == "Any" {
+		return lang.Anything
+	}
 	return FirstLower(s)
 }
 
+// arrayItem returns type of the values
+// in the array of the type t.
+func arrayItem(t lang.Typ) lang.Typ {
+	if t.Elem != nil {
+		return *t.Elem
+	}
+	s := ArrayItem(t.String())
+	if strings.HasPrefix(s, "*") {
+		return lang.NewTyp(s[1:], true)
+	}
+	return lang.NewTyp(s, false)
+}
+
+// arrayAccepts checks, if the value of the type v
+// can be stored in the array of the type t.
+func arrayAccepts(t, v lang.Typ) bool {
+	i := arrayItem(t)
+	return i.Equal(lang.Anything) || i.Eq(v)
+}
+
 // IsArray checks, if the s is a name
 // suitable for array type.
 func IsArray(s string) bool {
-	return strings.HasPrefix(s, "array.")
+	return strings.HasPrefix(s, "array.") ||
+		!strings.HasPrefix(s, "*") && strings.HasSuffix(s, "Array")
 }
 
 // FirstUpper uppercases first letter
@@ -81,6 +123,9 @@ func FirstLower(s string) string {
 
 type NameTranslation interface {
 	Translate(string) string
+	// Reserve marks the name as used, no other
+	// name is translated to it.
+	Reserve(string)
 }
 
 type nameTranslator struct {
@@ -102,6 +147,10 @@ func (t *nameTranslator) Translate(name string) string {
 	return new
 }
 
+func (t *nameTranslator) Reserve(name string) {
+	t.used[name] = true
+}
+
 func (t nameTranslator) resolveConflict(name string, try int) string {
 	n := fmt.Sprintf("%s%d", name, try)
 	if used := t.used[n]; used {
@@ -172,3 +221,102 @@ func (l *labelTranslator) Label(name string, unique bool, number int) string {
 	}
 	return l.Translate(name)
 }
+
+// memberTranslator translates names of the class members.
+// Public members are exported, the rest is not. Fields
+// and methods share one namespace in Go, so collisions
+// between them are resolved too.
+type memberTranslator struct {
+	fields  nameTranslator
+	methods nameTranslator
+
+	// exported makes protected members exported too,
+	// classes outside of the main package use it.
+	exported bool
+}
+
+func newMemberTranslator() *memberTranslator {
+	used := make(map[string]bool)
+	for k, v := range keywords {
+		used[k] = v
+	}
+	used["g"] = true
+	return &memberTranslator{
+		fields: nameTranslator{
+			names: make(map[string]string),
+			used:  used,
+		},
+		methods: nameTranslator{
+			names: make(map[string]string),
+			used:  used,
+		},
+	}
+}
+
+// inherit takes over members of the parent,
+// so inherited members keep their names.
+func (m *memberTranslator) inherit(parent *memberTranslator) {
+	for k, v := range parent.fields.used {
+		m.fields.used[k] = v
+	}
+	for k, v := range parent.fields.names {
+		m.fields.names[k] = v
+	}
+	for k, v := range parent.methods.names {
+		m.methods.names[k] = v
+	}
+}
+
+// Reserve marks the name as used, no member gets it.
+func (m *memberTranslator) Reserve(name string) {
+	m.fields.used[name] = true
+}
+
+// Field translates property name, PHP property names
+// are case sensitive.
+func (m *memberTranslator) Field(name string, public, private bool) string {
+	return m.fields.Translate(visibleName(name, m.visible(public, private)))
+}
+
+// Method translates method name, PHP method names
+// are case insensitive. __toString becomes String,
+// so the struct implements fmt.Stringer, __construct
+// becomes construct, exported ones Construct.
+func (m *memberTranslator) Method(name string, public, private bool) string {
+	if n, ok := m.LookupMethod(name); ok {
+		return n
+	}
+	n := visibleName(name, m.visible(public, private))
+	switch strings.ToLower(name) {
+	case "__tostring":
+		n = "String"
+	case "__construct":
+		n = visibleName("construct", m.exported)
+	}
+	if m.methods.used[n] {
+		n = m.methods.resolveConflict(n, 1)
+	}
+	m.methods.names[strings.ToLower(name)] = n
+	m.methods.used[n] = true
+	return n
+}
+
+// visible tells whether the member is exported. Classes
+// of packages export protected members as well, subclasses
+// from other packages have to reach them.
+func (m *memberTranslator) visible(public, private bool) bool {
+	return public || (m.exported && !private)
+}
+
+// LookupMethod returns name of the already translated method.
+func (m *memberTranslator) LookupMethod(name string) (string, bool) {
+	n, ok := m.methods.names[strings.ToLower(name)]
+	return n, ok
+}
+
+func visibleName(name string, public bool) string {
+	if public {
+		return FirstUpper(name)
+	}
+	return FirstLower(name)
+}
@@ -0,0 +1,272 @@
+package p
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/z7zmey/php-parser/node"
+	"github.com/z7zmey/php-parser/node/name"
+	"github.com/z7zmey/php-parser/node/stmt"
+
+	"github.com/lSimul/php2go/lang"
+)
+
+// Module sets the import path of the generated project,
+// packages created from PHP namespaces are imported from it.
+func (p *parser) Module(path string) {
+	p.module = path
+}
+
+// CheckOverflow sets if int arithmetic is checked for overflows,
+// it is on by default. Unchecked code uses plain Go operators,
+// which wrap around, but results stay ints.
+func (p *parser) CheckOverflow(check bool) {
+	p.checkOverflow = check
+}
+
+// imports keeps names brought to the file by use statements,
+// they are indexed by lowercased aliases.
+type imports struct {
+	classes   map[string]string
+	functions map[string]string
+}
+
+func newImports() imports {
+	return imports{
+		classes:   make(map[string]string),
+		functions: make(map[string]string),
+	}
+}
+
+// namespaceStmts sets the namespace of the file and records its use
+// statements, the rest of the root statements is returned. Only one
+// namespace per file is supported, it is mapped to one Go package.
+func (p *fileParser) namespaceStmts(stmts []node.Node) []node.Node {
+	res := make([]node.Node, 0, len(stmts))
+	declared := false
+	for _, s := range stmts {
+		switch s := s.(type) {
+		case *stmt.Namespace:
+			p.guard(s, func() {
+				if declared {
+					p.fail(s, UnsupportedStatement, "Only one namespace per file is supported.")
+				}
+				declared = true
+				if s.NamespaceName != nil {
+					p.namespace = nameOf(s.NamespaceName)
+				}
+			})
+			if s.Stmts != nil {
+				res = append(res, p.namespaceStmts(s.Stmts)...)
+			}
+
+		case *stmt.UseList:
+			p.guard(s, func() { p.useList(s.UseType, "", s.Uses) })
+
+		case *stmt.GroupUse:
+			p.guard(s, func() { p.useList(s.UseType, nameOf(s.Prefix)+`\`, s.UseList) })
+
+		default:
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// useList records imported classes and functions, imported
+// constants are not supported.
+func (p *fileParser) useList(typ node.Node, prefix string, uses []node.Node) {
+	for _, u := range uses {
+		u := u.(*stmt.Use)
+		t := typ
+		if u.UseType != nil {
+			t = u.UseType
+		}
+
+		full := prefix + nameOf(u.Use)
+		alias := full[strings.LastIndex(full, `\`)+1:]
+		if id, ok := u.Alias.(*node.Identifier); ok {
+			alias = id.Value
+		}
+
+		names := p.imports.classes
+		if id, ok := t.(*node.Identifier); ok {
+			switch strings.ToLower(id.Value) {
+			case "function":
+				names = p.imports.functions
+			case "const":
+				p.fail(u, UnsupportedStatement, "Importing constants is not supported.")
+			}
+		}
+		if _, ok := names[strings.ToLower(alias)]; ok {
+			p.fail(u, InvalidConstruct, "Cannot use %s as %s, the name is already in use.", full, alias)
+		}
+		names[strings.ToLower(alias)] = full
+	}
+}
+
+// nameOf joins parts of the name by backslashes.
+func nameOf(n node.Node) string {
+	var parts []node.Node
+	switch nm := n.(type) {
+	case *name.Name:
+		parts = nm.Parts
+	case *name.FullyQualified:
+		parts = nm.Parts
+	case *name.Relative:
+		parts = nm.Parts
+	}
+	s := make([]string, len(parts))
+	for i, p := range parts {
+		s[i] = p.(*name.NamePart).Value
+	}
+	return strings.Join(s, `\`)
+}
+
+func isName(n node.Node) bool {
+	switch n.(type) {
+	case *name.Name, *name.FullyQualified, *name.Relative:
+		return true
+	}
+	return false
+}
+
+// qualified prefixes the name by the namespace of the file.
+func (p *fileParser) qualified(n string) string {
+	if p.namespace == "" {
+		return n
+	}
+	return p.namespace + `\` + n
+}
+
+// resolve returns fully qualified PHP name, without the leading
+// backslash. Unqualified names are looked up in the imported ones
+// first, so are the first parts of qualified names.
+func (p *fileParser) resolve(n node.Node, imported map[string]string) string {
+	switch n.(type) {
+	case *name.FullyQualified:
+		return nameOf(n)
+	case *name.Relative:
+		return p.qualified(nameOf(n))
+	}
+
+	s := nameOf(n)
+	first := s
+	if i := strings.Index(s, `\`); i >= 0 {
+		first = s[:i]
+		imported = p.imports.classes
+	}
+	if full, ok := imported[strings.ToLower(first)]; ok {
+		return full + s[len(first):]
+	}
+	return p.qualified(s)
+}
+
+// resolveClass finds the class by its PHP name. Unlike PHP, the global
+// namespace is searched too, so runtime classes like Exception can be
+// used without the leading backslash.
+func (p *fileParser) resolveClass(n node.Node) *class {
+	full := p.resolve(n, p.imports.classes)
+	c, ok := p.classes[strings.ToLower(full)]
+	if !ok {
+		if _, unqualified := n.(*name.Name); unqualified && !strings.Contains(nameOf(n), `\`) {
+			c = p.classes[strings.ToLower(nameOf(n))]
+		}
+	}
+	if c != nil && c.Class != nil && c.Package == "" && p.inPackage() {
+		p.fail(n, InvalidConstruct, "Class %s of the main package cannot be used in the namespace %s.", c.name, p.namespace)
+	}
+	return c
+}
+
+// resolveFunction returns the Go package and the name of the called
+// function, unqualified functions fall back to the global namespace.
+func (p *fileParser) resolveFunction(n node.Node) (string, string) {
+	full := p.resolve(n, p.imports.functions)
+	i := strings.LastIndex(full, `\`)
+	if i < 0 {
+		return "", p.functionTranslator.Translate(full)
+	}
+
+	pkg, ok := p.packages[strings.ToLower(full[:i])]
+	fn := FirstUpper(p.functionTranslator.Translate(full[i+1:]))
+	if ok {
+		if _, defined := (*p.funcs.Namespace(pkg).Func)[fn]; defined {
+			return pkg, fn
+		}
+	}
+	if _, unqualified := n.(*name.Name); unqualified && !strings.Contains(nameOf(n), `\`) {
+		return "", p.functionTranslator.Translate(nameOf(n))
+	}
+	return pkg, fn
+}
+
+// packageOf returns the Go package created for the namespace,
+// it is named after the last part of the namespace.
+func (p *parser) packageOf(namespace string) string {
+	ns := strings.ToLower(namespace)
+	if pkg, ok := p.packages[ns]; ok {
+		return pkg
+	}
+
+	parts := strings.Split(ns, `\`)
+	pkg := parts[len(parts)-1]
+	for i := 1; reservedPackage(pkg) || p.gc.Packages[pkg] != ""; i++ {
+		pkg = fmt.Sprintf("%s%d", parts[len(parts)-1], i)
+	}
+	p.packages[ns] = pkg
+	p.gc.Packages[pkg] = strings.Join(parts, "/")
+	p.funcs.funcs[pkg] = &funcs{
+		namespace: "",
+		fn:        make(map[string][]*lang.Function),
+	}
+
+	// Package names cannot be used by variables and functions,
+	// they would shadow the package.
+	p.translator.Reserve(pkg)
+	p.functionTranslator.Reserve(pkg)
+	return pkg
+}
+
+// reservedPackage reports if the name is used
+// by the package imported by the generated code.
+func reservedPackage(name string) bool {
+	switch name {
+	case "main", "fmt", "flag", "io", "log", "http", "os", "std", "array":
+		return true
+	}
+	return keywords[name]
+}
+
+// functionName translates name of the declared function,
+// functions outside of the main package are exported.
+func (p *fileParser) functionName(name string) string {
+	n := p.functionTranslator.Translate(name)
+	if p.inPackage() {
+		return FirstUpper(n)
+	}
+	return n
+}
+
+// packageStmts reports statements outside of the declarations,
+// packages have no main function to run them.
+func (p *fileParser) packageStmts(stmts []node.Node) {
+	for _, s := range stmts {
+		switch s := s.(type) {
+		case *stmt.Nop:
+			continue
+		case *stmt.InlineHtml:
+			if strings.TrimSpace(s.Value) == "" {
+				continue
+			}
+		}
+		p.guard(s, func() {
+			p.fail(s, UnsupportedStatement, "Statements outside of functions are not supported in the namespace %s.", p.namespace)
+		})
+	}
+}
+
+// inPackage reports if the file is outside of the main package.
+func (p *fileParser) inPackage() bool {
+	return p.file != nil && p.file.Package != ""
+}
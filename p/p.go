@@ -3,8 +3,10 @@ package p
 import (
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"os"
+	"math/big"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -32,8 +34,35 @@ type parser struct {
 
 	asServer bool
 
+	// analysis keeps going even after issues, which
+	// would stop the transpilation of the statement,
+	// so every issue can be found.
+	analysis bool
+
+	// dump receives textual representation
+	// of every parsed PHP tree, if set.
+	dump io.Writer
+
 	gc    *lang.GlobalContext
 	funcs *Func
+
+	// module is the import path of the generated project.
+	module string
+	// checkOverflow makes int arithmetic promote
+	// overflowing results to floats, like PHP does.
+	checkOverflow bool
+	// packages maps lowercased PHP namespaces
+	// to the generated Go packages.
+	packages map[string]string
+
+	// classes are indexed by lowercased PHP names,
+	// class names are case insensitive.
+	classes map[string]*class
+
+	// Sources of the parsed files, used to
+	// locate diagnostics.
+	sources     map[string][]byte
+	diagnostics []Diagnostic
 }
 
 func NewParser(v, f NameTranslation) *parser {
@@ -42,6 +71,8 @@ func NewParser(v, f NameTranslation) *parser {
 		functionTranslator: f,
 		asServer:           false,
 		labelTranslator:    NewLabelTranslator(),
+		module:             "app",
+		checkOverflow:      true,
 	}
 }
 
@@ -50,41 +81,75 @@ type fileParser struct {
 
 	file  *lang.File
 	funcs *FileFunc
+	src   []byte
+
+	// namespace is PHP namespace of the file,
+	// names are resolved relative to it.
+	namespace string
+	imports   imports
 }
 
-func phpParse(src []byte) *node.Root {
-	parser := php7.NewParser(src, "")
+// DumpAST sets the writer which receives every parsed PHP
+// tree, it is meant only for debugging. Nil disables it.
+func (p *parser) DumpAST(w io.Writer) {
+	p.dump = w
+}
+
+// phpParse creates AST from the source. Syntax errors
+// are returned as SyntaxErrors.
+func (p *parser) phpParse(path string, src []byte) (*node.Root, error) {
+	parser := php7.NewParser(src, path)
 	parser.WithFreeFloating()
 	parser.Parse()
 
 	if errs := parser.GetErrors(); len(errs) > 0 {
-		for _, e := range errs {
-			fmt.Print(e)
-		}
-		os.Exit(1)
+		return nil, newSyntaxErrors(path, src, errs)
 	}
 
-	visitor := visitor.Dumper{
-		Writer: os.Stderr,
-		Indent: "",
+	if p.dump != nil {
+		visitor := visitor.Dumper{
+			Writer: p.dump,
+			Indent: "",
+		}
+		parser.GetRootNode().Walk(&visitor)
 	}
-	parser.GetRootNode().Walk(&visitor)
 
-	return parser.GetRootNode().(*node.Root)
+	if p.sources == nil {
+		p.sources = make(map[string][]byte)
+	}
+	p.sources[path] = src
+	return parser.GetRootNode().(*node.Root), nil
 }
 
-func (p *parser) RunFromString(path string, asServer bool) *lang.GlobalContext {
+// RunFromString reads and transpiles the PHP file. Error is returned
+// only if the file cannot be read or parsed, issues found during
+// the transpilation are returned as diagnostics.
+func (p *parser) RunFromString(path string, asServer bool) (*lang.GlobalContext, []Diagnostic, error) {
 	src, err := ioutil.ReadFile(path)
 	if err != nil {
-		fmt.Println(err)
-		return p.gc
+		return nil, nil, err
 	}
 
-	return p.Run(phpParse(src), path, asServer)
+	p.sources = nil
+	r, err := p.phpParse(path, src)
+	if err != nil {
+		return nil, nil, err
+	}
+	gc, diags := p.Run(r, path, asServer)
+	return gc, diags, nil
 }
 
-func (p *parser) Run(r *node.Root, path string, asServer bool) *lang.GlobalContext {
+// Run transpiles the PHP tree. Constructs which cannot be
+// transpiled are skipped and reported as diagnostics, the rest
+// of the file is transpiled anyway.
+func (p *parser) Run(r *node.Root, path string, asServer bool) (*lang.GlobalContext, []Diagnostic) {
 	p.gc = lang.NewGlobalContext()
+	p.gc.Module = p.module
+	p.packages = make(map[string]string)
+	p.diagnostics = make([]Diagnostic, 0)
+	if p.sources == nil {
+		p.sources = make(map[string][]byte)
+	}
 	if i := strings.LastIndex(path, "/"); i > 0 {
 		p.gc.Path = path[:i+1]
 	} else {
@@ -93,8 +158,11 @@ func (p *parser) Run(r *node.Root, path string, asServer bool) *lang.GlobalConte
 
 	p.asServer = asServer
 	p.funcs = NewFunc(p.gc)
+	p.funcs.implements = p.implements
+	p.classes = make(map[string]*class)
+	p.declareExceptions()
 	p.run(r, path, asServer, true)
-	return p.gc
+	return p.gc, p.diagnostics
 }
 
 func (parser *parser) run(r *node.Root, path string, asServer, withMain bool) {
@@ -103,6 +171,16 @@ func (parser *parser) run(r *node.Root, path string, asServer, withMain bool) {
 		parser: parser,
 		file:   f,
 		funcs:  &FileFunc{Func: parser.funcs, file: f},
+		src:    parser.sources[path],
+
+		imports: newImports(),
+	}
+
+	// Namespaced files become packages, the entry file
+	// stays in the main package anyway.
+	stmts := p.namespaceStmts(r.Stmts)
+	if p.namespace != "" && !withMain {
+		f.Package = parser.packageOf(p.namespace)
 	}
 
 	if withMain {
@@ -120,51 +198,80 @@ func (parser *parser) run(r *node.Root, path string, asServer, withMain bool) {
 		)
 	}
 
-	ms, fs := sanitizeRootStmts(r)
+	ms, fs, ts := sanitizeRootStmts(stmts)
 
-	for _, s := range fs {
-		f, defaultParams := p.funcDef(&s)
-		p.funcs.Add(f.Name, f, 0)
+	var fn *lang.Function
+	if p.file.Package == "" {
+		fn = lang.NewFunc(p.functionTranslator.Translate("mainFunc"))
+		fn.NeedsGlobal = true
+		fn.SetParent(p.file)
+		p.file.Main = fn
+		p.funcs.Add(fn.Name, fn, 0)
+	}
+	p.preload(ms)
 
-		for i := len(defaultParams) - 1; i >= 0; i-- {
-			n := p.functionTranslator.Translate(fmt.Sprintf("%s%d", f.Name, i))
-			vf := lang.NewFunc(n)
-			var args []lang.Expression
-			for j := 0; j < len(f.Args)-(len(defaultParams)-i); j++ {
-				v := lang.NewVariable(f.Args[j].Name, f.Args[j].Type(), false)
-				vf.Args = append(vf.Args, v)
-				args = append(args, lang.NewVarRef(v, v.CurrentType))
-			}
-			args = append(args, defaultParams[i])
+	// Classes go first, functions can use them in their signatures.
+	classes := p.declareTypes(ts)
+	for _, c := range classes {
+		p.classMembers(c)
+	}
 
-			c, err := p.funcs.Namespace("").Call(f.Name, args)
-			if err != nil {
-				panic(err)
-			}
-			if f.Return.Equal(lang.Void) {
-				vf.Body.AddStatement(c)
-			} else {
-				vf.Body.AddStatement(&lang.Return{Expression: c})
-			}
-			p.funcs.Add(f.Name, vf, len(defaultParams)-i)
-		}
+	defined := make([]bool, len(fs))
+	for i, s := range fs {
+		defined[i] = p.guard(&s, func() { p.defineFunc(&s) })
 	}
 
-	fn := lang.NewFunc(p.functionTranslator.Translate("mainFunc"))
-	fn.NeedsGlobal = true
-	fn.SetParent(p.file)
-	p.file.Main = fn
-	p.funcs.Add(fn.Name, fn, 0)
-	p.createFunction(&fn.Body, ms)
+	for _, c := range classes {
+		p.classBody(c)
+	}
+	if fn != nil {
+		p.createFunction(&fn.Body, ms)
+	} else {
+		p.packageStmts(ms)
+	}
 
-	for _, s := range fs {
+	for i, s := range fs {
+		if !defined[i] {
+			continue
+		}
 		f, _ := p.funcDef(&s)
-		fn := p.funcs.Namespace("")
+		fn := p.funcs.Namespace(p.file.Package)
 		fc := (*fn.Func)[f.Name][0]
 		p.createFunction(&fc.Body, s.Stmts)
 	}
 }
 
+// defineFunc adds function to the universe, default parameters
+// are solved by extra functions with less arguments.
+func (p *fileParser) defineFunc(s *stmt.Function) {
+	f, defaultParams := p.funcDef(s)
+	p.funcs.Add(f.Name, f, 0)
+
+	for i := len(defaultParams) - 1; i >= 0; i-- {
+		n := p.functionName(fmt.Sprintf("%s%d", f.Name, i))
+		vf := lang.NewFunc(n)
+		vf.Return = f.Return
+		var args []lang.Expression
+		for j := 0; j < len(f.Args)-(len(defaultParams)-i); j++ {
+			v := lang.NewVariable(f.Args[j].Name, f.Args[j].Declared(), false)
+			vf.Args = append(vf.Args, v)
+			args = append(args, lang.NewVarRef(v, v.CurrentType))
+		}
+		args = append(args, defaultParams[i])
+
+		c, err := p.funcs.Namespace(p.file.Package).Call(f.Name, args)
+		if err != nil {
+			panic(err)
+		}
+		if f.Return.Equal(lang.Void) {
+			vf.Body.AddStatement(c)
+		} else {
+			vf.Body.AddStatement(&lang.Return{Expression: c})
+		}
+		p.funcs.Add(f.Name, vf, len(defaultParams)-i)
+	}
+}
+
 func (p *fileParser) serverFile() {
 	p.file.AddImport("log")
 	p.file.AddImport("net/http")
@@ -188,41 +295,92 @@ func (p *fileParser) serverFile() {
 }
 
 // SanitizeRootStmts splits statements based on their type,
-// functions go to the one array, rest to the other one.
-// This makes function root more straight forward, main will
-// not be longer split up by functions.
-func sanitizeRootStmts(r *node.Root) ([]node.Node, []stmt.Function) {
+// functions and type declarations (classes, interfaces) go to
+// their own arrays, rest to the other one. This makes function
+// root more straight forward, main will not be longer split up
+// by functions.
+func sanitizeRootStmts(stmts []node.Node) ([]node.Node, []stmt.Function, []node.Node) {
 	main := make([]node.Node, 0)
 	functions := make([]stmt.Function, 0)
+	types := make([]node.Node, 0)
 
-	for _, s := range r.Stmts {
+	for _, s := range stmts {
 		switch s := s.(type) {
 		case *stmt.Function:
 			functions = append(functions, *s)
+		case *stmt.Class, *stmt.Interface:
+			types = append(types, s)
 		default:
 			main = append(main, s)
 		}
 	}
 
-	return main, functions
+	return main, functions, types
+}
+
+// includePath returns path of the included file,
+// it is relative to the current file.
+func (p *fileParser) includePath(val string) string {
+	val = strings.ReplaceAll(val, "\"", "")
+	path := filepath.Join(filepath.Dir(p.file.Name), val)
+	// Paths relative to the working directory keep
+	// their prefix, so they match the name of the file.
+	if strings.HasPrefix(p.file.Name, "./") && !filepath.IsAbs(path) && !strings.HasPrefix(path, "..") {
+		path = "./" + path
+	}
+	return path
+}
+
+// preload includes files required by the plain string path before
+// types of the file are declared, so their classes can be extended.
+// Failing ones are reported once the statement is transpiled.
+func (p *fileParser) preload(stmts []node.Node) {
+	for _, s := range stmts {
+		e, ok := s.(*stmt.Expression)
+		if !ok {
+			continue
+		}
+		var path node.Node
+		switch r := e.Expr.(type) {
+		case *expr.Include:
+			path = r.Expr
+		case *expr.IncludeOnce:
+			path = r.Expr
+		case *expr.Require:
+			path = r.Expr
+		case *expr.RequireOnce:
+			path = r.Expr
+		}
+		if str, ok := path.(*scalar.String); ok {
+			p.require(p.includePath(strings.Trim(str.Value, `'"`)))
+		}
+	}
 }
 
-func (p *parser) require(path string) *lang.FunctionCall {
+func (p *parser) require(path string) (lang.Expression, error) {
 	for _, f := range p.gc.Files {
+		if path == f.Name && f.Main == nil {
+			// Packages contain only declarations,
+			// there is nothing to run.
+			return &lang.Const{}, nil
+		}
 		if path == f.Name {
 			return &lang.FunctionCall{
 				Name: "g." + f.Main.Name,
-			}
+			}, nil
 		}
 	}
 
 	src, err := ioutil.ReadFile(path)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return nil, err
 	}
 
-	p.run(phpParse(src), path, p.asServer, false)
+	r, err := p.phpParse(path, src)
+	if err != nil {
+		return nil, err
+	}
+	p.run(r, path, p.asServer, false)
 	return p.require(path)
 }
 
@@ -232,44 +390,122 @@ func (parser *fileParser) funcDef(fc *stmt.Function) (*lang.Function, []lang.Exp
 		return nil, defaultParams
 	}
 
-	n := parser.functionTranslator.Translate(fc.FunctionName.(*node.Identifier).Value)
+	n := parser.functionName(fc.FunctionName.(*node.Identifier).Value)
 	f := lang.NewFunc(n)
 	f.SetParent(parser.file)
 
+	defaultParams = parser.params(f, fc.Params)
+	if fc.ReturnType != nil {
+		f.Return = parser.returnType(fc.ReturnType)
+	}
+
+	return f, defaultParams
+}
+
+// params adds parameters to the function, default
+// values of the parameters are returned.
+func (parser *fileParser) params(f *lang.Function, params []node.Node) []lang.Expression {
+	defaultParams := make([]lang.Expression, 0)
 	hasDefaultParams := false
-	for _, pr := range fc.Params {
+	for _, pr := range params {
 		p := pr.(*node.Parameter)
+		if !isName(p.VariableType) && !isArrayType(p.VariableType) {
+			parser.fail(p, InvalidConstruct, "Parameter requires a type declaration.")
+		}
 		// Default value has to be by value.
-		typ := lang.NewTyp(parser.constructName(p.VariableType.(*name.Name), true), p.ByRef)
+		typ := parser.typeName(p.VariableType, p.ByRef)
 		v := lang.NewVariable(
 			parser.identifierName(p.Variable.(*expr.Variable)),
 			typ, false)
+		if typ.IsPointer && IsArray(typ.String()[1:]) {
+			// Methods of arrays dereference the pointer.
+			v.CurrentType.IsPointer = false
+		}
 
 		if p.DefaultValue != nil {
-			dv := parser.expression(nil, p.DefaultValue)
+			dv := parser.declaredValue(nil, p.DefaultValue, typ)
 			defaultParams = append(defaultParams, dv)
 			hasDefaultParams = true
 		} else if hasDefaultParams {
-			panic(`Default parameters cannot be defined with gaps.`)
+			parser.fail(p, InvalidConstruct, "Default parameters cannot be defined with gaps.")
 		}
 
 		f.Args = append(f.Args, v)
 	}
+	return defaultParams
+}
 
-	if fc.ReturnType != nil {
-		n := parser.constructName(fc.ReturnType.(*name.Name), true)
-		if n == "void" {
-			n = lang.Void
-		}
-		// In PHP every return type is by value.
-		f.Return = lang.NewTyp(n, false)
+func (parser *fileParser) returnType(n node.Node) lang.Typ {
+	if nm, ok := n.(*name.Name); ok && strings.ToLower(nameOf(nm)) == "void" {
+		return lang.NewTyp(lang.Void, false)
 	}
+	// In PHP every return type is by value.
+	return parser.typeName(n, false)
+}
 
-	return f, defaultParams
+// typeName converts PHP type declaration to the Go type.
+// Objects are always passed as pointers to the struct.
+func (parser *fileParser) typeName(n node.Node, isPointer bool) lang.Typ {
+	if isArrayType(n) {
+		// Declarations do not say types of the items.
+		parser.funcs.Namespace("array")
+		return lang.NewTyp("array.Any", isPointer)
+	}
+	if !isName(n) {
+		parser.fail(n, UnsupportedExpression, "Type declaration %T is not supported.", n)
+	}
+	if c := parser.resolveClass(n); c != nil {
+		return c.typ()
+	}
+	nm, ok := n.(*name.Name)
+	if !ok {
+		parser.fail(n, UnknownClass, "Class %s is not defined.", parser.resolve(n, parser.imports.classes))
+	}
+	t, err := stringToTyp(parser.constructName(nm, true))
+	if err != nil {
+		return lang.NewTyp(parser.constructName(nm, true), isPointer)
+	}
+	t.IsPointer = isPointer
+	return t
+}
+
+// declaredValue translates the value of the declared type,
+// [] is the empty array of the type. Other empty arrays do
+// not have types.
+func (parser *fileParser) declaredValue(b lang.Block, n node.Node, t lang.Typ) lang.Expression {
+	if a, ok := n.(*expr.ShortArray); ok && len(a.Items) == 0 && IsArray(t.String()) {
+		parser.funcs.Namespace("array")
+		e := &lang.FunctionCall{
+			Name:   "array.New" + strings.TrimPrefix(t.String(), "array."),
+			Return: t,
+		}
+		e.SetParent(b)
+		return e
+	}
+	return parser.expression(b, n)
+}
+
+// isArrayType reports if the type declaration is array.
+func isArrayType(n node.Node) bool {
+	id, ok := n.(*node.Identifier)
+	return ok && strings.ToLower(id.Value) == "array"
 }
 
 func (parser *fileParser) createFunction(b lang.Block, stmts []node.Node) {
-	for _, s := range stmts {
+	var i int
+	var mark snapshot
+	var s node.Node
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		parser.skipStatement(b, s, mark, r)
+		parser.createFunction(b, stmts[i+1:])
+	}()
+
+	for i, s = range stmts {
+		mark = parser.snapshot(b)
 		parser.freeFloatingComment(b, s)
 
 		switch s := s.(type) {
@@ -373,7 +609,7 @@ func (parser *fileParser) createFunction(b lang.Block, stmts []node.Node) {
 			b.AddStatement(lf)
 			iterated := parser.expression(lf, s.Expr)
 			if !IsArray(iterated.Type().String()) {
-				panic(`Only arrays can be iterated.`)
+				parser.fail(s.Expr, TypeMismatch, "Only arrays can be iterated, '%s' given.", iterated.Type())
 			}
 
 			var fnName string
@@ -384,18 +620,27 @@ func (parser *fileParser) createFunction(b lang.Block, stmts []node.Node) {
 				fnName = i.String()
 
 			default:
-				panic(`Uncatched type of iterated.`)
+				parser.fail(s.Expr, UnsupportedExpression, "Iterated %T is not supported.", iterated)
 			}
 
-			var it *lang.FunctionCall
+			var it, release *lang.FunctionCall
 			// Easy chain, without array.Pair
 			if s.Key == nil {
 				it = &lang.FunctionCall{
 					Name: fnName + ".Iter",
 					// TODO: Set up return type.
 				}
+				// Variable is held by the loop until it is released,
+				// editing it in the loop clones the array.
+				if _, ok := iterated.(*lang.VarRef); ok {
+					it.Name = fnName + ".Range"
+					release = &lang.FunctionCall{
+						Name:   fnName + ".Release",
+						Return: lang.NewTyp(lang.Void, false),
+					}
+				}
 				name := parser.identifierName(s.Variable.(*expr.Variable))
-				typ := lang.NewTyp(ArrayItem(iterated.Type().String()), false)
+				typ := arrayItem(iterated.Type())
 				lf.Value = *lang.NewVariable(name, typ, false)
 			} else {
 				name := parser.identifierName(s.Key.(*expr.Variable))
@@ -403,7 +648,7 @@ func (parser *fileParser) createFunction(b lang.Block, stmts []node.Node) {
 				// formated as string.
 				k := lang.NewVariable(name, lang.NewTyp(lang.String, false), false)
 				n := parser.identifierName(s.Variable.(*expr.Variable))
-				typ := lang.NewTyp(ArrayItem(iterated.Type().String()), false)
+				typ := arrayItem(iterated.Type())
 				v := lang.NewVariable(n, typ, false)
 
 				it = &lang.FunctionCall{
@@ -427,7 +672,7 @@ func (parser *fileParser) createFunction(b lang.Block, stmts []node.Node) {
 					lf.Block.DefineVariable(k)
 				}
 
-				typ = lang.NewTyp(ArrayItem(iterated.Type().String()), false)
+				typ = arrayItem(iterated.Type())
 				pairV := lang.NewVariable(lf.Value.Name+".V", typ, true)
 				s, err := lang.NewAssign(v, lang.NewVarRef(pairV, pairV.Type()))
 				if err != nil {
@@ -441,6 +686,10 @@ func (parser *fileParser) createFunction(b lang.Block, stmts []node.Node) {
 			lf.Iterated = it
 
 			parser.createFunction(lf.Block, nodeList(s.Stmt))
+			if release != nil {
+				release.SetParent(b)
+				b.AddStatement(release)
+			}
 
 		case *stmt.If:
 			i := parser.constructIf(b, s)
@@ -459,17 +708,44 @@ func (parser *fileParser) createFunction(b lang.Block, stmts []node.Node) {
 		case *stmt.Return:
 			r := &lang.Return{}
 			if s.Expr != nil {
-				r.Expression = parser.expression(b, s.Expr)
+				r.Expression = copyArray(parser.expression(b, s.Expr))
+			}
+			// Values without static types, like results
+			// of arithmetic, get the declared type.
+			if l := literalOf(b); l != nil {
+				if r.Expression != nil && !l.Inferred {
+					if c, ok := parser.funcs.coerce(r.Expression, l.Return); ok {
+						r.Expression = c
+					}
+				}
+				if r.Expression != nil {
+					l.SetReturn(r.Expression.Type())
+				}
+			} else if f := functionOf(b); r.Expression != nil {
+				if c, ok := parser.funcs.coerce(r.Expression, f.Return); ok {
+					r.Expression = c
+				}
+			} else if f.Class != nil && f.Receiver == nil {
+				// Constructor always returns the created struct.
+				this := b.HasVariable("this", true)
+				r.Expression = lang.NewVarRef(this, this.Type())
 			}
+			lang.MarkReturn(b)
 			b.AddStatement(r)
 
+		case *stmt.Try:
+			b.AddStatement(parser.tryStmt(b, s))
+
+		case *stmt.Throw:
+			b.AddStatement(parser.throwStmt(b, s))
+
 		case *stmt.Echo:
 			var args []lang.Expression
 			for _, e := range s.Exprs {
 				// TODO: Do not ignore information in Argument,
 				// it has interesting information like if it is
 				// send by reference and others.
-				args = append(args, parser.expression(b, e))
+				args = append(args, parser.echoValue(b, parser.expression(b, e)))
 			}
 
 			var err error
@@ -487,6 +763,7 @@ func (parser *fileParser) createFunction(b lang.Block, stmts []node.Node) {
 
 		case *stmt.Break:
 			if s.Expr == nil {
+				parser.tryJump(b, s, 1)
 				c := &lang.Break{}
 				c.SetParent(b)
 				b.AddStatement(c)
@@ -496,17 +773,18 @@ func (parser *fileParser) createFunction(b lang.Block, stmts []node.Node) {
 			e := parser.expression(b, s.Expr)
 			v, ok := e.(*lang.Number)
 			if !ok {
-				panic(`break should be numerical`)
+				parser.fail(s.Expr, InvalidConstruct, "break should be numerical")
 			}
 			// Let's say value in this node is valid.
 			n, _ := strconv.Atoi(v.Value)
 			var bb lang.Node = b
 			if n <= 0 {
-				panic(`break jump should be > 0`)
+				parser.fail(s.Expr, InvalidConstruct, "break jump should be > 0")
 			}
+			parser.tryJump(b, s, n)
 			for {
 				if bb == nil {
-					panic(fmt.Sprintf("Invalid break, not enough blocks, still %d needed.", n))
+					parser.fail(s, InvalidConstruct, "Invalid break, not enough blocks, still %d needed.", n)
 				}
 
 				if n == 0 {
@@ -545,6 +823,7 @@ func (parser *fileParser) createFunction(b lang.Block, stmts []node.Node) {
 
 		case *stmt.Continue:
 			if s.Expr == nil {
+				parser.tryJump(b, s, 1)
 				c := &lang.Continue{}
 				c.SetParent(b)
 				b.AddStatement(c)
@@ -554,17 +833,18 @@ func (parser *fileParser) createFunction(b lang.Block, stmts []node.Node) {
 			e := parser.expression(b, s.Expr)
 			v, ok := e.(*lang.Number)
 			if !ok {
-				panic(`continue should be numerical`)
+				parser.fail(s.Expr, InvalidConstruct, "continue should be numerical")
 			}
 			// Let's say value in this node is valid.
 			n, _ := strconv.Atoi(v.Value)
 			var bb lang.Node = b
 			if n <= 0 {
-				panic(`continue jump should be > 0`)
+				parser.fail(s.Expr, InvalidConstruct, "continue jump should be > 0")
 			}
+			parser.tryJump(b, s, n)
 			for {
 				if bb == nil {
-					panic(fmt.Sprintf("Invalid continue, not enough blocks, still %d needed.", n))
+					parser.fail(s, InvalidConstruct, "Invalid continue, not enough blocks, still %d needed.", n)
 				}
 
 				switch bb.(type) {
@@ -604,7 +884,7 @@ func (parser *fileParser) createFunction(b lang.Block, stmts []node.Node) {
 			// 1:1 with the stuff which come from the parser.
 			n := parser.statement(b, s)
 			if n == nil {
-				panic(`Unexpected statement`)
+				parser.fail(s, UnsupportedStatement, "%T is not supported.", s)
 			}
 			n.SetParent(b)
 			b.AddStatement(n)
@@ -612,6 +892,45 @@ func (parser *fileParser) createFunction(b lang.Block, stmts []node.Node) {
 	}
 }
 
+// snapshot is the state of the block and the file before
+// the statement, skipped statement is rolled back to it.
+type snapshot struct {
+	statements int
+	imports    int
+}
+
+func (p *fileParser) snapshot(b lang.Block) snapshot {
+	s := snapshot{imports: len(p.file.Imports())}
+	if c, ok := b.(*lang.Code); ok {
+		s.statements = len(c.Statements)
+	}
+	return s
+}
+
+// skipStatement records a diagnostic for the statement which
+// could not be transpiled. Everything the statement managed
+// to add to the block is removed and replaced by a comment,
+// imports used only by the removed code are dropped too.
+func (p *fileParser) skipStatement(b lang.Block, s node.Node, mark snapshot, r interface{}) {
+	d := p.diagnose(s, r)
+	p.diagnostics = append(p.diagnostics, d)
+
+	if c, ok := b.(*lang.Code); ok && len(c.Statements) > mark.statements {
+		c.Statements = c.Statements[:mark.statements]
+	}
+	p.file.DropImports(mark.imports)
+	// Arrays of classes are declared for good, even if
+	// the statement creating them was skipped.
+	for _, c := range p.file.Classes {
+		if c.Array {
+			p.file.AddImport(p.funcs.funcs["array"].namespace)
+		}
+	}
+	b.AddStatement(&lang.Comment{
+		Value: fmt.Sprintf("php2go: skipped %s at line %d: %s", d.Node, d.Line, d.Message),
+	})
+}
+
 func nodeList(n node.Node) []node.Node {
 	list, ok := n.(*stmt.StmtList)
 	if ok {
@@ -741,13 +1060,15 @@ func (p *fileParser) simpleExpression(b lang.Block, n node.Node) lang.Node {
 	if a, ok := n.(*assign.Assign); ok {
 		r := p.expression(b, a.Expression)
 		if r == nil {
-			panic(`Missing right side for assignment.`)
+			p.fail(a.Expression, UnsupportedExpression, "Missing right side for assignment.")
 		}
+		r = copyArray(r)
 
 		n := p.identifierName(a.Variable.(*expr.Variable))
 		return p.buildAssignment(b, n, r)
 	}
-	panic(`SimpleExpression: something else uncatched. (` + n.GetPosition().String() + `)`)
+	p.fail(n, UnsupportedExpression, "%T is not supported.", n)
+	return nil
 }
 
 func (parser *fileParser) complexExpression(b lang.Block, n node.Node) lang.Expression {
@@ -762,14 +1083,14 @@ func (parser *fileParser) complexExpression(b lang.Block, n node.Node) lang.Expr
 
 	a, ok := n.(*assign.Assign)
 	if !ok {
-		panic(`ComplexExpression: something else uncatched. (` + n.GetPosition().String() + `)`)
+		parser.fail(n, UnsupportedExpression, "%T is not supported.", n)
 	}
 
 	// Every expression should have return value.
 	// Otherwise I cannot say what the assigned value will have.
 	r := parser.complexExpression(b, a.Expression)
 	if r == nil {
-		panic(`Missing right side for assignment.`)
+		parser.fail(a.Expression, UnsupportedExpression, "Missing right side for assignment.")
 	}
 
 	la, ok := r.(*lang.Assign)
@@ -777,46 +1098,48 @@ func (parser *fileParser) complexExpression(b lang.Block, n node.Node) lang.Expr
 		b.AddStatement(la)
 		r = lang.NewVarRef(la.Left(), la.Type())
 	}
+	r = copyArray(r)
 
 	switch v := a.Variable.(type) {
 	case *expr.Variable:
 		n := parser.identifierName(v)
 		return parser.buildAssignment(b, n, r)
 
-	case *expr.ArrayDimFetch:
-		vn := parser.identifierName(v.Variable.(*expr.Variable))
-		vr := b.HasVariable(vn, true)
-		if vr == nil || vr.Type().Equal(lang.Void) {
-			panic(vn + " is not defined.")
-		}
+	case *expr.PropertyFetch:
+		c, f, obj := parser.propertyFetch(b, v)
+		a := parser.assignField(c, f, obj, r)
+		a.SetParent(b)
+		return a
 
-		if vr.Type().Addressable {
+	case *expr.ArrayDimFetch:
+		vr, typ := parser.arrayTarget(b, v.Variable)
+		if typ.Addressable {
 			e := parser.expression(b, v.Dim)
 			str, ok := e.(*lang.Str)
 			if !ok {
-				panic(`Addressable structs can be used only with simple string.`)
+				parser.fail(v.Dim, InvalidConstruct, "Addressable structs can be used only with simple string.")
 			}
 			s := strings.ReplaceAll(str.Value, "\"", "")
 			s = FirstUpper(s)
 
-			t, ok := vr.Type().Tiles[s]
+			t, ok := typ.Tiles[s]
 			if !ok {
-				panic(`Unknown tile struct.`)
+				parser.fail(v.Dim, InvalidConstruct, "Unknown tile struct '%s'.", s)
 			}
 
 			if !r.Type().Eq(t) {
-				panic(`Incompatible types for the assignment in the struct.`)
+				parser.fail(a, TypeMismatch, "Incompatible types for the assignment in the struct.")
 			}
 
-			a, err := lang.NewAssign(lang.NewVariable(vr.String()+"."+s, t, false), r)
+			a, err := lang.NewAssign(lang.NewVariable(vr+"."+s, t, false), r)
 			if err != nil {
 				panic(err)
 			}
 			return a
 		}
 
-		if l, r := ArrayItem(vr.Type().String()), r.Type().String(); l != r {
-			panic(fmt.Sprintf("Array editing: '%s' expected, '%s' given.", l, r))
+		if !arrayAccepts(typ, r.Type()) {
+			parser.fail(a, TypeMismatch, "Array editing: '%s' expected, '%s' given.", arrayItem(typ), r.Type())
 		}
 
 		var fc *lang.FunctionCall
@@ -824,19 +1147,15 @@ func (parser *fileParser) complexExpression(b lang.Block, n node.Node) lang.Expr
 			fc = &lang.FunctionCall{
 				Name:   fmt.Sprintf("%s.Add", vr),
 				Args:   []lang.Expression{r},
-				Return: vr.Type(),
+				Return: typ,
 			}
 			fc.SetParent(b)
 		} else {
-			args := []lang.Expression{parser.expression(b, v.Dim)}
-			scalar, err := parser.funcs.Namespace("array").Call("NewScalar", args)
-			if err != nil {
-				panic(err)
-			}
+			scalar := parser.key(v.Dim, parser.expression(b, v.Dim))
 			fc = &lang.FunctionCall{
 				Name:   fmt.Sprintf("%s.Edit", vr),
 				Args:   []lang.Expression{scalar, r},
-				Return: vr.Type(),
+				Return: typ,
 			}
 
 			scalar.SetParent(fc)
@@ -845,75 +1164,117 @@ func (parser *fileParser) complexExpression(b lang.Block, n node.Node) lang.Expr
 		return fc
 
 	default:
-		panic(fmt.Sprintf("Unexpected left side: %v", a))
+		parser.fail(a.Variable, UnsupportedExpression, "Unexpected left side %T.", a.Variable)
 	}
+	return nil
 }
 
-func (parser *fileParser) directAssignment(b lang.Block, n node.Node) lang.Expression {
-	assignmentFunc := func(op string, expr node.Node, nv *expr.Variable) *lang.Assign {
-		e := parser.expression(b, expr)
-		n := parser.identifierName(nv)
-		v := b.HasVariable(n, true)
-		if v == nil {
-			panic(n + " is not defined.")
+// arrayTarget returns the edited array and its type. Nested arrays
+// are created when they do not exist, the same way as in PHP.
+func (p *fileParser) arrayTarget(b lang.Block, n node.Node) (string, lang.Typ) {
+	switch v := n.(type) {
+	case *expr.Variable:
+		vn := p.identifierName(v)
+		vr := b.HasVariable(vn, true)
+		if vr == nil || vr.Type().Equal(lang.Void) {
+			p.fail(v, UndefinedVariable, "'%s' is not defined.", vn)
 		}
-		e = parser.bOp(b, op, lang.NewVarRef(v, v.CurrentType), e)
-		if e == nil {
-			panic("Issue with a binary operand.")
+		return vr.String(), vr.Type()
+
+	case *expr.ArrayDimFetch:
+		arr, typ := p.arrayTarget(b, v.Variable)
+		if !IsArray(typ.String()) || !IsArray(arrayItem(typ).String()) {
+			p.fail(v, TypeMismatch, "Cannot use '%s' as an array.", arrayItem(typ))
+		}
+		if v.Dim == nil {
+			p.fail(v, UnsupportedExpression, "Appending of nested arrays is not supported.")
 		}
-		return parser.buildAssignment(b, n, e)
+		scalar := p.key(v.Dim, p.expression(b, v.Dim))
+		return fmt.Sprintf("%s.Sub(%s)", arr, scalar), arrayItem(typ)
+	}
+	p.fail(n, UnsupportedExpression, "Expected variable to be indexed.")
+	return "", lang.Typ{}
+}
+
+func (parser *fileParser) directAssignment(b lang.Block, n node.Node) lang.Expression {
+	assignmentFunc := func(op string, expr node.Node, target node.Node) *lang.Assign {
+		e := parser.expression(b, expr)
+		return parser.compoundAssignment(b, target, func(cur lang.Expression) lang.Expression {
+			res := parser.bOp(b, op, cur, e)
+			if res == nil {
+				parser.fail(expr, TypeMismatch, "Issue with a binary operand.")
+			}
+			return res
+		})
 	}
 
 	switch a := n.(type) {
 	case *assign.Concat:
 		e := parser.expression(b, a.Expression)
-		n := parser.identifierName(a.Variable.(*expr.Variable))
-		v := b.HasVariable(n, true)
-		if v == nil {
-			panic(n + " is not defined.")
-		}
-		fc, err := parser.funcs.Namespace("std").Call("Concat", []lang.Expression{
-			lang.NewVarRef(v, v.CurrentType), e,
+		return parser.compoundAssignment(b, a.Variable, func(cur lang.Expression) lang.Expression {
+			fc, err := parser.funcs.Namespace("std").Call("Concat", []lang.Expression{cur, e})
+			if err != nil {
+				panic(err)
+			}
+			return fc
 		})
-		if err != nil {
-			panic(err)
-		}
-		return parser.buildAssignment(b, n, fc)
 
 	case *assign.Plus:
-		return assignmentFunc("+", a.Expression, a.Variable.(*expr.Variable))
+		return assignmentFunc("+", a.Expression, a.Variable)
 
 	case *assign.Minus:
-		return assignmentFunc("-", a.Expression, a.Variable.(*expr.Variable))
+		return assignmentFunc("-", a.Expression, a.Variable)
 
 	case *assign.Div:
-		return assignmentFunc("/", a.Expression, a.Variable.(*expr.Variable))
+		return assignmentFunc("/", a.Expression, a.Variable)
 
 	case *assign.Mul:
-		return assignmentFunc("*", a.Expression, a.Variable.(*expr.Variable))
+		return assignmentFunc("*", a.Expression, a.Variable)
 
 	case *assign.Mod:
-		return assignmentFunc("%", a.Expression, a.Variable.(*expr.Variable))
+		return assignmentFunc("%", a.Expression, a.Variable)
 
 	case *assign.BitwiseAnd:
-		return assignmentFunc("&", a.Expression, a.Variable.(*expr.Variable))
+		return assignmentFunc("&", a.Expression, a.Variable)
 
 	case *assign.BitwiseOr:
-		return assignmentFunc("|", a.Expression, a.Variable.(*expr.Variable))
+		return assignmentFunc("|", a.Expression, a.Variable)
 
 	case *assign.BitwiseXor:
-		return assignmentFunc("^", a.Expression, a.Variable.(*expr.Variable))
+		return assignmentFunc("^", a.Expression, a.Variable)
 
 	case *assign.ShiftLeft:
-		return assignmentFunc("<<", a.Expression, a.Variable.(*expr.Variable))
+		return assignmentFunc("<<", a.Expression, a.Variable)
 
 	case *assign.ShiftRight:
-		return assignmentFunc(">>", a.Expression, a.Variable.(*expr.Variable))
+		return assignmentFunc(">>", a.Expression, a.Variable)
 	}
 
 	return nil
 }
 
+// compoundAssignment assigns the value computed from the current
+// value of the variable or the property, like $a += 1 does.
+func (parser *fileParser) compoundAssignment(b lang.Block, target node.Node, value func(lang.Expression) lang.Expression) *lang.Assign {
+	switch t := target.(type) {
+	case *expr.Variable:
+		n := parser.identifierName(t)
+		v := b.HasVariable(n, true)
+		if v == nil {
+			parser.fail(t, UndefinedVariable, "'%s' is not defined.", n)
+		}
+		return parser.buildAssignment(b, n, value(lang.NewVarRef(v, v.CurrentType)))
+
+	case *expr.PropertyFetch:
+		c, f, obj := parser.propertyFetch(b, t)
+		a := parser.assignField(c, f, obj, value(parser.property(b, t)))
+		a.SetParent(b)
+		return a
+	}
+	parser.fail(target, UnsupportedExpression, "Assignment to %T is not supported.", target)
+	return nil
+}
+
 func (parser *fileParser) statement(b lang.Block, n node.Node) lang.Node {
 	// Inpisration from parser.expression, *expr.ArrayDimFetch.
 	switch s := n.(type) {
@@ -923,18 +1284,14 @@ func (parser *fileParser) statement(b lang.Block, n node.Node) lang.Node {
 		for _, v := range s.Vars {
 			adf, ok := v.(*expr.ArrayDimFetch)
 			if !ok {
-				panic(`Only arrays are accepted for unset.`)
+				parser.fail(v, UnsupportedStatement, "Only arrays are accepted for unset.")
 			}
 			vn := parser.identifierName(adf.Variable.(*expr.Variable))
 			v := b.HasVariable(vn, true)
 			if v == nil || v.Type().Equal(lang.Void) {
-				panic(vn + " is not defined.")
-			}
-			args := []lang.Expression{parser.expression(b, adf.Dim)}
-			scalar, err := parser.funcs.Namespace("array").Call("NewScalar", args)
-			if err != nil {
-				panic(err)
+				parser.fail(adf.Variable, UndefinedVariable, "'%s' is not defined.", vn)
 			}
+			scalar := parser.key(adf.Dim, parser.expression(b, adf.Dim))
 
 			fc := &lang.FunctionCall{
 				Name: fmt.Sprintf("%s.Unset", v),
@@ -948,6 +1305,9 @@ func (parser *fileParser) statement(b lang.Block, n node.Node) lang.Node {
 		}
 		return ret
 
+	case *assign.Coalesce:
+		return parser.coalesceAssign(b, s)
+
 	case *stmt.Label:
 		n := parser.labelTranslator.Translate(s.LabelName.(*node.Identifier).Value)
 		return &lang.Const{Value: n + ":"}
@@ -963,7 +1323,7 @@ func (parser *fileParser) statement(b lang.Block, n node.Node) lang.Node {
 			vn := parser.identifierName(v.(*expr.Variable))
 			v := parser.gc.HasVariable(vn, false)
 			if v == nil {
-				panic(fmt.Sprintf("'%s' is not defined.", vn))
+				parser.fail(s, UndefinedVariable, "'%s' is not defined.", vn)
 			}
 			b.DefineVariable(v)
 			parser.requireGlobal(b)
@@ -978,10 +1338,13 @@ func (parser *fileParser) statement(b lang.Block, n node.Node) lang.Node {
 
 	inc := func() lang.Node {
 		if !ok {
-			panic(`"++" requires variable.`)
+			parser.fail(n, InvalidConstruct, `"++" requires variable.`)
 		}
 		if b.HasVariable(v.V.Name, true) == nil {
-			panic(fmt.Sprintf("'%s' is not defined.", v.V.Name))
+			parser.fail(n, UndefinedVariable, "'%s' is not defined.", v.V.Name)
+		}
+		if a := parser.checkedStep(b, "+", v); a != nil {
+			return a
 		}
 		return lang.NewInc(
 			b, v,
@@ -992,10 +1355,13 @@ func (parser *fileParser) statement(b lang.Block, n node.Node) lang.Node {
 	}
 	dec := func() lang.Node {
 		if !ok {
-			panic(`"--" requires variable.`)
+			parser.fail(n, InvalidConstruct, `"--" requires variable.`)
 		}
 		if b.HasVariable(v.V.Name, true) == nil {
-			panic(fmt.Sprintf("'%s' is not defined.", v.V.Name))
+			parser.fail(n, UndefinedVariable, "'%s' is not defined.", v.V.Name)
+		}
+		if a := parser.checkedStep(b, "-", v); a != nil {
+			return a
 		}
 		return lang.NewDec(
 			b, v,
@@ -1007,16 +1373,28 @@ func (parser *fileParser) statement(b lang.Block, n node.Node) lang.Node {
 
 	switch e := n.(type) {
 	case *expr.PreInc:
+		if pf, isField := e.Variable.(*expr.PropertyFetch); isField {
+			return parser.fieldStep(b, "+", pf)
+		}
 		v, ok = parser.expression(b, e.Variable).(*lang.VarRef)
 		return inc()
 	case *expr.PostInc:
+		if pf, isField := e.Variable.(*expr.PropertyFetch); isField {
+			return parser.fieldStep(b, "+", pf)
+		}
 		v, ok = parser.expression(b, e.Variable).(*lang.VarRef)
 		return inc()
 
 	case *expr.PreDec:
+		if pf, isField := e.Variable.(*expr.PropertyFetch); isField {
+			return parser.fieldStep(b, "-", pf)
+		}
 		v, ok = parser.expression(b, e.Variable).(*lang.VarRef)
 		return dec()
 	case *expr.PostDec:
+		if pf, isField := e.Variable.(*expr.PropertyFetch); isField {
+			return parser.fieldStep(b, "-", pf)
+		}
 		v, ok = parser.expression(b, e.Variable).(*lang.VarRef)
 		return dec()
 	}
@@ -1032,19 +1410,13 @@ func (parser *fileParser) expression(b lang.Block, n node.Node) lang.Expression
 			val = e.Value
 
 		default:
-			panic(`Only simple string can be in require.`)
+			parser.fail(n, UnsupportedExpression, "Only simple string can be in require.")
 		}
-		val = strings.ReplaceAll(val, "\"", "")
-		val = strings.ReplaceAll(val, "./", "")
-
-		// TODO: To the function, probably.
-		name := parser.file.Name
-		i := strings.LastIndex(name, "/")
-		if i > 0 {
-			name = name[:i+1]
+		fc, err := parser.require(parser.includePath(val))
+		if err != nil {
+			parser.fail(n, IncludeFailed, "%v", err)
 		}
-
-		return parser.require(name + val)
+		return fc
 	}
 
 	switch e := n.(type) {
@@ -1052,7 +1424,7 @@ func (parser *fileParser) expression(b lang.Block, n node.Node) lang.Expression
 		name := parser.identifierName(e)
 		v := b.HasVariable(name, true)
 		if v == nil {
-			panic("Using undefined variable \"" + name + "\".")
+			parser.fail(e, UndefinedVariable, "Using undefined variable \"%s\".", name)
 		}
 		return lang.NewVarRef(v, v.Type())
 
@@ -1070,7 +1442,7 @@ func (parser *fileParser) expression(b lang.Block, n node.Node) lang.Expression
 				vn := parser.identifierName(p)
 				v := b.HasVariable(vn, true)
 				if v == nil || v.Type().Equal(lang.Void) {
-					panic(vn + " is not defined.")
+					parser.fail(p, UndefinedVariable, "'%s' is not defined.", vn)
 				}
 				s.Value += v.Type().Format()
 				args = append(args, lang.NewVarRef(v, v.Type()))
@@ -1079,21 +1451,21 @@ func (parser *fileParser) expression(b lang.Block, n node.Node) lang.Expression
 				vn := parser.identifierName(p.Variable.(*expr.Variable))
 				v := b.HasVariable(vn, true)
 				if v == nil || v.Type().Equal(lang.Void) {
-					panic(vn + " is not defined.")
+					parser.fail(p, UndefinedVariable, "'%s' is not defined.", vn)
 				}
 
 				if v.Type().Addressable {
 					e := parser.expression(b, p.Dim)
 					str, ok := e.(*lang.Str)
 					if !ok {
-						panic(`Addressable structs can be used only with simple string.`)
+						parser.fail(p.Dim, InvalidConstruct, "Addressable structs can be used only with simple string.")
 					}
 					s := strings.ReplaceAll(str.Value, "\"", "")
 					s = FirstUpper(s)
 
 					t, ok := v.Type().Tiles[s]
 					if !ok {
-						panic(`Unknown tile struct.`)
+						parser.fail(p.Dim, InvalidConstruct, "Unknown tile struct '%s'.", s)
 					}
 
 					args = append(args, lang.NewVarRef(
@@ -1101,16 +1473,12 @@ func (parser *fileParser) expression(b lang.Block, n node.Node) lang.Expression
 						t,
 					))
 				} else {
-					scalar, err := parser.funcs.Namespace("array").Call(
-						"NewScalar", []lang.Expression{parser.expression(b, p.Dim)})
-					if err != nil {
-						panic(err)
-					}
+					scalar := parser.key(p.Dim, parser.expression(b, p.Dim))
 
 					fc := &lang.FunctionCall{
 						Name:   fmt.Sprintf("%s.At", v),
 						Args:   []lang.Expression{scalar},
-						Return: lang.NewTyp(ArrayItem(v.Type().String()), false),
+						Return: arrayItem(v.Type()),
 					}
 					scalar.SetParent(fc)
 					fc.SetParent(b)
@@ -1119,6 +1487,14 @@ func (parser *fileParser) expression(b lang.Block, n node.Node) lang.Expression
 
 					args = append(args, fc)
 				}
+
+			case *expr.PropertyFetch:
+				pr := parser.property(b, p)
+				s.Value += pr.Type().Format()
+				args = append(args, pr)
+
+			default:
+				parser.fail(p, UnsupportedExpression, "%T is not supported in the string.", p)
 			}
 		}
 		s.Value += "\""
@@ -1133,32 +1509,13 @@ func (parser *fileParser) expression(b lang.Block, n node.Node) lang.Expression
 
 	case *expr.Isset:
 		if len(e.Variables) != 1 {
-			panic(`Isset can have only one argument, for now.`)
+			parser.fail(e, UnsupportedExpression, "Isset can have only one argument, for now.")
 		}
 		adf, ok := e.Variables[0].(*expr.ArrayDimFetch)
 		if !ok {
-			panic(`Only arrays are accepted for isset.`)
-		}
-		vn := parser.identifierName(adf.Variable.(*expr.Variable))
-		v := b.HasVariable(vn, true)
-		if v == nil || v.Type().Equal(lang.Void) {
-			panic(vn + " is not defined.")
-		}
-
-		args := []lang.Expression{parser.expression(b, adf.Dim)}
-		scalar, err := parser.funcs.Namespace("array").Call("NewScalar", args)
-		if err != nil {
-			panic(err)
-		}
-
-		fc := &lang.FunctionCall{
-			Name:   fmt.Sprintf("%s.Isset", v),
-			Args:   []lang.Expression{scalar},
-			Return: lang.NewTyp(lang.Bool, false),
+			parser.fail(e, UnsupportedExpression, "Only arrays are accepted for isset.")
 		}
-
-		fc.SetParent(b)
-		return fc
+		return parser.isset(b, adf)
 
 	case *expr.UnaryPlus:
 		expr := parser.expression(b, e.Expr)
@@ -1206,7 +1563,7 @@ func (parser *fileParser) expression(b lang.Block, n node.Node) lang.Expression
 	case *scalar.MagicConstant:
 		s := e.Value
 		if s != "__DIR__" {
-			panic(`Invalid value of magic constant.`)
+			parser.fail(e, UnsupportedExpression, "Magic constant %s is not supported.", s)
 		}
 		str := &lang.Str{
 			Value: "\".\"",
@@ -1216,64 +1573,57 @@ func (parser *fileParser) expression(b lang.Block, n node.Node) lang.Expression
 
 	case *expr.ShortArray:
 		items := make([]lang.Expression, 0)
+		keys := make([]lang.Expression, 0)
 		for _, i := range e.Items {
-			v := i.(*expr.ArrayItem).Val
-			if v == nil {
+			ai := i.(*expr.ArrayItem)
+			if ai.Val == nil {
 				continue
 			}
-			items = append(items, parser.expression(b, v))
-		}
-		if len(items) == 0 {
-			panic(`Cannot decide type, empty array.`)
-		}
-		typ := items[0].Type().String()
-		for _, i := range items {
-			if !i.Type().Equal(typ) {
-				panic(`Type is not the same for every element of the array.`)
+			var k lang.Expression
+			if ai.Key != nil {
+				k = parser.key(ai.Key, parser.expression(b, ai.Key))
+			} else if len(keys) > 0 && keys[len(keys)-1] != nil {
+				parser.fail(ai, UnsupportedExpression, "Items without keys have to precede the ones with keys.")
 			}
+			keys = append(keys, k)
+			items = append(items, parser.expression(b, ai.Val))
 		}
-
-		f, err := parser.funcs.Namespace("array").Call("New"+FirstUpper(typ), items)
-		if err != nil {
-			panic(err)
+		if len(items) == 0 {
+			parser.fail(e, TypeMismatch, "Cannot decide type, empty array.")
 		}
-
-		f.SetParent(b)
-		return f
+		return parser.newArray(b, keys, items)
 
 	case *expr.ArrayDimFetch:
-		v, ok := parser.expression(b, e.Variable).(*lang.VarRef)
-		if !ok {
-			panic(`Expected variable to be indexed.`)
-		}
-
-		if v.Type().Addressable {
+		v := parser.expression(b, e.Variable)
+		if _, ok := v.(*lang.VarRef); ok && v.Type().Addressable {
 			ex := parser.expression(b, e.Dim)
 			str, ok := ex.(*lang.Str)
 			if !ok {
-				panic(`Addressable structs can be used only with simple string.`)
+				parser.fail(e.Dim, InvalidConstruct, "Addressable structs can be used only with simple string.")
 			}
 			s := strings.ReplaceAll(str.Value, "\"", "")
 			s = FirstUpper(s)
 
 			t, ok := v.Type().Tiles[s]
 			if !ok {
-				panic(`Unknown tile struct.`)
+				parser.fail(e.Dim, InvalidConstruct, "Unknown tile struct '%s'.", s)
 			}
 
 			return lang.NewVarRef(lang.NewVariable(v.String()+"."+s, t, false), t)
 		}
-
-		args := []lang.Expression{parser.expression(b, e.Dim)}
-		scalar, err := parser.funcs.Namespace("array").Call("NewScalar", args)
-		if err != nil {
-			panic(err)
+		if !IsArray(v.Type().String()) {
+			parser.fail(e.Variable, TypeMismatch, "Cannot use '%s' as an array.", v.Type())
 		}
+		if e.Dim == nil {
+			parser.fail(e, InvalidConstruct, "Cannot use [] for reading.")
+		}
+
+		scalar := parser.key(e.Dim, parser.expression(b, e.Dim))
 
 		fc := &lang.FunctionCall{
 			Name:   fmt.Sprintf("%s.At", v),
 			Args:   []lang.Expression{scalar},
-			Return: lang.NewTyp(ArrayItem(v.Type().String()), false),
+			Return: arrayItem(v.Type()),
 		}
 		scalar.SetParent(fc)
 		fc.SetParent(b)
@@ -1287,14 +1637,6 @@ func (parser *fileParser) expression(b lang.Block, n node.Node) lang.Expression
 		return includeRequire(e.Expr)
 	case *expr.IncludeOnce:
 		return includeRequire(e.Expr)
-		/*
-			TODO:
-			[.] coalesce
-			[.] equal
-			[.] not_equal
-			[.] pow // **
-			[.] spaceship
-		*/
 
 	case *binary.Plus:
 		return parser.binaryOp(b, "+", e.Left, e.Right)
@@ -1311,31 +1653,51 @@ func (parser *fileParser) expression(b lang.Block, n node.Node) lang.Expression
 	case *binary.Mod:
 		return parser.binaryOp(b, "%", e.Left, e.Right)
 
+	case *binary.Pow:
+		args := []lang.Expression{
+			parser.expression(b, e.Left),
+			parser.expression(b, e.Right),
+		}
+		f, nsp, err := pow(b, args)
+		if err != nil {
+			parser.fail(e, TypeMismatch, "**: %v", err)
+		}
+		parser.funcs.Namespace(nsp)
+		return f
+
 	case *binary.Smaller:
-		return parser.binaryOp(b, "<", e.Left, e.Right)
+		return parser.compare(b, "<", e.Left, e.Right)
 
 	case *binary.SmallerOrEqual:
-		return parser.binaryOp(b, "<=", e.Left, e.Right)
+		return parser.compare(b, "<=", e.Left, e.Right)
 
 	case *binary.GreaterOrEqual:
-		return parser.binaryOp(b, ">=", e.Left, e.Right)
+		return parser.compare(b, ">=", e.Left, e.Right)
 
 	case *binary.Greater:
-		return parser.binaryOp(b, ">", e.Left, e.Right)
+		return parser.compare(b, ">", e.Left, e.Right)
 
-	// TODO: Improve solution to really just use equal.
 	case *binary.Equal:
-		return parser.binaryOp(b, "==", e.Left, e.Right)
+		return parser.compare(b, "==", e.Left, e.Right)
 
 	case *binary.Identical:
-		return parser.binaryOp(b, "==", e.Left, e.Right)
+		return parser.identical(b, "==", e.Left, e.Right)
 
-		// TODO: Improve solution to really just use not-equal.
 	case *binary.NotEqual:
-		return parser.binaryOp(b, "!=", e.Left, e.Right)
+		return parser.compare(b, "!=", e.Left, e.Right)
+
+	case *binary.Spaceship:
+		f, err := parser.funcs.Namespace("std").Call("Compare", []lang.Expression{
+			parser.expression(b, e.Left),
+			parser.expression(b, e.Right),
+		})
+		if err != nil {
+			panic(err)
+		}
+		return f
 
 	case *binary.NotIdentical:
-		return parser.binaryOp(b, "!=", e.Left, e.Right)
+		return parser.identical(b, "!=", e.Left, e.Right)
 
 	case *binary.LogicalAnd:
 		// and
@@ -1372,11 +1734,28 @@ func (parser *fileParser) expression(b lang.Block, n node.Node) lang.Expression
 
 	case *expr.ConstFetch:
 		n := parser.constructName(e.Constant.(*name.Name), true)
+		if n == "null" {
+			nl := &lang.Nil{}
+			nl.SetParent(b)
+			return nl
+		}
 		if n == "php_eol" {
 			s := &lang.Str{Value: "\"\\n\""}
 			s.SetParent(b)
 			return s
 		}
+		if f, ok := constantsPHP[parser.constructName(e.Constant.(*name.Name), false)]; ok {
+			parser.funcs.Namespace("std")
+			nb := &lang.Number{Value: f}
+			nb.SetParent(b)
+			return nb
+		}
+		if f, ok := floatConstantsPHP[parser.constructName(e.Constant.(*name.Name), false)]; ok {
+			parser.funcs.Namespace("std")
+			fl := &lang.Float{Value: f}
+			fl.SetParent(b)
+			return fl
+		}
 		c := &lang.Const{
 			Value: n,
 		}
@@ -1397,15 +1776,11 @@ func (parser *fileParser) expression(b lang.Block, n node.Node) lang.Expression
 		return f
 
 	case *expr.FunctionCall:
-		n := parser.constructName(e.Function.(*name.Name), false)
-		arguments := e.ArgumentList.Arguments
-		args := make([]lang.Expression, 0, len(arguments))
-		for _, a := range arguments {
-			// TODO: Do not ignore information in Argument,
-			// it has interesting information like if it is
-			// send by reference and others.
-			args = append(args, parser.expression(b, a.(*node.Argument).Expr))
+		if !isName(e.Function) {
+			return parser.callValue(b, e)
 		}
+		n := nameOf(e.Function)
+		args := parser.arguments(b, e.ArgumentList)
 
 		if n == "printf" {
 			var err error
@@ -1417,7 +1792,7 @@ func (parser *fileParser) expression(b lang.Block, n node.Node) lang.Expression
 				f, err = parser.funcs.Namespace("fmt").Call("Printf", args)
 			}
 			if err != nil {
-				panic(err)
+				parser.fail(e, TypeMismatch, "%v", err)
 			}
 			return f
 		}
@@ -1425,9 +1800,17 @@ func (parser *fileParser) expression(b lang.Block, n node.Node) lang.Expression
 		if fc, ok := functionsPHP[n]; ok {
 			f, nsp, err := fc(b, args)
 			if err != nil {
-				panic(err)
+				parser.fail(e, TypeMismatch, "%s: %v", n, err)
 			}
 			parser.funcs.Namespace(nsp)
+			if convertsByStd(f) {
+				parser.funcs.Namespace("std")
+			}
+			// Variables holding the result can be declared
+			// with its type, e.g. globals.
+			if strings.HasPrefix(f.Return.String(), "array.") {
+				parser.funcs.Namespace("array")
+			}
 
 			if n == "mysqli_select_db" {
 				b.AddStatement(f)
@@ -1437,27 +1820,168 @@ func (parser *fileParser) expression(b lang.Block, n node.Node) lang.Expression
 			return f
 		}
 
-		n = parser.constructName(e.Function.(*name.Name), true)
+		pkg, fn := parser.resolveFunction(e.Function)
+		if pkg == "" && parser.inPackage() {
+			if _, ok := (*parser.funcs.Namespace("").Func)[fn]; ok {
+				parser.fail(e, InvalidConstruct, "Function %s of the main package cannot be used in the namespace %s.", n, parser.namespace)
+			}
+		}
 
-		f, err := parser.funcs.Namespace("").Call(n, args)
-		if err != nil {
-			panic(err)
+		f, err := parser.funcs.Namespace(pkg).Call(fn, args)
+		if _, ok := err.(*undefinedFunctionError); ok {
+			return parser.undefinedFunction(e, n, args)
+		} else if err != nil {
+			parser.fail(e, TypeMismatch, "%v", err)
 		}
 		return f
 
+	case *expr.New:
+		return parser.newObject(b, e)
+
+	case *expr.PropertyFetch:
+		return parser.property(b, e)
+
+	case *expr.MethodCall:
+		return parser.methodCall(b, e)
+
+	case *expr.StaticCall:
+		return parser.staticCall(b, e)
+
+	case *expr.InstanceOf:
+		return parser.instanceOf(b, e)
+
+	case *expr.Ternary:
+		return parser.ternary(b, e)
+
+	case *binary.Coalesce:
+		return parser.coalesce(b, e)
+
+	case *expr.Closure:
+		return parser.closure(b, e)
+
+	case *expr.ArrowFunction:
+		return parser.arrowFunction(b, e)
+
 	case *cast.Int:
-		f, err := parser.funcs.Namespace("std").Call("ToInt", []lang.Expression{
-			parser.expression(b, e.Expr),
-		})
-		if err != nil {
-			panic(err)
-		}
-		return f
+		return parser.cast(b, "ToInt", e.Expr)
+
+	case *cast.Double:
+		return parser.cast(b, "ToFloat64", e.Expr)
 
+	case *cast.String:
+		return parser.cast(b, "ToString", e.Expr)
+
+	case *cast.Bool:
+		return parser.cast(b, "ToBool", e.Expr)
+
+	case nil,
+		*assign.Assign, *assign.Reference, *assign.Concat,
+		*assign.Plus, *assign.Minus, *assign.Mul, *assign.Div,
+		*assign.Mod, *assign.Pow, *assign.Coalesce,
+		*assign.BitwiseAnd, *assign.BitwiseOr, *assign.BitwiseXor,
+		*assign.ShiftLeft, *assign.ShiftRight,
+		*expr.PreInc, *expr.PostInc, *expr.PreDec, *expr.PostDec:
+		// Assignments and {inc,dec}rements are not expressions
+		// in Go, they are resolved by the caller.
+		return nil
 	}
+	parser.fail(n, UnsupportedExpression, "%T is not supported.", n)
 	return nil
 }
 
+// isset checks presence of the key in the array,
+// nested arrays are checked from the outermost one.
+func (parser *fileParser) isset(b lang.Block, adf *expr.ArrayDimFetch) lang.Expression {
+	var v fmt.Stringer
+	var outer lang.Expression
+	switch n := adf.Variable.(type) {
+	case *expr.Variable:
+		vn := parser.identifierName(n)
+		vr := b.HasVariable(vn, true)
+		if vr == nil || vr.Type().Equal(lang.Void) {
+			parser.fail(adf.Variable, UndefinedVariable, "'%s' is not defined.", vn)
+		}
+		v = vr
+
+	case *expr.ArrayDimFetch:
+		outer = parser.isset(b, n)
+		v = parser.expression(b, n)
+
+	default:
+		parser.fail(adf.Variable, UnsupportedExpression, "Expected variable to be indexed.")
+	}
+
+	scalar := parser.key(adf.Dim, parser.expression(b, adf.Dim))
+
+	fc := &lang.FunctionCall{
+		Name:   fmt.Sprintf("%s.Isset", v),
+		Args:   []lang.Expression{scalar},
+		Return: lang.NewTyp(lang.Bool, false),
+	}
+
+	fc.SetParent(b)
+	if outer != nil {
+		return parser.bOp(b, "&&", outer, fc)
+	}
+	return fc
+}
+
+func (p *fileParser) arguments(b lang.Block, l *node.ArgumentList) []lang.Expression {
+	args := make([]lang.Expression, 0, len(l.Arguments))
+	for _, a := range l.Arguments {
+		// TODO: Do not ignore information in Argument,
+		// it has interesting information like if it is
+		// send by reference and others.
+		args = append(args, p.expression(b, a.(*node.Argument).Expr))
+	}
+	return args
+}
+
+// undefinedFunction reports a call of the unknown function. In the
+// analysis mode the call is kept with return type interface{}, so
+// the rest of the statement can be analysed.
+func (p *fileParser) undefinedFunction(n node.Node, name string, args []lang.Expression) lang.Expression {
+	d := &Diagnostic{
+		Severity: Error,
+		Code:     UnknownFunction,
+		Symbol:   name,
+		Message:  fmt.Sprintf("Function '%s' is not defined.", name),
+	}
+	p.locate(d, n)
+	if !p.analysis {
+		panic(d)
+	}
+	p.diagnostics = append(p.diagnostics, *d)
+
+	fc := &lang.FunctionCall{
+		Name:   name,
+		Args:   args,
+		Return: lang.NewTyp(lang.Anything, false),
+	}
+	for _, a := range args {
+		a.SetParent(fc)
+	}
+	return fc
+}
+
+// convertsByStd reports if arguments of the call are
+// converted by std, see convertArg. Translators of PHP
+// functions return only the package of the function.
+func convertsByStd(f *lang.FunctionCall) bool {
+	for _, a := range f.Args {
+		c, ok := a.(*lang.FunctionCall)
+		if !ok {
+			continue
+		}
+		for _, fn := range scalarConversions {
+			if c.Name == "std."+fn {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (p *fileParser) binaryOp(b lang.Block, op string, left, right node.Node) lang.Expression {
 	l := p.expression(b, left)
 	r := p.expression(b, right)
@@ -1465,6 +1989,9 @@ func (p *fileParser) binaryOp(b lang.Block, op string, left, right node.Node) la
 }
 
 func (p *fileParser) bOp(b lang.Block, op string, l, r lang.Expression) lang.Expression {
+	if res := p.arithmetic(b, op, l, r); res != nil {
+		return res
+	}
 	l, r, c := convertToMatchingType(l, r)
 	if c {
 		p.funcs.Namespace("std")
@@ -1477,6 +2004,466 @@ func (p *fileParser) bOp(b lang.Block, op string, l, r lang.Expression) lang.Exp
 	return res
 }
 
+// arithmeticFuncs are std functions of the arithmetic operators.
+var arithmeticFuncs = map[string]string{
+	"+": "Add",
+	"-": "Sub",
+	"*": "Mul",
+	"/": "Div",
+	"%": "Mod",
+}
+
+// arithmetic translates PHP arithmetic, which Go operators cannot
+// do. Values without static types and strings are converted to
+// numbers by std. Ints overflow to floats, unless the check is off,
+// and their division is a float when it is not exact. Nil is
+// returned when Go operators can be used.
+func (p *fileParser) arithmetic(b lang.Block, op string, l, r lang.Expression) lang.Expression {
+	name, ok := arithmeticFuncs[op]
+	if !ok {
+		return nil
+	}
+	lt, rt := l.Type(), r.Type()
+	switch {
+	case lt.Equal(lang.Anything) || rt.Equal(lang.Anything) ||
+		lt.Equal(lang.String) || rt.Equal(lang.String):
+		l, r = untyped(l), untyped(r)
+
+	case lt.Equal(lang.Int) && rt.Equal(lang.Int):
+		switch {
+		case op == "%":
+			return nil
+		case op != "/" && (!p.checkOverflow || constantFits(op, l, r)):
+			return nil
+		}
+		// Variables can hold floats already, when they
+		// are assigned results of arithmetic in loops.
+		if !mayWiden(b, l) && !mayWiden(b, r) {
+			name += "Int"
+			break
+		}
+		l, r = untyped(l), untyped(r)
+
+	case mixedNumbers(lt, rt) && (mayWiden(b, l) || mayWiden(b, r)):
+		// Float result turns the int variable to interface{}
+		// in loops, the conversion of the int would panic.
+		l, r = untyped(l), untyped(r)
+
+	default:
+		return nil
+	}
+
+	res, err := p.funcs.Namespace("std").Call(name, []lang.Expression{l, r})
+	if err != nil {
+		panic(err)
+	}
+	res.SetParent(b)
+	return res
+}
+
+// mixedNumbers reports if one type is int and the other float.
+func mixedNumbers(lt, rt lang.Typ) bool {
+	return lt.Equal(lang.Int) && rt.Equal(lang.Float64) ||
+		lt.Equal(lang.Float64) && rt.Equal(lang.Int)
+}
+
+// checkedStep lowers increments and decrements of ints to
+// the arithmetic, so they overflow to floats like in PHP.
+// Values without static types are lowered too, Go operators
+// cannot be used with them. Loop counters keep Go operators,
+// see keepsType.
+func (p *fileParser) checkedStep(b lang.Block, op string, v *lang.VarRef) *lang.Assign {
+	switch t := v.Type(); {
+	case t.IsPointer:
+		return nil
+	case t.Equal(lang.Anything):
+	case !p.checkOverflow || !t.Equal(lang.Int) || loopCounter(b, v.V):
+		return nil
+	}
+	e := p.arithmetic(b, op, v, &lang.Number{Value: "1"})
+	return p.buildAssignment(b, v.V.Name, e)
+}
+
+// echoValue converts floats and values without static types
+// to strings, fmt would not format floats the PHP way.
+func (p *fileParser) echoValue(b lang.Block, e lang.Expression) lang.Expression {
+	if t := e.Type(); !t.Equal(lang.Float64) && !t.Equal(lang.Anything) {
+		return e
+	}
+	s, err := p.funcs.Namespace("std").Call("ToString", []lang.Expression{e})
+	if err != nil {
+		panic(err)
+	}
+	s.SetParent(b)
+	return s
+}
+
+// mayWiden reports if the expression is a variable, which can
+// get values of other types, see keepsType.
+func mayWiden(b lang.Block, e lang.Expression) bool {
+	v, ok := e.(*lang.VarRef)
+	return ok && !keepsType(b, v.V)
+}
+
+// keepsType reports if the variable cannot change its type.
+// Parameters keep their declared types and Go cannot redeclare
+// counters of for loops.
+func keepsType(b lang.Block, v *lang.Variable) bool {
+	return isParam(b, v) || loopCounter(b, v)
+}
+
+// loopCounter reports if the variable is declared
+// in the initialization of the enclosing for loop.
+func loopCounter(b lang.Block, v *lang.Variable) bool {
+	for n := lang.Node(b); n != nil; n = n.Parent() {
+		f, ok := n.(*lang.For)
+		if !ok {
+			continue
+		}
+		for _, lv := range f.Vars {
+			if lv == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isParam reports if the variable is a parameter
+// of the function or the closure of the block. Captured
+// values are parameters of the wrapping function.
+func isParam(b lang.Block, v *lang.Variable) bool {
+	if l := literalOf(b); l != nil {
+		for _, a := range l.Args {
+			if a == v {
+				return true
+			}
+		}
+		for _, c := range l.Captures {
+			if c.V == v {
+				return true
+			}
+		}
+	}
+	for _, a := range functionOf(b).Args {
+		if a == v {
+			return true
+		}
+	}
+	return false
+}
+
+// untyped references the variable without the type assertion,
+// its value can differ from the type known at this point.
+func untyped(e lang.Expression) lang.Expression {
+	v, ok := e.(*lang.VarRef)
+	if !ok || v.Type().IsPointer {
+		return e
+	}
+	return lang.NewVarRef(v.V, lang.NewTyp(lang.Anything, false))
+}
+
+// constantFits reports if both operands are int constants
+// and the result of the operation does not overflow.
+func constantFits(op string, l, r lang.Expression) bool {
+	x, ok := intConstant(l)
+	if !ok {
+		return false
+	}
+	y, ok := intConstant(r)
+	if !ok {
+		return false
+	}
+	return evalInt(op, x, y).IsInt64()
+}
+
+// intConstant evaluates int literals and unchecked operations
+// of them, which were kept because they do not overflow.
+func intConstant(e lang.Expression) (*big.Int, bool) {
+	switch e := e.(type) {
+	case *lang.Number:
+		return new(big.Int).SetString(e.Value, 0)
+
+	case *lang.BinaryOp:
+		if _, ok := arithmeticFuncs[e.Operation]; !ok || e.Operation == "/" || e.Operation == "%" {
+			return nil, false
+		}
+		x, ok := intConstant(e.Left())
+		if !ok {
+			return nil, false
+		}
+		y, ok := intConstant(e.Right())
+		if !ok {
+			return nil, false
+		}
+		return evalInt(e.Operation, x, y), true
+	}
+	return nil, false
+}
+
+func evalInt(op string, x, y *big.Int) *big.Int {
+	res := new(big.Int)
+	switch op {
+	case "+":
+		res.Add(x, y)
+	case "-":
+		res.Sub(x, y)
+	case "*":
+		res.Mul(x, y)
+	}
+	return res
+}
+
+// compare translates PHP loose comparison. Go operators are used
+// for values of the same type, numbers of different types are
+// converted to float64. The rest is compared by std, the same
+// way as PHP does it.
+func (p *fileParser) compare(b lang.Block, op string, left, right node.Node) lang.Expression {
+	l := p.expression(b, left)
+	r := p.expression(b, right)
+	if !looseComparison(l, r) {
+		return p.bOp(b, op, l, r)
+	}
+
+	std := p.funcs.Namespace("std")
+	args := []lang.Expression{l, r}
+	var res lang.Expression
+	var err error
+	switch op {
+	case "==", "!=":
+		res, err = std.Call("LooseEq", args)
+		if op == "!=" {
+			neg := &lang.Negation{Right: res}
+			res.SetParent(neg)
+			res = neg
+		}
+
+	case "<", "<=":
+		var f lang.Expression
+		f, err = std.Call("Compare", args)
+		if err == nil {
+			res, err = lang.NewBinaryOp(op, f, &lang.Number{Value: "0"})
+		}
+
+	case ">":
+		res, err = std.Call("Greater", args)
+
+	case ">=":
+		res, err = std.Call("GreaterOrEqual", args)
+	}
+	if err != nil {
+		panic(err)
+	}
+	res.SetParent(b)
+	return res
+}
+
+// identical translates === and !==. Go compares only values
+// of interface{} to nil, the other scalars are never null.
+func (p *fileParser) identical(b lang.Block, op string, left, right node.Node) lang.Expression {
+	l := p.expression(b, left)
+	r := p.expression(b, right)
+	_, ln := l.(*lang.Nil)
+	_, rn := r.(*lang.Nil)
+	if (ln || rn) && (neverNull(l.Type()) || neverNull(r.Type())) {
+		c := &lang.Const{Value: strconv.FormatBool(op == "!=")}
+		c.SetParent(b)
+		return c
+	}
+	return p.bOp(b, op, l, r)
+}
+
+// neverNull reports if Go values of the type cannot be nil.
+func neverNull(t lang.Typ) bool {
+	if t.IsPointer {
+		return false
+	}
+	return t.Equal(lang.Int) || t.Equal(lang.Float64) ||
+		t.Equal(lang.String) || t.Equal(lang.Bool)
+}
+
+// looseComparison reports if the values have to be compared
+// by std. Arrays cannot be compared by Go and numeric strings
+// are compared as numbers, strings use Go operators only when
+// one of them is the literal, which is not numeric.
+func looseComparison(left, right lang.Expression) bool {
+	l, r := left.Type(), right.Type()
+	if l.Equal(lang.Anything) || r.Equal(lang.Anything) || IsArray(l.String()) || IsArray(r.String()) {
+		return true
+	}
+	if l.Eq(r) {
+		return l.Equal(lang.String) && mayBeNumeric(left) && mayBeNumeric(right)
+	}
+	numeric := func(t lang.Typ) bool {
+		return t.Equal(lang.Int) || t.Equal(lang.Float64)
+	}
+	return !numeric(l) || !numeric(r)
+}
+
+// mayBeNumeric reports if the string can be numeric,
+// only literals are known not to be numbers.
+func mayBeNumeric(e lang.Expression) bool {
+	s, ok := e.(*lang.Str)
+	if !ok {
+		return true
+	}
+	v, err := strconv.Unquote(s.Value)
+	if err != nil {
+		return true
+	}
+	_, err = strconv.ParseFloat(strings.TrimSpace(v), 64)
+	return err == nil
+}
+
+// copyArray copies the array, PHP arrays are values. Only variables
+// and items of arrays are copied, other expressions create new arrays.
+// The data is shared until one of the arrays is edited.
+func copyArray(e lang.Expression) lang.Expression {
+	t := e.Type()
+	if !IsArray(t.String()) || t.Equal("array.Scalar") {
+		return e
+	}
+	switch e := e.(type) {
+	case *lang.VarRef:
+	case *lang.FunctionCall:
+		if !strings.HasSuffix(e.Name, ".At") {
+			return e
+		}
+	default:
+		return e
+	}
+
+	t.IsPointer = false
+	c := &lang.FunctionCall{
+		Name:   e.String() + ".Copy",
+		Return: t,
+	}
+	c.SetParent(e.Parent())
+	e.SetParent(c)
+	return c
+}
+
+// key converts the expression to the key of an array,
+// values of other types than the scalar ones cannot be keys.
+// Values without static types are checked by std.Key.
+func (p *fileParser) key(n node.Node, e lang.Expression) lang.Expression {
+	switch e.Type().String() {
+	case lang.Int, lang.String, lang.Float64, lang.Bool, "array.Scalar":
+	case lang.Anything:
+		scalar, err := p.funcs.Namespace("std").Call("Key", []lang.Expression{e})
+		if err != nil {
+			panic(err)
+		}
+		return scalar
+	default:
+		p.fail(n, TypeMismatch, "Illegal offset type %s.", e.Type())
+	}
+	scalar, err := p.funcs.Namespace("array").Call("NewScalar", []lang.Expression{e})
+	if err != nil {
+		panic(err)
+	}
+	return scalar
+}
+
+// newArray creates the typed array of the items. Items of different
+// types, or of types without typed arrays, are kept in array.Any.
+// Arrays of arrays are generated in the file, which uses them.
+// Items with keys are set one by one, they follow the rest,
+// their keys are already converted to array.Scalar.
+func (p *fileParser) newArray(b lang.Block, keys, items []lang.Expression) lang.Expression {
+	for i := range items {
+		items[i] = copyArray(items[i])
+	}
+	typ := items[0].Type()
+	for _, i := range items {
+		if !i.Type().Eq(typ) {
+			typ = lang.NewTyp(lang.Anything, false)
+			break
+		}
+	}
+	keyed := items[len(items):]
+	for i, k := range keys {
+		if k != nil {
+			keyed = items[i:]
+			keys = keys[i:]
+			items = items[:i]
+			break
+		}
+	}
+
+	// Arrays of runtime classes cannot be generated.
+	var f *lang.FunctionCall
+	switch c := p.classOf(typ); {
+	case c != nil && c.Class != nil && c.Package != "std" && c.Type().Eq(typ):
+		c.Array = true
+		if file, ok := c.Parent().(*lang.File); ok {
+			file.AddImport(p.funcs.funcs["array"].namespace)
+		}
+		name := ArrayType(typ.String())
+		i := strings.LastIndex(name, ".") + 1
+		f = &lang.FunctionCall{
+			Name:   name[:i] + "New" + name[i:],
+			Args:   items,
+			Return: lang.NewTyp(name, false),
+		}
+		for _, i := range items {
+			i.SetParent(f)
+		}
+
+	case IsArray(typ.String()):
+		p.funcs.Namespace("array")
+		t := p.file.NestedArray(typ)
+		name := t.String()
+		i := strings.LastIndex(name, ".") + 1
+		f = &lang.FunctionCall{
+			Name:   name[:i] + "New" + name[i:],
+			Args:   items,
+			Return: t,
+		}
+		for _, i := range items {
+			i.SetParent(f)
+		}
+
+	default:
+		switch typ.String() {
+		case lang.Int, lang.String, lang.Float64, lang.Bool:
+		default:
+			typ = lang.NewTyp(lang.Anything, false)
+		}
+		name := strings.TrimPrefix(ArrayType(typ.String()), "array.")
+		var err error
+		f, err = p.funcs.Namespace("array").Call("New"+name, items)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	for i, v := range keyed {
+		w := &lang.FunctionCall{
+			Name:   fmt.Sprintf("%s.With", f),
+			Args:   []lang.Expression{keys[i], v},
+			Return: f.Return,
+		}
+		keys[i].SetParent(w)
+		v.SetParent(w)
+		f = w
+	}
+	f.SetParent(b)
+	return f
+}
+
+// cast converts the expression using the std function,
+// which does the same thing as PHP cast.
+func (p *fileParser) cast(b lang.Block, fn string, n node.Node) lang.Expression {
+	f, err := p.funcs.Namespace("std").Call(fn, []lang.Expression{
+		p.expression(b, n),
+	})
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
 // Returns a sign if namespace "std" has to be imported.
 func convertToMatchingType(left, right lang.Expression) (lang.Expression, lang.Expression, bool) {
 	lt := left.Type()
@@ -1549,7 +2536,7 @@ func convertToMatchingType(left, right lang.Expression) (lang.Expression, lang.E
 
 		case lang.Int:
 			f := &lang.FunctionCall{
-				Name:   "float",
+				Name:   "float64",
 				Args:   []lang.Expression{right},
 				Return: lang.NewTyp(lang.Float64, false),
 			}
@@ -1636,27 +2623,27 @@ func (p *fileParser) flowControlExpr(b lang.Block, n node.Node) (init lang.Node,
 		expr = s.(lang.Expression)
 
 	default:
-		panic(`flowControlExpr: missing expression`)
+		p.fail(n, UnsupportedExpression, "%T cannot be used as a condition.", n)
 	}
-	if !expr.Type().Equal(lang.Bool) {
-		var err error
-		expr, err = p.funcs.Namespace("std").Call("Truthy", []lang.Expression{expr})
-		if err != nil {
-			panic(err)
-		}
-		expr.SetParent(b)
-	}
-
+	expr = p.truthy(b, expr)
 	return
 }
 
-func (parser *parser) buildAssignment(parent lang.Block, name string, right lang.Expression) *lang.Assign {
+func (parser *fileParser) buildAssignment(parent lang.Block, name string, right lang.Expression) *lang.Assign {
 	t := right.Type()
 	if t.Equal(lang.Void) {
 		panic("Cannot assign \"void\" " + "to \"" + name + "\".")
 	}
 
 	v := parent.HasVariable(name, false)
+	// Parameters and loop counters keep their types,
+	// values without static types are converted to them.
+	if v != nil && keepsType(parent, v) {
+		if c, ok := parser.funcs.coerce(right, v.CurrentType); ok {
+			right = c
+			t = right.Type()
+		}
+	}
 	fd := false
 	if v == nil {
 		v = lang.NewVariable(name, t, false)
@@ -1681,7 +2668,8 @@ func (parser *parser) buildAssignment(parent lang.Block, name string, right lang
 			parser.gc.DefineVariable(v)
 		} else {
 			done := false
-			if m, ok := parent.Parent().(*lang.Function); ok {
+			// Locals of main declared in nested blocks are not globals.
+			if m, ok := parent.Parent().(*lang.Function); ok && parser.gc.HasVariable(v.Name, false) == v {
 				if f, ok := m.Parent().(*lang.File); ok {
 					if f.Main == m {
 						v.CurrentType = t
@@ -1691,13 +2679,21 @@ func (parser *parser) buildAssignment(parent lang.Block, name string, right lang
 				}
 			}
 			if !done {
-				if v.FirstDefinition.Parent() == parent {
+				// Values without static types, like results of checked
+				// arithmetic, widen the variable where it is declared.
+				decl, ok := v.FirstDefinition.Parent().(*lang.Code)
+				switch {
+				case v.FirstDefinition.Parent() == parent:
 					v.CurrentType = t
-				} else {
+					parent.DefineVariable(v)
+				case ok && t.Equal(lang.Anything):
+					v.CurrentType = t
+					decl.DefineVariable(v)
+				default:
 					v = lang.NewVariable(name, t, false)
 					fd = true
+					parent.DefineVariable(v)
 				}
-				parent.DefineVariable(v)
 			}
 		}
 	}
@@ -1772,7 +2768,7 @@ func (p *fileParser) freeFloatingComment(b lang.Block, n node.Node) {
 			r := regexp.MustCompile(`\$(\w+)`)
 			name := r.FindString(s)
 			if len(name) <= 1 {
-				panic(`wrong name`)
+				p.fail(n, InvalidAnnotation, "Missing variable name in @var.")
 			}
 			name = name[1:]
 
@@ -1791,7 +2787,7 @@ func (p *fileParser) freeFloatingComment(b lang.Block, n node.Node) {
 				vt.Addressable = true
 				vt.Tiles = types
 			} else if err != nil {
-				panic(err)
+				p.fail(n, InvalidAnnotation, "Unknown type '%s' in @var.", typ)
 			}
 
 			// Do not move structs, redeclaration is complicated.
@@ -1817,7 +2813,7 @@ func (p *fileParser) freeFloatingComment(b lang.Block, n node.Node) {
 					b.AddStatement(v.FirstDefinition)
 				}
 			} else if typ == "array" {
-				panic(`Redeclaration of the struct.`)
+				p.fail(n, InvalidAnnotation, "Redeclaration of the struct.")
 			}
 		}
 	}
@@ -1905,15 +2901,35 @@ func stringToTyp(s string) (lang.Typ, error) {
 }
 
 func (p *fileParser) requireGlobal(b lang.Block) {
+	if p.inPackage() {
+		p.fail(nil, UnsupportedStatement, "Global context cannot be used in the namespace %s.", p.namespace)
+	}
+	f := functionOf(b)
+	if f.Class != nil {
+		// Methods reach global context through the struct at the
+		// top of the hierarchy, every constructor has to fill it in.
+		root := f.Class.Root()
+		for _, c := range p.classes {
+			if c.Class != nil && c.Root() == root {
+				c.Constructor.NeedsGlobal = true
+			}
+		}
+	}
+	if f.Receiver == nil {
+		p.funcs.Namespace("").NeedsGlobal(f.Name)
+	}
+	f.NeedsGlobal = true
+}
+
+// functionOf finds the function containing the block.
+func functionOf(b lang.Block) *lang.Function {
 	var bl lang.Node = b
 	for {
 		if bl == nil {
 			panic(`Cannot find parent function.`)
 		}
 		if f, ok := bl.(*lang.Function); ok {
-			p.funcs.Namespace("").NeedsGlobal(f.Name)
-			f.NeedsGlobal = true
-			break
+			return f
 		}
 		bl = bl.Parent()
 	}
@@ -1,6 +1,10 @@
 package p
 
 import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -21,6 +25,27 @@ func TestP(t *testing.T) {
 	t.Run("unary operations", unaryOp)
 	t.Run("statements", testStatements)
 	t.Run("text comparison of the main function", testMain)
+	t.Run("diagnostics", testDiagnostics)
+	t.Run("syntax errors", testSyntaxErrors)
+	t.Run("compatibility check", testCheck)
+	t.Run("classes", testClasses)
+	t.Run("inheritance", testInheritance)
+	t.Run("closures", testClosures)
+	t.Run("ternary operators", testTernary)
+	t.Run("casts", testCasts)
+	t.Run("loose comparison", testLooseComparison)
+	t.Run("arrays", testArrays)
+	t.Run("nested arrays", testNestedArrays)
+	t.Run("array copies", testArrayCopies)
+	t.Run("sorting", testSorting)
+	t.Run("array functions", testArrayFunctions)
+	t.Run("string functions", testStringFunctions)
+	t.Run("math functions", testMathFunctions)
+	t.Run("arithmetic", testArithmetic)
+	t.Run("time functions", testTimeFunctions)
+	t.Run("exceptions", testExceptions)
+	t.Run("namespaces", testNamespaces)
+	t.Run("project", testProject)
 }
 
 func helpers(t *testing.T) {
@@ -422,7 +447,7 @@ func testMain(tt *testing.T) {
 			functionTranslator: NewFunctionTranslator(),
 		}
 
-		out := parser.Run(parsePHP(t.source), "dummy", false)
+		out, _ := parser.Run(parsePHP(t.source), "dummy", false)
 		for _, f := range out.Files {
 			if f.Name == "fc" {
 				main := f.String()
@@ -432,6 +457,1806 @@ func testMain(tt *testing.T) {
 	}
 }
 
+func testDiagnostics(t *testing.T) {
+	t.Helper()
+
+	source := []byte(`<?php
+function fc() {
+	$a = 1;
+	class A {}
+	echo undefined(str_repeat("a", $a));
+	echo $a;
+}
+`)
+	parser := parser{
+		translator:         NewNameTranslator(),
+		functionTranslator: NewFunctionTranslator(),
+		sources:            map[string][]byte{"dummy": source},
+	}
+
+	out, diags := parser.Run(parsePHP(source), "dummy", false)
+	expected := []Diagnostic{
+		{
+			File: "dummy", Line: 4, Column: 2,
+			Severity: Error, Code: UnsupportedStatement, Node: "*stmt.Class",
+			Message: "*stmt.Class is not supported.",
+		},
+		{
+			File: "dummy", Line: 5, Column: 7,
+			Severity: Error, Code: UnknownFunction, Node: "*expr.FunctionCall",
+			Symbol:  "undefined",
+			Message: "Function 'undefined' is not defined.",
+		},
+	}
+	if len(diags) != len(expected) {
+		t.Fatalf("%d diagnostics expected, %d found: %v", len(expected), len(diags), diags)
+	}
+	for i, d := range diags {
+		if d != expected[i] {
+			t.Errorf("'%s' expected, '%s' found.", expected[i], d)
+		}
+	}
+	if !HasErrors(diags) {
+		t.Error("Diagnostics should contain an error.")
+	}
+
+	compare(t, `func fc() {
+		a := 1
+		// php2go: skipped *stmt.Class at line 4: *stmt.Class is not supported.
+		// php2go: skipped *expr.FunctionCall at line 5: Function 'undefined' is not defined.
+		fmt.Print(a)
+	}`, out.Files[0].Funcs["fc"].String())
+	// Skipped code was the only user of std.
+	for _, i := range out.Files[0].Imports() {
+		if i == "github.com/lSimul/php2go/std" {
+			t.Error("Imports of the skipped statement should be dropped.")
+		}
+	}
+}
+
+func testSyntaxErrors(t *testing.T) {
+	t.Helper()
+
+	parser := parser{}
+	_, err := parser.phpParse("dummy", []byte("<?php\n$a = ;\n"))
+	errs, ok := err.(SyntaxErrors)
+	if !ok {
+		t.Fatalf("SyntaxErrors expected, '%v' found.", err)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("One syntax error expected, %d found.", len(errs))
+	}
+	if e := errs[0]; e.File != "dummy" || e.Line != 2 || e.Column != 6 {
+		t.Errorf("'dummy:2:6' expected, '%s:%d:%d' found.", e.File, e.Line, e.Column)
+	}
+
+	r, err := parser.phpParse("dummy", []byte("<?php\n$a = 1;\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(r.Stmts) != 1 {
+		t.Errorf("One statement expected, %d found.", len(r.Stmts))
+	}
+}
+
+func testCheck(t *testing.T) {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "php2go-check")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"ok.php":     "<?php\n$a = 1;\necho $a;\n",
+		"broken.php": "<?php\n$a = 1;\n$b = clone $a;\necho strrev(unknown($a));\n",
+		"syntax.php": "<?php\n$a = ;\n",
+		"readme.md":  "Not a PHP file.",
+	}
+	for n, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, n), []byte(src), 0644); err != nil {
+			t.Fatalf("writing %s: %v", n, err)
+		}
+	}
+
+	r, err := Check(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(r.Files) != 3 {
+		t.Fatalf("3 files expected, %d found.", len(r.Files))
+	}
+
+	expected := []struct {
+		file         string
+		transpilable bool
+		codes        []string
+	}{
+		{"broken.php", false, []string{UnsupportedExpression, UnknownFunction, UnknownFunction}},
+		{"ok.php", true, []string{}},
+		{"syntax.php", false, []string{SyntaxFailed}},
+	}
+	for i, e := range expected {
+		f := r.Files[i]
+		if f.File != filepath.Join(dir, e.file) {
+			t.Errorf("'%s' expected, '%s' found.", e.file, f.File)
+		}
+		if f.Transpilable != e.transpilable {
+			t.Errorf("%s: transpilable should be %t.", e.file, e.transpilable)
+		}
+		if len(f.Diagnostics) != len(e.codes) {
+			t.Errorf("%s: %d diagnostics expected, %d found: %v", e.file, len(e.codes), len(f.Diagnostics), f.Diagnostics)
+			continue
+		}
+		for j, c := range e.codes {
+			if f.Diagnostics[j].Code != c {
+				t.Errorf("%s: '%s' expected, '%s' found.", e.file, c, f.Diagnostics[j].Code)
+			}
+		}
+	}
+
+	if r.Totals.Files != 3 || r.Totals.Transpilable != 1 || r.Totals.Errors != 4 {
+		t.Errorf("Wrong totals: %+v", r.Totals)
+	}
+	if r.Totals.UnsupportedNodes["*expr.Clone"] != 1 {
+		t.Errorf("Clone should be reported as unsupported: %v", r.Totals.UnsupportedNodes)
+	}
+	if r.Totals.UnknownFunctions["unknown"] != 1 || r.Totals.UnknownFunctions["strrev"] != 1 {
+		t.Errorf("Unknown functions not reported: %v", r.Totals.UnknownFunctions)
+	}
+
+	var b bytes.Buffer
+	if err := r.WriteJSON(&b); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(b.String(), `"severity": "error"`) {
+		t.Error("Severity should be written as a string.")
+	}
+}
+
+func testClasses(t *testing.T) {
+	t.Helper()
+
+	source := []byte(`<?php
+class Counter {
+	public $count = 0;
+	private string $name;
+
+	public function __construct(string $name) {
+		$this->name = $name;
+	}
+
+	public function add(int $n): int {
+		$this->count = $this->count + $n;
+		return $this->count;
+	}
+
+	public function __toString(): string {
+		return $this->name;
+	}
+}
+
+function fc(): int {
+	$c = new Counter("c");
+	return $c->add(2);
+}
+`)
+	parser := parser{
+		translator:         NewNameTranslator(),
+		functionTranslator: NewFunctionTranslator(),
+	}
+
+	out, diags := parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+	f := out.Files[0]
+	if len(f.Classes) != 1 {
+		t.Fatalf("One class expected, %d found.", len(f.Classes))
+	}
+
+	compare(t, `type Counter struct {
+		Count int
+		name string
+	}
+
+	func (this *Counter) Add(n int) int {
+		this.Count = this.Count + n
+		return this.Count
+	}
+
+	func (this *Counter) String() string {
+		return this.name
+	}
+	`, f.Classes[0].String())
+
+	compare(t, `func NewCounter(name string) *Counter {
+		this := &Counter{}
+		this.Count = 0
+		this.name = name
+		return this
+	}`, f.Funcs["NewCounter"].String())
+
+	compare(t, `func fc() int {
+		c := NewCounter("c")
+		return c.Add(2)
+	}`, f.Funcs["fc"].String())
+
+	// Compound assignments and increments of properties.
+	source = []byte(`<?php
+class Counter {
+	public int $n = 0;
+	public string $s = "";
+
+	public function step(): void {
+		$this->n += 2;
+		$this->n -= 1;
+		$this->n *= 3;
+		$this->s .= "a";
+		$this->n++;
+		--$this->n;
+	}
+}
+
+function fc(): int {
+	$o = new Counter();
+	$o->n++;
+	$o->n--;
+	return $o->n;
+}
+`)
+	out, diags = parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+	compare(t, `func (this *Counter) Step() {
+		this.N = this.N + 2
+		this.N = this.N - 1
+		this.N = this.N * 3
+		this.S = std.Concat(this.S, "a")
+		this.N++
+		this.N--
+	}`, out.Files[0].Classes[0].Methods[0].String())
+	compare(t, `func fc() int {
+		o := NewCounter()
+		o.N++
+		o.N--
+		return o.N
+	}`, out.Files[0].Funcs["fc"].String())
+
+	// Checked arithmetic assigns the converted result.
+	parser.CheckOverflow(true)
+	out, diags = parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+	compare(t, `func (this *Counter) Step() {
+		this.N = std.ToInt(std.Add(this.N, 2))
+		this.N = std.ToInt(std.Sub(this.N, 1))
+		this.N = std.ToInt(std.Mul(this.N, 3))
+		this.S = std.Concat(this.S, "a")
+		this.N = std.ToInt(std.Add(this.N, 1))
+		this.N = std.ToInt(std.Sub(this.N, 1))
+	}`, out.Files[0].Classes[0].Methods[0].String())
+	compare(t, `func fc() int {
+		o := NewCounter()
+		o.N = std.ToInt(std.Add(o.N, 1))
+		o.N = std.ToInt(std.Sub(o.N, 1))
+		return o.N
+	}`, out.Files[0].Funcs["fc"].String())
+	parser.CheckOverflow(false)
+
+	source = []byte(`<?php
+class A extends B {}
+$a = new A();
+`)
+	_, diags = parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 2 || diags[0].Code != UnknownClass || diags[1].Code != UnknownClass {
+		t.Errorf("Unknown classes should be reported, %v found.", diags)
+	}
+}
+
+func testInheritance(t *testing.T) {
+	t.Helper()
+
+	source := []byte(`<?php
+class Dog extends Animal {
+	public function __construct(string $name) {
+		parent::__construct($name);
+	}
+
+	public function sound(): string {
+		return "woof";
+	}
+}
+
+interface Named {
+	public function name(): string;
+}
+
+abstract class Animal implements Named {
+	private string $name;
+
+	public function __construct(string $name) {
+		$this->name = $name;
+	}
+
+	abstract public function sound(): string;
+
+	public function name(): string {
+		return $this->name;
+	}
+
+	public function speak(): string {
+		return $this->sound();
+	}
+}
+
+function fc(Animal $a): bool {
+	return $a instanceof Named;
+}
+`)
+	parser := parser{
+		translator:         NewNameTranslator(),
+		functionTranslator: NewFunctionTranslator(),
+	}
+
+	out, diags := parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+	f := out.Files[0]
+	if len(f.Classes) != 2 || len(f.Interfaces) != 1 {
+		t.Fatalf("Two classes and one interface expected, %d and %d found.", len(f.Classes), len(f.Interfaces))
+	}
+
+	compare(t, `type Named interface {
+		Name() string
+	}
+	`, f.Interfaces[0].String())
+
+	compare(t, `type Animal struct {
+		selfAnimal AnimalInterface
+		name string
+	}
+
+	func (this *Animal) asAnimal() *Animal {
+		return this
+	}
+
+	func (this *Animal) construct(name string) {
+		this.name = name
+	}
+
+	func (this *Animal) Name() string {
+		return this.name
+	}
+
+	func (this *Animal) Speak() string {
+		return this.selfAnimal.Sound()
+	}
+
+	type AnimalInterface interface {
+		asAnimal() *Animal
+		Sound() string
+		Name() string
+		Speak() string
+	}
+	`, f.Classes[0].String())
+
+	compare(t, `type Dog struct {
+		Animal
+	}
+
+	func (this *Dog) Sound() string {
+		return "woof"
+	}
+	`, f.Classes[1].String())
+
+	compare(t, `func NewDog(name string) *Dog {
+		this := &Dog{}
+		this.selfAnimal = this
+		this.Animal.construct(name)
+		return this
+	}`, f.Funcs["NewDog"].String())
+
+	compare(t, `func fc(a AnimalInterface) bool {
+		return func() bool {
+			_, ok := interface{}(a).(Named)
+			return ok
+		}()
+	}`, f.Funcs["fc"].String())
+}
+
+func testClosures(t *testing.T) {
+	t.Helper()
+
+	source := []byte(`<?php
+function fc(int $a, int $b): int {
+	$add = function (int $x) use ($a, &$b) {
+		$b = $b + 1;
+		return $x + $a;
+	};
+	$mul = fn(int $x): int => $x * $b;
+	return $mul($add(1));
+}
+`)
+	parser := parser{
+		translator:         NewNameTranslator(),
+		functionTranslator: NewFunctionTranslator(),
+	}
+
+	out, diags := parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+
+	compare(t, `func fc(a int, b int) int {
+		add := func(a int) func(int) int {
+			return func(x int) int {
+				a := a
+				b = b + 1
+				return x + a
+			}
+		}(a)
+		mul := func(b int) func(int) int {
+			return func(x int) int {
+				b := b
+				return x * b
+			}
+		}(b)
+		return mul(add(1))
+	}`, out.Files[0].Funcs["fc"].String())
+
+	// Arrow functions capture values when they are created.
+	source = []byte(`<?php
+function fc(): int {
+	$k = 10;
+	$add = fn(int $x): int => $x + $k;
+	$k = 100;
+	return $add(1);
+}
+`)
+	out, diags = parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+
+	compare(t, `func fc() int {
+		k := 10
+		add := func(k int) func(int) int {
+			return func(x int) int {
+				k := k
+				return x + k
+			}
+		}(k)
+		k = 100
+		return add(1)
+	}`, out.Files[0].Funcs["fc"].String())
+
+	// Captured values are copied on every call, unused ones are not.
+	source = []byte(`<?php
+function fc(int $a, int $b): int {
+	$inc = function () use ($a, $b): int {
+		$a = $a + 1;
+		return $a;
+	};
+	return $inc() + $inc();
+}
+`)
+	out, diags = parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+
+	compare(t, `func fc(a int, b int) int {
+		inc := func(a int, b int) func() int {
+			return func() int {
+				a := a
+				a = a + 1
+				return a
+			}
+		}(a, b)
+		return inc() + inc()
+	}`, out.Files[0].Funcs["fc"].String())
+
+	source = []byte(`<?php
+function fc(): int {
+	$arr = [1, 2];
+	$push = function () use ($arr): int {
+		$arr[] = 3;
+		return count($arr);
+	};
+	return $push();
+}
+`)
+	out, diags = parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+
+	compare(t, `func fc() int {
+		arr := array.NewInt(1, 2)
+		push := func(arr array.Int) func() int {
+			return func() int {
+				arr := arr.Copy()
+				arr.Add(3)
+				return arr.Count()
+			}
+		}(arr.Copy())
+		return push()
+	}`, out.Files[0].Funcs["fc"].String())
+
+	source = []byte(`<?php
+function fc(int $a) {
+	$f = function (int $x) {
+		return $x + $a;
+	};
+	$f("a");
+}
+`)
+	_, diags = parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 2 || diags[0].Code != UndefinedVariable || diags[1].Code != TypeMismatch {
+		t.Errorf("Closure should not see uncaptured variables, %v found.", diags)
+	}
+}
+
+func testTernary(t *testing.T) {
+	t.Helper()
+
+	source := []byte(`<?php
+function fc(int $a): int {
+	$b = $a > 2 ? $a : false;
+	return $b ?: 2;
+}
+`)
+	parser := parser{
+		translator:         NewNameTranslator(),
+		functionTranslator: NewFunctionTranslator(),
+	}
+
+	out, diags := parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+
+	compare(t, `func fc(a int) int {
+		b := func() int {
+			if a > 2 {
+				return a
+			}
+			return std.BoolToInt(false)
+		}()
+		return func() int {
+			if v := b; std.Truthy(v) {
+				return v
+			}
+			return 2
+		}()
+	}`, out.Files[0].Funcs["fc"].String())
+
+	source = []byte(`<?php
+function fc(): int {
+	$arr = [1, 2];
+	$arr[3] ??= 4;
+	return $arr[5] ?? $undefined ?? 0;
+}
+`)
+	out, diags = parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+
+	compare(t, `func fc() int {
+		arr := array.NewInt(1, 2)
+		if !(arr.Isset(array.NewScalar(3))) {
+			arr.Edit(array.NewScalar(3), 4)
+		}
+		return func() int {
+			if arr.Isset(array.NewScalar(5)) {
+				return arr.At(array.NewScalar(5))
+			}
+			return 0
+		}()
+	}`, out.Files[0].Funcs["fc"].String())
+
+	// Values without static types can be null.
+	source = []byte(`<?php
+function fc(): int {
+	$arr = [1, 2];
+	$k = array_search(2, $arr);
+	$k ??= 0;
+	return array_search(1, $arr) ?? $k;
+}
+`)
+	out, diags = parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+
+	compare(t, `func fc() int {
+		arr := array.NewInt(1, 2)
+		k := std.ArraySearch(2, arr, false)
+		if k == nil {
+			k = 0
+		}
+		return std.ToInt(func() interface{} {
+			if v := std.ArraySearch(1, arr, false); v != nil {
+				return v
+			}
+			return k
+		}())
+	}`, out.Files[0].Funcs["fc"].String())
+
+	// null is nil without a static type.
+	source = []byte(`<?php
+function fc(int $i): void {
+	$a = ["p" => 3];
+	$x = $a["q"] ?? null;
+	if ($x === null || $i === null) {
+		echo "none";
+	}
+	$z = null;
+	$z ??= $i;
+}
+`)
+	out, diags = parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+
+	compare(t, `func fc(i int) {
+		a := array.NewInt().With(array.NewScalar("p"), 3)
+		x := func() interface{} {
+			if a.Isset(array.NewScalar("q")) {
+				return a.At(array.NewScalar("q"))
+			}
+			return nil
+		}()
+		if x == nil || false {
+			fmt.Print("none")
+		}
+		var z interface{}
+		if z == nil {
+			z = i
+		}
+	}`, out.Files[0].Funcs["fc"].String())
+
+	source = []byte(`<?php
+class A {
+	public string $name;
+	public function fill(): void {
+		$this->name ??= "default";
+	}
+}
+$n = 1;
+$z = $n++ ?: $n;
+`)
+	_, diags = parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 2 || diags[0].Severity != Warning || diags[1].Code != UnsupportedExpression {
+		t.Errorf("Properties and increments should be reported, %v found.", diags)
+	}
+}
+
+func testCasts(t *testing.T) {
+	t.Helper()
+
+	source := []byte(`<?php
+function fc(string $s): string {
+	$i = (int) $s;
+	$f = (float) $s;
+	$b = (bool) $s;
+	return (string) $f;
+}
+`)
+	parser := parser{
+		translator:         NewNameTranslator(),
+		functionTranslator: NewFunctionTranslator(),
+	}
+
+	out, diags := parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+
+	compare(t, `func fc(s string) string {
+		i := std.ToInt(s)
+		f := std.ToFloat64(s)
+		b := std.ToBool(s)
+		return std.ToString(f)
+	}`, out.Files[0].Funcs["fc"].String())
+}
+
+func testLooseComparison(t *testing.T) {
+	t.Helper()
+
+	source := []byte(`<?php
+function fc(string $s, string $t, int $i, float $f): int {
+	$a = $s == $i;
+	$b = $s != $i;
+	$c = $s < $f;
+	$d = $i >= $s;
+	$e = $i > $f;
+	$arr = [1] == [1];
+	$h = $s == "a";
+	$h = $s == $t;
+	$h = $s < "10";
+	$h = "1e1" != "10";
+	$h = "abc" > $t;
+	return $s <=> $i;
+}
+`)
+	parser := parser{
+		translator:         NewNameTranslator(),
+		functionTranslator: NewFunctionTranslator(),
+	}
+
+	out, diags := parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+
+	compare(t, `func fc(s string, t string, i int, f float64) int {
+		a := std.LooseEq(s, i)
+		b := !(std.LooseEq(s, i))
+		c := std.Compare(s, f) < 0
+		d := std.GreaterOrEqual(i, s)
+		e := float64(i) > f
+		arr := std.LooseEq(array.NewInt(1), array.NewInt(1))
+		h := s == "a"
+		h = std.LooseEq(s, t)
+		h = std.Compare(s, "10") < 0
+		h = !(std.LooseEq("1e1", "10"))
+		h = "abc" > t
+		return std.Compare(s, i)
+	}`, out.Files[0].Funcs["fc"].String())
+}
+
+func testArrays(t *testing.T) {
+	t.Helper()
+
+	types := []struct {
+		item, array string
+	}{
+		{lang.Int, "array.Int"},
+		{lang.Float64, "array.Float64"},
+		{lang.Bool, "array.Bool"},
+		{lang.Anything, "array.Any"},
+		{"*Point", "PointArray"},
+		{"*models.User", "models.UserArray"},
+	}
+	for _, c := range types {
+		if a := ArrayType(c.item); a != c.array {
+			t.Errorf("'%s' expected, '%s' found.", c.array, a)
+		}
+		if i := ArrayItem(c.array); i != c.item {
+			t.Errorf("'%s' expected, '%s' found.", c.item, i)
+		}
+		if !IsArray(c.array) {
+			t.Errorf("'%s' should be an array.", c.array)
+		}
+	}
+	if IsArray("*PointArray") {
+		t.Error("Objects are not arrays.")
+	}
+
+	source := []byte(`<?php
+class Point {
+	public int $x = 0;
+}
+
+function fc(): int {
+	$f = [1.5, 2.5];
+	$b = [true];
+	$m = [1, "two"];
+	$m[] = 3.5;
+	$p = [new Point()];
+	$p[] = new Point();
+	return $p[0]->x;
+}
+`)
+	parser := parser{
+		translator:         NewNameTranslator(),
+		functionTranslator: NewFunctionTranslator(),
+	}
+
+	out, diags := parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+
+	compare(t, `func fc() int {
+		f := array.NewFloat64(1.5, 2.5)
+		b := array.NewBool(true)
+		m := array.NewAny(1, "two")
+		m.Add(3.5)
+		p := NewPointArray(NewPoint())
+		p.Add(NewPoint())
+		return p.At(array.NewScalar(0)).X
+	}`, out.Files[0].Funcs["fc"].String())
+
+	if c := out.Files[0].Classes[0]; !c.Array {
+		t.Error("Array of Point should be generated.")
+	} else if !strings.Contains(c.String(), "type PointArray struct {") {
+		t.Errorf("PointArray is not declared:\n%s", c)
+	}
+
+	source = []byte(`<?php
+$a = [1];
+echo $a[$a];
+`)
+	_, diags = parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 1 || diags[0].Code != TypeMismatch {
+		t.Errorf("Arrays cannot be keys, %v found.", diags)
+	}
+}
+
+func testNestedArrays(t *testing.T) {
+	t.Helper()
+
+	source := []byte(`<?php
+function fc(): int {
+	$m = [[1, 2], [3, 4]];
+	$m[1][0] = 5;
+	$m[2][] = 6;
+	$c = ["db" => ["host" => "localhost"]];
+	$c["cache"]["driver"] = "redis";
+	if (isset($c["db"]["host"])) {
+		foreach ($m as $row) {
+			foreach ($row as $v) {
+				echo $v;
+			}
+		}
+	}
+	return $m[1][0];
+}
+`)
+	parser := parser{
+		translator:         NewNameTranslator(),
+		functionTranslator: NewFunctionTranslator(),
+	}
+
+	out, diags := parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+
+	f := out.Files[0]
+	compare(t, `func fc() int {
+		m := NewIntArray(array.NewInt(1, 2), array.NewInt(3, 4))
+		m.Sub(array.NewScalar(1)).Edit(array.NewScalar(0), 5)
+		m.Sub(array.NewScalar(2)).Add(6)
+		c := NewStringArray().With(array.NewScalar("db"), array.NewString().With(array.NewScalar("host"), "localhost"))
+		c.Sub(array.NewScalar("cache")).Edit(array.NewScalar("driver"), "redis")
+		if c.Isset(array.NewScalar("db")) && c.At(array.NewScalar("db")).Isset(array.NewScalar("host")) {
+			for _, row := range m.Range() {
+				for _, v := range row.Range() {
+					fmt.Print(v)
+				}
+				row.Release()
+			}
+			m.Release()
+		}
+		return m.At(array.NewScalar(1)).At(array.NewScalar(0))
+	}`, f.Funcs["fc"].String())
+
+	if len(f.Arrays) != 2 {
+		t.Fatalf("Two nested arrays expected, %d found.", len(f.Arrays))
+	}
+	if !strings.Contains(f.String(), "func (a *IntArray) Sub(k array.Scalar) *array.Int {") {
+		t.Error("IntArray should create nested arrays.")
+	}
+}
+
+func testArrayCopies(t *testing.T) {
+	t.Helper()
+
+	source := []byte(`<?php
+function fc(): int {
+	$a = [1, 2];
+	$b = $a;
+	$b[] = 3;
+	$m = [$a, [4]];
+	$row = $m[0];
+	$m[0][] = 5;
+	return count($row);
+}
+`)
+	parser := parser{
+		translator:         NewNameTranslator(),
+		functionTranslator: NewFunctionTranslator(),
+	}
+
+	out, diags := parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+
+	compare(t, `func fc() int {
+		a := array.NewInt(1, 2)
+		b := a.Copy()
+		b.Add(3)
+		m := NewIntArray(a.Copy(), array.NewInt(4))
+		row := m.At(array.NewScalar(0)).Copy()
+		m.Sub(array.NewScalar(0)).Add(5)
+		return row.Count()
+	}`, out.Files[0].Funcs["fc"].String())
+
+	// Declarations do not say types of the items, typed
+	// arrays are converted. References are pointers.
+	source = []byte(`<?php
+class Bag {
+	public array $items = [];
+}
+function add(array $a, int $v): array {
+	$a[] = $v;
+	return $a;
+}
+function push(array &$a, array $b = []): void {
+	$a = $b;
+	$a[] = 1;
+	push($a);
+}
+function fc(Bag $bag): void {
+	$x = add([1], 2);
+	$y = add($x, 3);
+	push($y);
+	$bag->items = $y;
+}
+`)
+	out, diags = parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+
+	compare(t, `func add(a array.Any, v int) array.Any {
+		a.Add(v)
+		return a.Copy()
+	}`, out.Files[0].Funcs["add"].String())
+	compare(t, `func push(a *array.Any, b array.Any) {
+		*a = b.Copy()
+		a.Add(1)
+		push0(a)
+	}`, out.Files[0].Funcs["push"].String())
+	compare(t, `func fc(bag *Bag) {
+		x := add(array.NewInt(1).Any(), 2)
+		y := add(x.Copy(), 3)
+		push0(&y)
+		bag.Items = y.Copy()
+	}`, out.Files[0].Funcs["fc"].String())
+	compare(t, `func NewBag() *Bag {
+		this := &Bag{}
+		this.Items = array.NewAny()
+		return this
+	}`, out.Files[0].Classes[0].Constructor.String())
+}
+
+func testSorting(t *testing.T) {
+	t.Helper()
+
+	source := []byte(`<?php
+function fc(): void {
+	$a = [3, 1, 2];
+	sort($a);
+	rsort($a, SORT_STRING | SORT_FLAG_CASE);
+	$m = ["b" => 1, "a" => 2];
+	asort($m);
+	arsort($m, SORT_NUMERIC);
+	ksort($m);
+	krsort($m, SORT_NATURAL);
+	usort($a, fn(int $x, int $y): int => $x <=> $y);
+	uasort($m, fn(int $x, int $y): int => $y <=> $x);
+	uksort($m, fn(string $x, string $y): int => $x <=> $y);
+}
+`)
+	parser := parser{
+		translator:         NewNameTranslator(),
+		functionTranslator: NewFunctionTranslator(),
+	}
+
+	out, diags := parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+
+	compare(t, `func fc() {
+		a := array.NewInt(3, 1, 2)
+		a.Sort(std.Sorter(std.SortRegular, false), false, false)
+		a.Sort(std.Sorter(std.SortString | std.SortFlagCase, true), false, false)
+		m := array.NewInt().With(array.NewScalar("b"), 1).With(array.NewScalar("a"), 2)
+		m.Sort(std.Sorter(std.SortRegular, false), false, true)
+		m.Sort(std.Sorter(std.SortNumeric, true), false, true)
+		m.Sort(std.Sorter(std.SortRegular, false), true, true)
+		m.Sort(std.Sorter(std.SortNatural, true), true, true)
+		a.USort(func(x int, y int) int {
+			return std.Compare(x, y)
+		}, false)
+		m.USort(func(x int, y int) int {
+			return std.Compare(y, x)
+		}, true)
+		m.UKSort(func(x string, y string) int {
+			return std.Compare(x, y)
+		})
+	}`, out.Files[0].Funcs["fc"].String())
+
+	source = []byte(`<?php
+$a = [3, 1, 2];
+usort($a, fn(string $x, string $y): int => $x <=> $y);
+`)
+	_, diags = parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 1 || diags[0].Code != TypeMismatch {
+		t.Errorf("Comparison of wrong types should be reported, %v found.", diags)
+	}
+}
+
+func testArrayFunctions(t *testing.T) {
+	t.Helper()
+
+	source := []byte(`<?php
+function fc(): void {
+	$a = [1, 2, 3];
+	$s = array_map(fn(int $x): string => "n" . $x, $a);
+	$a = array_filter($a, fn(int $x): bool => $x > 1);
+	$a = array_filter($a);
+	$n = array_reduce($a, fn(int $c, int $x): int => $c + $x, 0);
+	$f = in_array(2, $a);
+	$k = array_search("n1", $s, true);
+	$e = array_key_exists(1, $a);
+	$e = array_key_exists($k, $a);
+	$n = $a[$k];
+	$ks = array_keys($s);
+	$s = array_values($s);
+	$a = array_merge($a, [4], [5]);
+	$a = array_slice($a, 1);
+	$a = array_slice($a, 0, 2, true);
+	$ks = array_slice(array_keys($s), 1);
+	$r = array_splice($a, 1, 1, 9);
+	$r = array_splice($a, 0);
+	$c = array_combine($s, $a);
+	$ks = array_flip($s);
+	$a = array_unique($a);
+	$a = range(1, 10, 2);
+	$fs = range(0, 1, 0.5);
+	$s = range("a", "z");
+	$n = array_sum($a);
+	$n = array_pop($a);
+	$n = array_shift($a);
+	$n = array_unshift($a, 1, 2);
+}
+`)
+	parser := parser{
+		translator:         NewNameTranslator(),
+		functionTranslator: NewFunctionTranslator(),
+	}
+
+	out, diags := parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+
+	compare(t, `func fc() {
+		a := array.NewInt(1, 2, 3)
+		s := a.MapString(func(x int) string {
+			return std.Concat("n", x)
+		})
+		a = a.Filter(func(x int) bool {
+			return x > 1
+		})
+		a = a.Filter(func(v int) bool {
+			return std.ToBool(v)
+		})
+		n := a.ReduceInt(func(c int, x int) int {
+			return c + x
+		}, 0)
+		f := std.InArray(2, a, false)
+		k := std.ArraySearch("n1", s, true)
+		e := a.Isset(array.NewScalar(1))
+		e = a.Isset(std.Key(k))
+		n = a.At(std.Key(k))
+		ks := std.ArrayKeys(s)
+		s = s.Values()
+		a = a.Merge(array.NewInt(4), array.NewInt(5))
+		a = a.Slice(1, std.IntMax, false)
+		a = a.Slice(0, 2, true)
+		ks = std.ArrayKeys(s).Slice(1, std.IntMax, false)
+		r := a.Splice(1, 1, array.NewInt(9))
+		r = a.Splice(0, std.IntMax, array.NewInt())
+		c := a.Combine(s)
+		ks = std.ArrayFlip(s)
+		a = a.Unique(std.ToString)
+		a = std.Range(1, 10, 2)
+		fs := std.RangeFloat64(float64(0), float64(1), 0.5)
+		s = std.RangeString("a", "z", 1)
+		n = std.ToInt(std.ArraySum(a))
+		n = a.Pop()
+		n = a.Shift()
+		n = a.Unshift(1, 2)
+	}`, out.Files[0].Funcs["fc"].String())
+
+	for _, call := range []string{
+		`array_map(fn(string $x): string => $x, $a)`,
+		`array_filter($a, fn(int $x): int => $x)`,
+		`array_reduce($a, fn(int $c, int $x): int => $c + $x, "")`,
+		`array_merge($a, ["a"])`,
+		`array_slice($a, "1")`,
+		`array_key_exists($a, $a)`,
+		`range(1, "z")`,
+		`array_pop([1])`,
+		`array_unshift($a, "a")`,
+	} {
+		source = []byte(`<?php
+$a = [3, 1, 2];
+` + call + `;
+`)
+		_, diags = parser.Run(parsePHP(source), "dummy", false)
+		if len(diags) != 1 || diags[0].Code != TypeMismatch {
+			t.Errorf("Wrong arguments of %s should be reported, %v found.", call, diags)
+		}
+	}
+}
+
+func testStringFunctions(t *testing.T) {
+	t.Helper()
+
+	source := []byte(`<?php
+function fc(): void {
+	$s = "Hello, World";
+	$n = strlen($s);
+	$s = substr($s, 1);
+	$s = substr($s, 1, -1);
+	$p = strpos($s, "o");
+	$p = strrpos($s, "o", -2);
+	$s = str_replace("l", "L", $s);
+	$s = str_pad($n, 5, "0", STR_PAD_LEFT);
+	$s = trim($s);
+	$s = rtrim($s, "0..9");
+	$s = ucwords(strtolower($s));
+	$a = explode(",", $s);
+	$s = implode(", ", $a);
+	$s = implode($a);
+	$s = sprintf("%05.2f %s", 3, $s);
+	$s = number_format($n, 2);
+	$s = wordwrap($s, 10);
+}
+`)
+	parser := parser{
+		translator:         NewNameTranslator(),
+		functionTranslator: NewFunctionTranslator(),
+	}
+
+	out, diags := parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+
+	compare(t, `func fc() {
+		s := "Hello, World"
+		n := std.Strlen(s)
+		s = std.Substr(s, 1, std.IntMax)
+		s = std.Substr(s, 1, -1)
+		p := std.Strpos(s, "o", 0)
+		p = std.Strrpos(s, "o", -2)
+		s = std.StrReplace("l", "L", s)
+		s = std.StrPad(std.ToString(n), 5, "0", std.StrPadLeft)
+		s = std.Trim(s, std.TrimChars)
+		s = std.Rtrim(s, "0..9")
+		s = std.Ucwords(std.Strtolower(s), std.WordDelimiters)
+		a := std.Explode(",", s, std.IntMax)
+		s = std.Implode(", ", a)
+		s = std.Implode("", a)
+		s = std.Sprintf("%05.2f %s", 3, s)
+		s = std.NumberFormat(float64(n), 2, ".", ",")
+		s = std.Wordwrap(s, 10, "\n", false)
+	}`, out.Files[0].Funcs["fc"].String())
+
+	for _, call := range []string{
+		`strlen()`,
+		`substr("abc", "1")`,
+		`str_repeat("a", 1, 2)`,
+		`implode(",", "a")`,
+		`trim([1])`,
+	} {
+		source = []byte(`<?php
+` + call + `;
+`)
+		_, diags = parser.Run(parsePHP(source), "dummy", false)
+		if len(diags) != 1 || diags[0].Code != TypeMismatch {
+			t.Errorf("Wrong arguments of %s should be reported, %v found.", call, diags)
+		}
+	}
+}
+
+func testMathFunctions(t *testing.T) {
+	t.Helper()
+
+	source := []byte(`<?php
+function fc(): void {
+	$i = abs(-5);
+	$f = abs(-2.5);
+	$i = min(3, $i, 2);
+	$f = max($f, 1.5);
+	$m = max($i, $f);
+	$i = min([4, 2, 8]);
+	$f = round($i, 2);
+	$f = floor($f);
+	$f = ceil(4.1);
+	$i = intdiv($i, 2);
+	$f = fmod(10, 3);
+	$p = pow(2, 10);
+	$p = 2 ** $i;
+	$f = $f ** 0.5;
+	$f = sqrt(16) * pi();
+	mt_srand(1);
+	$i = mt_rand();
+	$i = mt_rand(1, 6) + rand(6, 1);
+	$i = random_int(0, PHP_INT_MAX);
+	$f = PHP_FLOAT_EPSILON;
+}
+`)
+	parser := parser{
+		translator:         NewNameTranslator(),
+		functionTranslator: NewFunctionTranslator(),
+	}
+
+	out, diags := parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+
+	compare(t, `func fc() {
+		i := std.AbsInt(-5)
+		f := math.Abs(-2.5)
+		i = std.MinInts(3, i, 2)
+		f = std.MaxFloats(f, 1.5)
+		m := std.Max(i, f)
+		i = std.ToInt(std.MinArray(array.NewInt(4, 2, 8)))
+		f = std.Round(float64(i), 2)
+		f = math.Floor(f)
+		f = math.Ceil(4.1)
+		i = std.Intdiv(i, 2)
+		f = math.Mod(float64(10), float64(3))
+		p := std.PowInt(2, 10)
+		p = std.PowInt(2, i)
+		f = math.Pow(f, 0.5)
+		f = math.Sqrt(float64(16)) * float64(math.Pi)
+		std.MtSrand(1)
+		i = std.MtRand()
+		i = std.MtRandRange(1, 6) + std.RandRange(6, 1)
+		i = std.RandomInt(0, std.IntMax)
+		f = std.FloatEpsilon
+	}`, out.Files[0].Funcs["fc"].String())
+
+	for _, call := range []string{
+		`abs("1")`,
+		`max(1)`,
+		`round("1.5")`,
+		`intdiv(1.5, 2)`,
+		`"2" ** 2`,
+		`mt_rand(1)`,
+	} {
+		source = []byte(`<?php
+` + call + `;
+`)
+		_, diags = parser.Run(parsePHP(source), "dummy", false)
+		if len(diags) != 1 || diags[0].Code != TypeMismatch {
+			t.Errorf("Wrong arguments of %s should be reported, %v found.", call, diags)
+		}
+	}
+}
+
+func testArithmetic(t *testing.T) {
+	t.Helper()
+
+	source := []byte(`<?php
+function half(int $n): float {
+	return $n / 2;
+}
+
+function fc(int $a, int $b): int {
+	$c = $a + $b;
+	$d = $a * 2 - 1;
+	$e = 7 / 2;
+	$e = $a / $b;
+	$s = "3" + $a;
+	$c += 1;
+	$m = $a % $b;
+	$k = 2 + 3 * 4;
+	$h = PHP_INT_MAX + 1;
+	$b = $a - $c;
+	$f = half($a + 1);
+	return $c - $a;
+}
+`)
+	parser := parser{
+		translator:         NewNameTranslator(),
+		functionTranslator: NewFunctionTranslator(),
+		checkOverflow:      true,
+	}
+
+	out, diags := parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+
+	compare(t, `func half(n int) float64 {
+		return std.ToFloat64(std.DivInt(n, 2))
+	}`, out.Files[0].Funcs["half"].String())
+	compare(t, `func fc(a int, b int) int {
+		c := std.AddInt(a, b)
+		d := std.Sub(std.MulInt(a, 2), 1)
+		e := std.DivInt(7, 2)
+		e = std.DivInt(a, b)
+		s := std.Add("3", a)
+		c = std.Add(c, 1)
+		m := a % b
+		k := 2 + 3 * 4
+		h := std.AddInt(std.IntMax, 1)
+		b = std.ToInt(std.Sub(a, c))
+		f := half(std.ToInt(std.AddInt(a, 1)))
+		return std.ToInt(std.Sub(c, a))
+	}`, out.Files[0].Funcs["fc"].String())
+
+	// Unchecked code keeps Go operators, the division is still checked.
+	parser.CheckOverflow(false)
+	out, diags = parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+	compare(t, `func fc(a int, b int) int {
+		c := a + b
+		d := a * 2 - 1
+		e := std.DivInt(7, 2)
+		e = std.DivInt(a, b)
+		s := std.Add("3", a)
+		c = c + 1
+		m := a % b
+		k := 2 + 3 * 4
+		h := std.IntMax + 1
+		b = a - c
+		f := half(a + 1)
+		return c - a
+	}`, out.Files[0].Funcs["fc"].String())
+
+	// Callbacks return the declared type, not the widened one.
+	parser.CheckOverflow(true)
+	source = []byte(`<?php
+function fc(): int {
+	$a = [1, 2, 3];
+	$b = array_map(fn(int $x): int => $x * 2, $a);
+	usort($b, fn(int $x, int $y): int => $y - $x);
+	return array_reduce($b, fn(int $c, int $x): int => $c + $x, 0);
+}
+`)
+	out, diags = parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+	compare(t, `func fc() int {
+		a := array.NewInt(1, 2, 3)
+		b := a.MapInt(func(x int) int {
+			return std.ToInt(std.MulInt(x, 2))
+		})
+		b.USort(func(x int, y int) int {
+			return std.ToInt(std.SubInt(y, x))
+		}, false)
+		return b.ReduceInt(func(c int, x int) int {
+			return std.ToInt(std.AddInt(c, x))
+		}, 0)
+	}`, out.Files[0].Funcs["fc"].String())
+
+	// Typed properties and loop counters keep their types,
+	// echo formats floats the PHP way. Variables changed
+	// in nested blocks are widened where they are declared.
+	source = []byte(`<?php
+class Counter {
+	private int $n = 0;
+
+	public function add(int $k): int {
+		$this->n = $this->n + $k;
+		return $this->n;
+	}
+}
+
+function fc(int $n): void {
+	$i = $n;
+	$i++;
+	for ($j = 0; $j < $n; $j++) {
+		$i--;
+	}
+	$k = 0;
+	if ($n > 0) {
+		$k = $k + $n;
+	}
+	echo $i, 1.5, $n, $k;
+}
+`)
+	out, diags = parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+	compare(t, `type Counter struct {
+		n int
+	}
+
+	func (this *Counter) Add(k int) int {
+		this.n = std.ToInt(std.Add(this.n, k))
+		return this.n
+	}
+	`, out.Files[0].Classes[0].String())
+	compare(t, `func fc(n int) {
+		var k interface{}
+		var i interface{}
+		i = n
+		i = std.Add(i, 1)
+		for j := 0; j < n; j++ {
+			i = std.Sub(i, 1)
+		}
+		k = 0
+		if n > 0 {
+			k = std.Add(k, n)
+		}
+		fmt.Print(std.ToString(i), std.ToString(1.5), n, std.ToString(k))
+	}`, out.Files[0].Funcs["fc"].String())
+
+	// Ints mixed with floats are not converted, the variable
+	// holds a float after the first iteration.
+	source = []byte(`<?php
+function fc(int $n): void {
+	$m = 1;
+	for ($j = 0; $j < $n; $j++) {
+		$m = $m + 0.5;
+	}
+	$h = $n / 2.0;
+	echo $m, $h;
+}
+`)
+	for _, checked := range []bool{true, false} {
+		parser.CheckOverflow(checked)
+		out, diags = parser.Run(parsePHP(source), "dummy", false)
+		if len(diags) != 0 {
+			t.Fatalf("No diagnostics expected, %v found.", diags)
+		}
+		compare(t, `func fc(n int) {
+			var m interface{}
+			m = 1
+			for j := 0; j < n; j++ {
+				m = std.Add(m, 0.5)
+			}
+			h := float64(n) / 2.0
+			fmt.Print(std.ToString(m), std.ToString(h))
+		}`, out.Files[0].Funcs["fc"].String())
+	}
+}
+
+func testTimeFunctions(t *testing.T) {
+	t.Helper()
+
+	source := []byte(`<?php
+function fc(): void {
+	$t = time();
+	$s = date("Y-m-d H:i:s");
+	$s = date("D, d M Y", $t);
+	$t = mktime(0, 0, 0, 1, 1, 2000);
+	$t = mktime(12);
+	$r = strtotime("+1 day");
+	$r = strtotime("next monday", $t);
+	$b = checkdate(2, 29, 2020);
+	$b = date_default_timezone_set("Europe/Prague");
+	$s = date_default_timezone_get();
+	$f = microtime(true);
+	$s = microtime();
+	$s = microtime(false);
+	$h = hrtime();
+	$t = hrtime(true);
+}
+`)
+	parser := parser{
+		translator:         NewNameTranslator(),
+		functionTranslator: NewFunctionTranslator(),
+	}
+
+	out, diags := parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+
+	compare(t, `func fc() {
+		t := std.Time()
+		s := std.Date("Y-m-d H:i:s", std.Time())
+		s = std.Date("D, d M Y", t)
+		t = std.Mktime(0, 0, 0, 1, 1, 2000)
+		t = std.Mktime(12)
+		r := std.Strtotime("+1 day", std.Time())
+		r = std.Strtotime("next monday", t)
+		b := std.Checkdate(2, 29, 2020)
+		b = std.DateDefaultTimezoneSet("Europe/Prague")
+		s = std.DateDefaultTimezoneGet()
+		f := std.Microtime()
+		s = std.MicrotimeString()
+		s = std.MicrotimeString()
+		h := std.Hrtime()
+		t = std.HrtimeNumber()
+	}`, out.Files[0].Funcs["fc"].String())
+
+	for _, call := range []string{
+		`mktime()`,
+		`mktime(1, 2, 3, 4, 5, 6, 7)`,
+		`date()`,
+		`checkdate(1, 2)`,
+		`microtime($x)`,
+		`hrtime(1)`,
+		`strtotime([1])`,
+	} {
+		source = []byte(`<?php
+$x = true;
+` + call + `;
+`)
+		_, diags = parser.Run(parsePHP(source), "dummy", false)
+		if len(diags) != 1 || diags[0].Code != TypeMismatch {
+			t.Errorf("Wrong arguments of %s should be reported, %v found.", call, diags)
+		}
+	}
+}
+
+func testExceptions(t *testing.T) {
+	t.Helper()
+
+	source := []byte(`<?php
+class NotFound extends RuntimeException {}
+
+function fc(int $a): int {
+	try {
+		if ($a > 0) {
+			throw new NotFound("positive");
+		}
+	} catch (NotFound $e) {
+		return $e->getCode();
+	} catch (LogicException | RuntimeException $e) {
+		echo "unreachable";
+	} finally {
+		echo "finally";
+	}
+	return $a;
+}
+`)
+	parser := parser{
+		translator:         NewNameTranslator(),
+		functionTranslator: NewFunctionTranslator(),
+	}
+
+	out, diags := parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 0 {
+		t.Fatalf("No diagnostics expected, %v found.", diags)
+	}
+	f := out.Files[0]
+
+	compare(t, `type NotFound struct {
+		std.RuntimeException
+	}
+	`, f.Classes[0].String())
+
+	compare(t, `func NewNotFound(message string, code int, previous std.Throwable) *NotFound {
+		this := &NotFound{}
+		this.Construct(message, code, previous)
+		return this
+	}`, f.Funcs["NewNotFound"].String())
+
+	compare(t, `func fc(a int) int {
+		if ret, returned := func() (ret int, returned bool) {
+			defer func() {
+				fmt.Print("finally")
+			}()
+			defer func() {
+				thrown := recover()
+				if thrown == nil {
+					return
+				}
+				switch thrown.(type) {
+				case *NotFound:
+					e := thrown.(*NotFound)
+					ret, returned = e.GetCode(), true
+					return
+
+				case std.LogicExceptionInterface, std.RuntimeExceptionInterface:
+					fmt.Print("unreachable")
+
+				default:
+					panic(thrown)
+				}
+			}()
+			if a > 0 {
+				panic(std.Thrown(NewNotFound("positive", 0, nil), "dummy", 7))
+			}
+			return
+		}(); returned {
+			return ret
+		}
+		return a
+	}`, f.Funcs["fc"].String())
+
+	source = []byte(`<?php
+function fc() {
+	for ($i = 0; $i < 2; $i++) {
+		try {
+			continue;
+		} catch (Exception $e) {}
+	}
+	throw 1;
+}
+`)
+	_, diags = parser.Run(parsePHP(source), "dummy", false)
+	if len(diags) != 2 || diags[0].Code != UnsupportedStatement || diags[1].Code != TypeMismatch {
+		t.Errorf("Leaving try and throwing non-object should be reported, %v found.", diags)
+	}
+}
+
+func testNamespaces(t *testing.T) {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "php2go-namespaces")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"index.php": `<?php
+use function Lib\Util\shout;
+use Lib\Util as U;
+
+require 'lib/Util.php';
+require 'lib/Bad.php';
+require 'lib/Shape.php';
+
+echo shout("a");
+echo \Lib\Util\shout("b");
+echo U\shout("c");
+`,
+		"lib/Util.php": `<?php
+namespace Lib\Util;
+
+function shout(string $s): string {
+	return $s . "!";
+}
+`,
+		"lib/Bad.php": `<?php
+namespace Lib\Bad;
+
+echo "top level";
+`,
+		"lib/Shape.php": `<?php
+namespace Lib\Shapes;
+
+class Shape {
+	private string $name = "shape";
+	protected int $sides = 0;
+
+	private function label(): string {
+		return $this->name;
+	}
+
+	protected function count(): int {
+		return $this->sides;
+	}
+
+	public function describe(): string {
+		return $this->label();
+	}
+}
+`,
+	}
+	for n, src := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, n)), 0755); err != nil {
+			t.Fatalf("creating dir for %s: %v", n, err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, n), []byte(src), 0644); err != nil {
+			t.Fatalf("writing %s: %v", n, err)
+		}
+	}
+
+	parser := NewParser(NewNameTranslator(), NewFunctionTranslator())
+	parser.Module("example.com/app")
+	out, diags, err := parser.RunFromString(filepath.Join(dir, "index.php"), false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(diags) != 1 || diags[0].Code != UnsupportedStatement {
+		t.Errorf("Statement outside of functions should be reported, %v found.", diags)
+	}
+	if len(out.Files) != 4 {
+		t.Fatalf("4 files expected, %d found.", len(out.Files))
+	}
+
+	util := out.Files[1]
+	if util.Package != "util" || util.Dir() != "lib/util" {
+		t.Errorf("Package 'util' in 'lib/util' expected, '%s' in '%s' found.", util.Package, util.Dir())
+	}
+	compare(t, `package util
+
+	import (
+		"github.com/lSimul/php2go/std"
+	)
+
+	func Shout(s string) string {
+		return std.Concat(s, "!")
+	}`, util.String())
+
+	main := out.Files[0]
+	compare(t, `func (g *global) mainFunc0() {
+		fmt.Print(util.Shout("a"))
+		fmt.Print(util.Shout("b"))
+		fmt.Print(util.Shout("c"))
+	}`, main.Main.String())
+	if !strings.Contains(main.String(), `"example.com/app/lib/util"`) {
+		t.Error("Package util should be imported.")
+	}
+
+	// Protected members are reachable from subclasses
+	// in other packages, private ones stay unexported.
+	shapes := out.Files[3].String()
+	for _, m := range []string{"name string", "Sides int", "label() string", "Count() int", "Describe() string"} {
+		if !strings.Contains(shapes, m) {
+			t.Errorf("Member '%s' expected in:\n%s", m, shapes)
+		}
+	}
+}
+
+func testProject(t *testing.T) {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "php2go-project")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"about.php":       "<?php\nrequire 'lib/helpers.php';\necho greet();\n",
+		"index.php":       "<?php\nif (true) {\n\trequire_once './lib/helpers.php';\n}\necho greet();\n",
+		"admin/users.php": "<?php\nrequire '../lib/helpers.php';\necho greet();\n",
+		"lib/helpers.php": "<?php\nfunction greet(): string {\n\treturn \"hi\";\n}\n",
+	}
+	for n, src := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, n)), 0755); err != nil {
+			t.Fatalf("creating dir for %s: %v", n, err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, n), []byte(src), 0644); err != nil {
+			t.Fatalf("writing %s: %v", n, err)
+		}
+	}
+
+	entries, err := Entries(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"index.php", "about.php", "admin/users.php"}
+	if len(entries) != len(expected) {
+		t.Fatalf("%v expected, %v found.", expected, entries)
+	}
+	for i, e := range expected {
+		if entries[i] != filepath.Join(dir, e) {
+			t.Errorf("'%s' expected, '%s' found.", e, entries[i])
+		}
+	}
+
+	parser := NewParser(NewNameTranslator(), NewFunctionTranslator())
+	out, diags, err := parser.RunProject(entries, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(diags) != 0 {
+		t.Errorf("No diagnostics expected, %v found.", diags)
+	}
+	names := make([]string, len(out.Files))
+	for i, f := range out.Files {
+		names[i] = strings.TrimPrefix(f.Name, out.Path)
+	}
+	if n := strings.Join(names, " "); n != "index.php lib/helpers.php about.php admin/users.php" {
+		t.Errorf("Every file should be transpiled once, '%s' found.", n)
+	}
+}
+
 func parsePHP(source []byte) *node.Root {
 	parser := php7.NewParser(source, "")
 	parser.Parse()
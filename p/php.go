@@ -2,6 +2,7 @@ package p
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/lSimul/php2go/lang"
 )
@@ -10,6 +11,75 @@ var functionsPHP = map[string](func(lang.Block, []lang.Expression) (*lang.Functi
 	"array_push": arrayPush,
 	"count":      count,
 
+	"sort":   sortArray(false, false, false),
+	"rsort":  sortArray(true, false, false),
+	"asort":  sortArray(false, false, true),
+	"arsort": sortArray(true, false, true),
+	"ksort":  sortArray(false, true, true),
+	"krsort": sortArray(true, true, true),
+	"usort":  userSort(false),
+	"uasort": userSort(true),
+	"uksort": userKeySort,
+
+	"array_map":        arrayMap,
+	"array_filter":     arrayFilter,
+	"array_reduce":     arrayReduce,
+	"in_array":         inArray,
+	"array_search":     arraySearch,
+	"array_key_exists": arrayKeyExists,
+	"array_keys":       arrayKeys,
+	"array_values":     arrayValues,
+	"array_merge":      arrayMerge,
+	"array_slice":      arraySlice,
+	"array_splice":     arraySplice,
+	"array_combine":    arrayCombine,
+	"array_flip":       arrayFlip,
+	"array_unique":     arrayUnique,
+	"array_sum":        arraySum,
+	"array_pop":        arrayPop,
+	"array_shift":      arrayShift,
+	"array_unshift":    arrayUnshift,
+	"range":            rangeArray,
+
+	"strlen":        stdFunction("std.Strlen", lang.Int, param{typ: lang.String}),
+	"substr":        stdFunction("std.Substr", lang.String, param{typ: lang.String}, param{typ: lang.Int}, param{lang.Int, "std.IntMax"}),
+	"strpos":        stdFunction("std.Strpos", lang.Anything, param{typ: lang.String}, param{typ: lang.String}, param{lang.Int, "0"}),
+	"stripos":       stdFunction("std.Stripos", lang.Anything, param{typ: lang.String}, param{typ: lang.String}, param{lang.Int, "0"}),
+	"strrpos":       stdFunction("std.Strrpos", lang.Anything, param{typ: lang.String}, param{typ: lang.String}, param{lang.Int, "0"}),
+	"str_replace":   stdFunction("std.StrReplace", lang.String, param{typ: lang.String}, param{typ: lang.String}, param{typ: lang.String}),
+	"str_repeat":    stdFunction("std.StrRepeat", lang.String, param{typ: lang.String}, param{typ: lang.Int}),
+	"str_pad":       stdFunction("std.StrPad", lang.String, param{typ: lang.String}, param{typ: lang.Int}, param{lang.String, `" "`}, param{lang.Int, "std.StrPadRight"}),
+	"trim":          stdFunction("std.Trim", lang.String, param{typ: lang.String}, param{lang.String, "std.TrimChars"}),
+	"ltrim":         stdFunction("std.Ltrim", lang.String, param{typ: lang.String}, param{lang.String, "std.TrimChars"}),
+	"rtrim":         stdFunction("std.Rtrim", lang.String, param{typ: lang.String}, param{lang.String, "std.TrimChars"}),
+	"strtolower":    stdFunction("std.Strtolower", lang.String, param{typ: lang.String}),
+	"strtoupper":    stdFunction("std.Strtoupper", lang.String, param{typ: lang.String}),
+	"ucfirst":       stdFunction("std.Ucfirst", lang.String, param{typ: lang.String}),
+	"ucwords":       stdFunction("std.Ucwords", lang.String, param{typ: lang.String}, param{lang.String, "std.WordDelimiters"}),
+	"explode":       stdFunction("std.Explode", ArrayType(lang.String), param{typ: lang.String}, param{typ: lang.String}, param{lang.Int, "std.IntMax"}),
+	"implode":       implode,
+	"sprintf":       sprintf,
+	"number_format": stdFunction("std.NumberFormat", lang.String, param{typ: lang.Float64}, param{lang.Int, "0"}, param{lang.String, `"."`}, param{lang.String, `","`}),
+	"nl2br":         stdFunction("std.Nl2br", lang.String, param{typ: lang.String}, param{lang.Bool, "true"}),
+	"wordwrap":      stdFunction("std.Wordwrap", lang.String, param{typ: lang.String}, param{lang.Int, "75"}, param{lang.String, `"\n"`}, param{lang.Bool, "false"}),
+
+	"abs":        abs,
+	"min":        extremum("Min"),
+	"max":        extremum("Max"),
+	"round":      stdFunction("std.Round", lang.Float64, param{typ: lang.Float64}, param{lang.Int, "0"}),
+	"floor":      stdFunction("math.Floor", lang.Float64, param{typ: lang.Float64}),
+	"ceil":       stdFunction("math.Ceil", lang.Float64, param{typ: lang.Float64}),
+	"intdiv":     stdFunction("std.Intdiv", lang.Int, param{typ: lang.Int}, param{typ: lang.Int}),
+	"fmod":       stdFunction("math.Mod", lang.Float64, param{typ: lang.Float64}, param{typ: lang.Float64}),
+	"pow":        pow,
+	"sqrt":       stdFunction("math.Sqrt", lang.Float64, param{typ: lang.Float64}),
+	"pi":         pi,
+	"rand":       random("Rand"),
+	"mt_rand":    random("MtRand"),
+	"srand":      mtSrand,
+	"mt_srand":   mtSrand,
+	"random_int": stdFunction("std.RandomInt", lang.Int, param{typ: lang.Int}, param{typ: lang.Int}),
+
 	"mysqli_connect":     mysqliConnect,
 	"mysqli_select_db":   mysqliSelectDB,
 	"mysqli_query":       mysqliQuery,
@@ -20,10 +90,40 @@ var functionsPHP = map[string](func(lang.Block, []lang.Expression) (*lang.Functi
 	"file_exists": fileExists,
 	"scandir":     scandir,
 
-	"microtime": microtime,
+	"time":                      stdFunction("std.Time", lang.Int),
+	"microtime":                 microtime,
+	"hrtime":                    hrtime,
+	"date":                      stdFunction("std.Date", lang.String, param{typ: lang.String}, param{lang.Int, "std.Time()"}),
+	"mktime":                    mktime,
+	"strtotime":                 stdFunction("std.Strtotime", lang.Anything, param{typ: lang.String}, param{lang.Int, "std.Time()"}),
+	"checkdate":                 stdFunction("std.Checkdate", lang.Bool, param{typ: lang.Int}, param{typ: lang.Int}, param{typ: lang.Int}),
+	"date_default_timezone_set": stdFunction("std.DateDefaultTimezoneSet", lang.Bool, param{typ: lang.String}),
+	"date_default_timezone_get": stdFunction("std.DateDefaultTimezoneGet", lang.String),
 	// "echo":       true, // extra case, AST does not use echo as a function
 }
 
+// constantsPHP are PHP constants of ints defined in std.
+var constantsPHP = map[string]string{
+	"SORT_REGULAR":       "std.SortRegular",
+	"SORT_NUMERIC":       "std.SortNumeric",
+	"SORT_STRING":        "std.SortString",
+	"SORT_LOCALE_STRING": "std.SortLocaleString",
+	"SORT_NATURAL":       "std.SortNatural",
+	"SORT_FLAG_CASE":     "std.SortFlagCase",
+
+	"STR_PAD_LEFT":  "std.StrPadLeft",
+	"STR_PAD_RIGHT": "std.StrPadRight",
+	"STR_PAD_BOTH":  "std.StrPadBoth",
+
+	"PHP_INT_MAX": "std.IntMax",
+	"PHP_INT_MIN": "std.IntMin",
+}
+
+// floatConstantsPHP are PHP constants of floats defined in std.
+var floatConstantsPHP = map[string]string{
+	"PHP_FLOAT_EPSILON": "std.FloatEpsilon",
+}
+
 func arrayPush(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
 	if len(args) < 2 {
 		return nil, "", errors.New("array_push requires atlast two arguments")
@@ -33,11 +133,9 @@ func arrayPush(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string
 	if !ok || !IsArray(v.Type().String()) {
 		return nil, "", errors.New("First argument has to be a variable, an array.")
 	}
-	typ := ArrayItem(v.Type().String())
-
 	vars := []lang.Expression{}
 	for _, arg := range args[1:] {
-		if !arg.Type().Equal(typ) {
+		if !arrayAccepts(v.Type(), arg.Type()) {
 			return nil, "", errors.New("Cannot push this type.")
 		}
 		vars = append(vars, arg)
@@ -72,6 +170,112 @@ func count(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, er
 	return fc, "", nil
 }
 
+// sortArray sorts the array passed by reference, in the reverse
+// order if set. Arrays sorted by values lose their keys,
+// unless keep is set.
+func sortArray(reverse, byKey, keep bool) func(lang.Block, []lang.Expression) (*lang.FunctionCall, string, error) {
+	return func(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+		if len(args) != 1 && len(args) != 2 {
+			return nil, "", errors.New("Sort requires one or two arguments.")
+		}
+
+		v, ok := args[0].(*lang.VarRef)
+		if !ok || !IsArray(v.Type().String()) {
+			return nil, "", errors.New("First argument has to be a variable, an array.")
+		}
+		var flags lang.Expression = &lang.Number{Value: constantsPHP["SORT_REGULAR"]}
+		if len(args) == 2 {
+			if !args[1].Type().Equal(lang.Int) {
+				return nil, "", errors.New("Flags have to be an int.")
+			}
+			flags = args[1]
+		}
+
+		sorter := &lang.FunctionCall{
+			Name:   "std.Sorter",
+			Args:   []lang.Expression{flags, &lang.Const{Value: fmt.Sprint(reverse)}},
+			Return: lang.NewTyp(lang.Anything, false),
+		}
+		fc := &lang.FunctionCall{
+			Name: v.String() + ".Sort",
+			Args: []lang.Expression{
+				sorter,
+				&lang.Const{Value: fmt.Sprint(byKey)},
+				&lang.Const{Value: fmt.Sprint(keep)},
+			},
+			Return: lang.NewTyp(lang.Void, false),
+		}
+
+		fc.SetParent(b)
+		return fc, "std", nil
+	}
+}
+
+// userSort sorts values of the array passed by reference
+// by the function, keys are kept if set.
+func userSort(keep bool) func(lang.Block, []lang.Expression) (*lang.FunctionCall, string, error) {
+	return func(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+		if len(args) != 2 {
+			return nil, "", errors.New("Sort requires exactly two arguments.")
+		}
+
+		v, ok := args[0].(*lang.VarRef)
+		if !ok || !IsArray(v.Type().String()) {
+			return nil, "", errors.New("First argument has to be a variable, an array.")
+		}
+		if err := comparison(args[1], arrayItem(v.Type())); err != nil {
+			return nil, "", err
+		}
+
+		fc := &lang.FunctionCall{
+			Name:   v.String() + ".USort",
+			Args:   []lang.Expression{args[1], &lang.Const{Value: fmt.Sprint(keep)}},
+			Return: lang.NewTyp(lang.Void, false),
+		}
+
+		fc.SetParent(b)
+		return fc, "", nil
+	}
+}
+
+// userKeySort sorts keys of the array passed by reference by
+// the function, keys are compared as strings.
+func userKeySort(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) != 2 {
+		return nil, "", errors.New("uksort requires exactly two arguments.")
+	}
+
+	v, ok := args[0].(*lang.VarRef)
+	if !ok || !IsArray(v.Type().String()) {
+		return nil, "", errors.New("First argument has to be a variable, an array.")
+	}
+	if err := comparison(args[1], lang.NewTyp(lang.String, false)); err != nil {
+		return nil, "", err
+	}
+
+	fc := &lang.FunctionCall{
+		Name:   v.String() + ".UKSort",
+		Args:   []lang.Expression{args[1]},
+		Return: lang.NewTyp(lang.Void, false),
+	}
+
+	fc.SetParent(b)
+	return fc, "", nil
+}
+
+// comparison checks the function compares two values
+// of the type, returning an int.
+func comparison(f lang.Expression, t lang.Typ) error {
+	fn := f.Type().Func
+	if fn == nil {
+		return errors.New("Second argument has to be a function.")
+	}
+	if len(fn.Args) != 2 || !fn.Args[0].Eq(t) || !fn.Args[1].Eq(t) || !fn.Return.Equal(lang.Int) {
+		return fmt.Errorf("Function has to compare two values of the type %s, returning an int.", t)
+	}
+	return nil
+}
+
 func mysqliConnect(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
 	if len(args) != 3 {
 		return nil, "", errors.New("mysqli_connect has to have three arguments.")
@@ -238,12 +442,3 @@ func scandir(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string,
 }
 
 // Not 1:1, it will always return float.
-func microtime(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
-	fc := &lang.FunctionCall{
-		Name:   "std.Microtime",
-		Return: lang.NewTyp(lang.Float64, false),
-	}
-
-	fc.SetParent(b)
-	return fc, "std", nil
-}
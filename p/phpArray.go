@@ -0,0 +1,575 @@
+package p
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lSimul/php2go/lang"
+)
+
+// Array functions of PHP, they are translated to methods
+// of the arrays, or to std functions working with any array.
+
+func arrayMap(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) != 2 {
+		return nil, "", errors.New("array_map requires exactly two arguments.")
+	}
+
+	if !IsArray(args[1].Type().String()) {
+		return nil, "", errors.New("Second argument has to be an array.")
+	}
+	ret, err := signature(args[0], arrayItem(args[1].Type()))
+	if err != nil {
+		return nil, "", err
+	}
+	name, ok := basicArray(ret)
+	if !ok {
+		return nil, "", fmt.Errorf("Arrays of %s cannot be created by array_map.", ret)
+	}
+
+	fc := &lang.FunctionCall{
+		Name:   args[1].String() + ".Map" + name,
+		Args:   args[:1],
+		Return: lang.NewTyp(ArrayType(ret.String()), false),
+	}
+
+	fc.SetParent(b)
+	return fc, "", nil
+}
+
+func arrayFilter(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, "", errors.New("array_filter requires one or two arguments, modes are not supported.")
+	}
+
+	if !IsArray(args[0].Type().String()) {
+		return nil, "", errors.New("First argument has to be an array.")
+	}
+	item := arrayItem(args[0].Type())
+
+	// Items are converted to bool by default.
+	var keep lang.Expression
+	nsp := ""
+	if len(args) == 2 {
+		ret, err := signature(args[1], item)
+		if err != nil {
+			return nil, "", err
+		}
+		if !ret.Equal(lang.Bool) {
+			return nil, "", errors.New("Function has to return a bool.")
+		}
+		keep = args[1]
+	} else {
+		l := lang.NewFuncLit(b)
+		v := lang.NewVariable("v", item, false)
+		l.Args = []*lang.Variable{v}
+		l.Return = lang.NewTyp(lang.Bool, false)
+		l.AddStatement(&lang.Return{
+			Expression: &lang.FunctionCall{
+				Name:   "std.ToBool",
+				Args:   []lang.Expression{lang.NewVarRef(v, item)},
+				Return: lang.NewTyp(lang.Bool, false),
+			},
+		})
+		keep = l
+		nsp = "std"
+	}
+
+	fc := &lang.FunctionCall{
+		Name:   args[0].String() + ".Filter",
+		Args:   []lang.Expression{keep},
+		Return: valueOf(args[0].Type()),
+	}
+
+	fc.SetParent(b)
+	return fc, nsp, nil
+}
+
+func arrayReduce(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) != 3 {
+		return nil, "", errors.New("array_reduce requires exactly three arguments, the initial value is required.")
+	}
+
+	if !IsArray(args[0].Type().String()) {
+		return nil, "", errors.New("First argument has to be an array.")
+	}
+	carry := args[2].Type()
+	name, ok := basicArray(carry)
+	if !ok {
+		return nil, "", fmt.Errorf("Values of %s cannot be reduced to.", carry)
+	}
+	ret, err := signature(args[1], carry, arrayItem(args[0].Type()))
+	if err != nil {
+		return nil, "", err
+	}
+	if !ret.Eq(carry) {
+		return nil, "", fmt.Errorf("Function has to return %s, like the initial value.", carry)
+	}
+
+	fc := &lang.FunctionCall{
+		Name:   args[0].String() + ".Reduce" + name,
+		Args:   args[1:],
+		Return: carry,
+	}
+
+	fc.SetParent(b)
+	return fc, "", nil
+}
+
+func inArray(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	return search(b, "in_array", "std.InArray", lang.NewTyp(lang.Bool, false), args)
+}
+
+func arraySearch(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	return search(b, "array_search", "std.ArraySearch", lang.NewTyp(lang.Anything, false), args)
+}
+
+// search calls std function looking for the needle,
+// strict comparison is off by default.
+func search(b lang.Block, n, fn string, ret lang.Typ, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, "", fmt.Errorf("%s requires two or three arguments.", n)
+	}
+
+	if !IsArray(args[1].Type().String()) {
+		return nil, "", errors.New("Second argument has to be an array.")
+	}
+	if len(args) == 2 {
+		args = append(args, &lang.Const{Value: "false"})
+	} else if !args[2].Type().Equal(lang.Bool) {
+		return nil, "", errors.New("Third argument has to be a bool.")
+	}
+
+	fc := &lang.FunctionCall{
+		Name:   fn,
+		Args:   args,
+		Return: ret,
+	}
+
+	fc.SetParent(b)
+	return fc, "std", nil
+}
+
+func arrayKeyExists(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) != 2 {
+		return nil, "", errors.New("array_key_exists requires exactly two arguments.")
+	}
+
+	if !IsArray(args[1].Type().String()) {
+		return nil, "", errors.New("Second argument has to be an array.")
+	}
+	// Keys without static types are checked by std.Key.
+	fn, ns := "array.NewScalar", "array"
+	switch args[0].Type().String() {
+	case lang.Int, lang.String, lang.Float64, lang.Bool, "array.Scalar":
+	case lang.Anything:
+		fn, ns = "std.Key", "std"
+	default:
+		return nil, "", fmt.Errorf("Illegal offset type %s.", args[0].Type())
+	}
+
+	fc := &lang.FunctionCall{
+		Name: args[1].String() + ".Isset",
+		Args: []lang.Expression{&lang.FunctionCall{
+			Name:   fn,
+			Args:   args[:1],
+			Return: lang.NewTyp("array.Scalar", false),
+		}},
+		Return: lang.NewTyp(lang.Bool, false),
+	}
+
+	fc.SetParent(b)
+	return fc, ns, nil
+}
+
+func arrayKeys(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	return arrayFunc(b, "array_keys", "std.ArrayKeys", args)
+}
+
+func arrayFlip(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	return arrayFunc(b, "array_flip", "std.ArrayFlip", args)
+}
+
+// arrayFunc calls std function creating array.Any from the array.
+func arrayFunc(b lang.Block, n, fn string, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) != 1 {
+		return nil, "", fmt.Errorf("%s requires exactly one argument.", n)
+	}
+
+	if !IsArray(args[0].Type().String()) {
+		return nil, "", errors.New("Argument has to be an array.")
+	}
+
+	fc := &lang.FunctionCall{
+		Name:   fn,
+		Args:   args,
+		Return: lang.NewTyp(ArrayType(lang.Anything), false),
+	}
+
+	fc.SetParent(b)
+	return fc, "std", nil
+}
+
+func arrayValues(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) != 1 {
+		return nil, "", errors.New("array_values requires exactly one argument.")
+	}
+
+	if !IsArray(args[0].Type().String()) {
+		return nil, "", errors.New("Argument has to be an array.")
+	}
+
+	fc := &lang.FunctionCall{
+		Name:   args[0].String() + ".Values",
+		Return: valueOf(args[0].Type()),
+	}
+
+	fc.SetParent(b)
+	return fc, "", nil
+}
+
+func arrayUnique(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) != 1 {
+		return nil, "", errors.New("array_unique requires exactly one argument, flags are not supported.")
+	}
+
+	if !IsArray(args[0].Type().String()) {
+		return nil, "", errors.New("Argument has to be an array.")
+	}
+
+	// PHP compares the items as strings.
+	fc := &lang.FunctionCall{
+		Name:   args[0].String() + ".Unique",
+		Args:   []lang.Expression{&lang.Const{Value: "std.ToString"}},
+		Return: valueOf(args[0].Type()),
+	}
+
+	fc.SetParent(b)
+	return fc, "std", nil
+}
+
+func arrayMerge(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) < 1 {
+		return nil, "", errors.New("array_merge requires atleast one argument.")
+	}
+
+	t := args[0].Type()
+	if !IsArray(t.String()) {
+		return nil, "", errors.New("Arguments have to be arrays.")
+	}
+	for _, a := range args[1:] {
+		if !a.Type().Eq(t) {
+			return nil, "", fmt.Errorf("Arrays of different types cannot be merged, %s and %s found.", t, a.Type())
+		}
+	}
+
+	fc := &lang.FunctionCall{
+		Name:   args[0].String() + ".Merge",
+		Args:   args[1:],
+		Return: valueOf(t),
+	}
+
+	fc.SetParent(b)
+	return fc, "", nil
+}
+
+func arraySlice(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) < 2 || len(args) > 4 {
+		return nil, "", errors.New("array_slice requires two to four arguments.")
+	}
+
+	a := args[0]
+	if !IsArray(a.Type().String()) {
+		return nil, "", errors.New("First argument has to be an array.")
+	}
+	bounds, ns, err := bounds(args[1:])
+	if err != nil {
+		return nil, "", err
+	}
+	preserve := lang.Expression(&lang.Const{Value: "false"})
+	if len(args) == 4 {
+		if !args[3].Type().Equal(lang.Bool) {
+			return nil, "", errors.New("Fourth argument has to be a bool.")
+		}
+		preserve = args[3]
+	}
+
+	fc := &lang.FunctionCall{
+		Name:   a.String() + ".Slice",
+		Args:   append(bounds, preserve),
+		Return: valueOf(a.Type()),
+	}
+
+	fc.SetParent(b)
+	return fc, ns, nil
+}
+
+func arraySplice(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) < 2 || len(args) > 4 {
+		return nil, "", errors.New("array_splice requires two to four arguments.")
+	}
+
+	v, ok := args[0].(*lang.VarRef)
+	if !ok || !IsArray(v.Type().String()) {
+		return nil, "", errors.New("First argument has to be a variable, an array.")
+	}
+	bounds, ns, err := bounds(args[1:])
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Replacement can be a single value too.
+	t := valueOf(v.Type())
+	repl := lang.Expression(&lang.FunctionCall{
+		Name:   constructor(t.String()),
+		Return: t,
+	})
+	if len(args) == 4 {
+		switch r := args[3]; {
+		case r.Type().Eq(t):
+			repl = r
+		case arrayAccepts(t, r.Type()):
+			repl = &lang.FunctionCall{
+				Name:   constructor(t.String()),
+				Args:   []lang.Expression{copyArray(r)},
+				Return: t,
+			}
+		default:
+			return nil, "", fmt.Errorf("Cannot use %s as the replacement.", r.Type())
+		}
+	}
+
+	fc := &lang.FunctionCall{
+		Name:   v.String() + ".Splice",
+		Args:   append(bounds, repl),
+		Return: t,
+	}
+
+	fc.SetParent(b)
+	return fc, ns, nil
+}
+
+// bounds returns the offset and length of array_slice and
+// array_splice, the rest of the array is used by default, like
+// in substr. Namespace of the default length is returned too.
+func bounds(args []lang.Expression) ([]lang.Expression, string, error) {
+	if !args[0].Type().Equal(lang.Int) {
+		return nil, "", errors.New("Offset has to be an int.")
+	}
+	if len(args) < 2 {
+		return []lang.Expression{args[0], value(lang.Int, "std.IntMax")}, "std", nil
+	}
+	if !args[1].Type().Equal(lang.Int) {
+		return nil, "", errors.New("Length has to be an int.")
+	}
+	return args[:2], "", nil
+}
+
+func arrayCombine(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) != 2 {
+		return nil, "", errors.New("array_combine requires exactly two arguments.")
+	}
+
+	if !IsArray(args[0].Type().String()) || !IsArray(args[1].Type().String()) {
+		return nil, "", errors.New("Arguments have to be arrays.")
+	}
+
+	fc := &lang.FunctionCall{
+		Name:   args[1].String() + ".Combine",
+		Args:   args[:1],
+		Return: valueOf(args[1].Type()),
+	}
+
+	fc.SetParent(b)
+	return fc, "", nil
+}
+
+func arraySum(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) != 1 {
+		return nil, "", errors.New("array_sum requires exactly one argument.")
+	}
+
+	if !IsArray(args[0].Type().String()) {
+		return nil, "", errors.New("Argument has to be an array.")
+	}
+
+	fc := &lang.FunctionCall{
+		Name:   "std.ArraySum",
+		Args:   args,
+		Return: lang.NewTyp(lang.Anything, false),
+	}
+	// Sums of ints and floats keep their types.
+	switch t := arrayItem(args[0].Type()); t.String() {
+	case lang.Int:
+		fc = &lang.FunctionCall{
+			Name:   "std.ToInt",
+			Args:   []lang.Expression{fc},
+			Return: t,
+		}
+	case lang.Float64:
+		fc = &lang.FunctionCall{
+			Name:   "std.ToFloat64",
+			Args:   []lang.Expression{fc},
+			Return: t,
+		}
+	}
+
+	fc.SetParent(b)
+	return fc, "std", nil
+}
+
+func rangeArray(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, "", errors.New("range requires two or three arguments.")
+	}
+
+	if len(args) == 2 {
+		args = append(args, &lang.Number{Value: "1"})
+	}
+	if !isNumber(args[2].Type().String()) {
+		return nil, "", errors.New("Step has to be a number.")
+	}
+
+	start, end, step := args[0].Type().String(), args[1].Type().String(), args[2].Type().String()
+	var fc *lang.FunctionCall
+	switch {
+	case start == lang.Int && end == lang.Int && step == lang.Int:
+		fc = &lang.FunctionCall{
+			Name:   "std.Range",
+			Return: lang.NewTyp(ArrayType(lang.Int), false),
+		}
+
+	case start == lang.String && end == lang.String && step == lang.Int:
+		fc = &lang.FunctionCall{
+			Name:   "std.RangeString",
+			Return: lang.NewTyp(ArrayType(lang.String), false),
+		}
+
+	// Any float makes the range of floats.
+	case isNumber(start) && isNumber(end):
+		for i, a := range args {
+			if a.Type().Equal(lang.Int) {
+				args[i] = &lang.FunctionCall{
+					Name:   "float64",
+					Args:   []lang.Expression{a},
+					Return: lang.NewTyp(lang.Float64, false),
+				}
+			}
+		}
+		fc = &lang.FunctionCall{
+			Name:   "std.RangeFloat64",
+			Return: lang.NewTyp(ArrayType(lang.Float64), false),
+		}
+
+	default:
+		return nil, "", errors.New("Range has to be of ints, floats or letters.")
+	}
+	fc.Args = args
+
+	fc.SetParent(b)
+	return fc, "std", nil
+}
+
+func isNumber(t string) bool {
+	return t == lang.Int || t == lang.Float64
+}
+
+func arrayPop(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	return removeItem(b, "array_pop", "Pop", args)
+}
+
+func arrayShift(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	return removeItem(b, "array_shift", "Shift", args)
+}
+
+// removeItem removes the item from the array passed by reference.
+func removeItem(b lang.Block, n, method string, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) != 1 {
+		return nil, "", fmt.Errorf("%s requires exactly one argument.", n)
+	}
+
+	v, ok := args[0].(*lang.VarRef)
+	if !ok || !IsArray(v.Type().String()) {
+		return nil, "", errors.New("Argument has to be a variable, an array.")
+	}
+
+	fc := &lang.FunctionCall{
+		Name:   v.String() + "." + method,
+		Return: arrayItem(v.Type()),
+	}
+
+	fc.SetParent(b)
+	return fc, "", nil
+}
+
+func arrayUnshift(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) < 2 {
+		return nil, "", errors.New("array_unshift requires atlast two arguments")
+	}
+
+	v, ok := args[0].(*lang.VarRef)
+	if !ok || !IsArray(v.Type().String()) {
+		return nil, "", errors.New("First argument has to be a variable, an array.")
+	}
+	vars := []lang.Expression{}
+	for _, arg := range args[1:] {
+		if !arrayAccepts(v.Type(), arg.Type()) {
+			return nil, "", errors.New("Cannot unshift this type.")
+		}
+		vars = append(vars, copyArray(arg))
+	}
+
+	fc := &lang.FunctionCall{
+		Name:   v.String() + ".Unshift",
+		Args:   vars,
+		Return: lang.NewTyp(lang.Int, false),
+	}
+
+	fc.SetParent(b)
+	return fc, "", nil
+}
+
+// signature checks the function accepts arguments
+// of the types, its return type is returned.
+func signature(f lang.Expression, args ...lang.Typ) (lang.Typ, error) {
+	fn := f.Type().Func
+	if fn == nil {
+		return lang.Typ{}, errors.New("Callback has to be a function.")
+	}
+	ok := len(fn.Args) == len(args)
+	for i := 0; ok && i < len(args); i++ {
+		ok = fn.Args[i].Eq(args[i])
+	}
+	if !ok {
+		names := make([]string, len(args))
+		for i, a := range args {
+			names[i] = a.String()
+		}
+		return lang.Typ{}, fmt.Errorf("Callback has to accept %s.", strings.Join(names, ", "))
+	}
+	return fn.Return, nil
+}
+
+// basicArray returns the name of the array of the type
+// in std/array, arrays of other types cannot be returned
+// by Map and Reduce methods.
+func basicArray(t lang.Typ) (string, bool) {
+	switch t.String() {
+	case lang.Int, lang.String, lang.Float64, lang.Bool, lang.Anything:
+		return strings.TrimPrefix(ArrayType(t.String()), "array."), true
+	}
+	return "", false
+}
+
+// valueOf returns the type of the array passed by value.
+func valueOf(t lang.Typ) lang.Typ {
+	t.IsPointer = false
+	return t
+}
+
+// constructor returns the function creating the array.
+func constructor(name string) string {
+	i := strings.LastIndex(name, ".") + 1
+	return name[:i] + "New" + name[i:]
+}
@@ -0,0 +1,159 @@
+package p
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lSimul/php2go/lang"
+)
+
+// Math functions of PHP, functions of Go math are used,
+// when they behave the same way.
+
+func abs(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) != 1 {
+		return nil, "", errors.New("abs requires exactly one argument.")
+	}
+
+	var fc *lang.FunctionCall
+	nsp := ""
+	switch t := args[0].Type(); {
+	case t.Equal(lang.Int):
+		fc = &lang.FunctionCall{
+			Name:   "std.AbsInt",
+			Args:   args,
+			Return: lang.NewTyp(lang.Int, false),
+		}
+		nsp = "std"
+
+	case t.Equal(lang.Float64):
+		fc = &lang.FunctionCall{
+			Name:   "math.Abs",
+			Args:   args,
+			Return: lang.NewTyp(lang.Float64, false),
+		}
+		nsp = "math"
+
+	default:
+		return nil, "", errors.New("Argument has to be a number.")
+	}
+
+	fc.SetParent(b)
+	return fc, nsp, nil
+}
+
+// extremum returns min or max of the values or items of the array.
+// Ints and floats are compared by Go, the rest by std like PHP does.
+func extremum(fn string) func(lang.Block, []lang.Expression) (*lang.FunctionCall, string, error) {
+	return func(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+		if len(args) == 0 {
+			return nil, "", fmt.Errorf("%s requires atleast one argument.", FirstLower(fn))
+		}
+
+		if len(args) == 1 {
+			if !IsArray(args[0].Type().String()) {
+				return nil, "", errors.New("Single argument has to be an array.")
+			}
+			fc := &lang.FunctionCall{
+				Name:   "std." + fn + "Array",
+				Args:   args,
+				Return: lang.NewTyp(lang.Anything, false),
+			}
+			switch t := arrayItem(args[0].Type()); t.String() {
+			case lang.Int:
+				fc = &lang.FunctionCall{
+					Name:   "std.ToInt",
+					Args:   []lang.Expression{fc},
+					Return: t,
+				}
+			case lang.Float64:
+				fc = &lang.FunctionCall{
+					Name:   "std.ToFloat64",
+					Args:   []lang.Expression{fc},
+					Return: t,
+				}
+			}
+
+			fc.SetParent(b)
+			return fc, "std", nil
+		}
+
+		fc := &lang.FunctionCall{
+			Name:   "std." + fn,
+			Args:   args,
+			Return: lang.NewTyp(lang.Anything, false),
+		}
+		t := args[0].Type()
+		same := true
+		for _, a := range args[1:] {
+			same = same && a.Type().Eq(t)
+		}
+		if same && t.Equal(lang.Int) {
+			fc.Name += "Ints"
+			fc.Return = t
+		} else if same && t.Equal(lang.Float64) {
+			fc.Name += "Floats"
+			fc.Return = t
+		}
+
+		fc.SetParent(b)
+		return fc, "std", nil
+	}
+}
+
+func pi(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) != 0 {
+		return nil, "", errors.New("pi has no arguments.")
+	}
+
+	fc := &lang.FunctionCall{
+		Name:   "float64",
+		Args:   []lang.Expression{&lang.Const{Value: "math.Pi"}},
+		Return: lang.NewTyp(lang.Float64, false),
+	}
+
+	fc.SetParent(b)
+	return fc, "math", nil
+}
+
+// pow raises ints to ints by std, the result can be
+// an int or a float, floats are raised by Go.
+func pow(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) != 2 {
+		return nil, "", errors.New("pow requires exactly two arguments.")
+	}
+
+	if args[0].Type().Equal(lang.Int) && args[1].Type().Equal(lang.Int) {
+		fc := &lang.FunctionCall{
+			Name:   "std.PowInt",
+			Args:   args,
+			Return: lang.NewTyp(lang.Anything, false),
+		}
+
+		fc.SetParent(b)
+		return fc, "std", nil
+	}
+	return stdFunction("math.Pow", lang.Float64, param{typ: lang.Float64}, param{typ: lang.Float64})(b, args)
+}
+
+// random returns a random number of the Mersenne Twister,
+// from zero or from the range.
+func random(fn string) func(lang.Block, []lang.Expression) (*lang.FunctionCall, string, error) {
+	return func(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+		switch len(args) {
+		case 0:
+			return stdFunction("std.MtRand", lang.Int)(b, args)
+		case 2:
+			return stdFunction("std."+fn+"Range", lang.Int, param{typ: lang.Int}, param{typ: lang.Int})(b, args)
+		}
+		return nil, "", errors.New("Function requires zero or two arguments.")
+	}
+}
+
+func mtSrand(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) == 0 {
+		return stdFunction("std.MtSrandRandom", lang.Void)(b, args)
+	}
+	// Only MT_RAND_MT19937 mode is supported.
+	return stdFunction("std.MtSrand", lang.Void, param{typ: lang.Int})(b, args)
+}
@@ -0,0 +1,158 @@
+package p
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lSimul/php2go/lang"
+)
+
+// String functions of PHP, they are implemented in std
+// and work with bytes, like PHP does.
+
+// param is the parameter of the std function, def is
+// the value of the omitted argument, parameters without
+// it are required.
+type param struct {
+	typ string
+	def string
+}
+
+// stdFunction calls the function of std, or other package
+// of the name, with the parameters. Omitted arguments are
+// replaced by their defaults.
+func stdFunction(name, ret string, params ...param) func(lang.Block, []lang.Expression) (*lang.FunctionCall, string, error) {
+	required := 0
+	for required < len(params) && params[required].def == "" {
+		required++
+	}
+	return func(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+		if len(args) < required || len(args) > len(params) {
+			if required == len(params) {
+				return nil, "", fmt.Errorf("Function requires %d arguments.", required)
+			}
+			return nil, "", fmt.Errorf("Function requires %d to %d arguments.", required, len(params))
+		}
+
+		vals := make([]lang.Expression, len(params))
+		for i, p := range params {
+			if i >= len(args) {
+				vals[i] = value(p.typ, p.def)
+				continue
+			}
+			v, err := convertArg(args[i], p.typ)
+			if err != nil {
+				return nil, "", fmt.Errorf("Argument %d: %v", i+1, err)
+			}
+			vals[i] = v
+		}
+
+		fc := &lang.FunctionCall{
+			Name:   name,
+			Args:   vals,
+			Return: lang.NewTyp(ret, false),
+		}
+
+		fc.SetParent(b)
+		return fc, name[:strings.Index(name, ".")], nil
+	}
+}
+
+// value creates the literal of the type.
+func value(typ, v string) lang.Expression {
+	switch typ {
+	case lang.Int:
+		return &lang.Number{Value: v}
+	case lang.Float64:
+		return &lang.Float{Value: v}
+	case lang.String:
+		return &lang.Str{Value: v}
+	}
+	return &lang.Const{Value: v}
+}
+
+// convertArg converts the argument the same way as PHP
+// in the coercive typing mode, scalars can be passed
+// as strings, ints as floats and values without static
+// types are converted by std.
+func convertArg(arg lang.Expression, typ string) (lang.Expression, error) {
+	t := arg.Type()
+	switch {
+	case t.Equal(typ), typ == lang.Anything:
+		return arg, nil
+
+	case typ == lang.String && isScalar(t.String()):
+		return &lang.FunctionCall{
+			Name:   "std.ToString",
+			Args:   []lang.Expression{arg},
+			Return: lang.NewTyp(lang.String, false),
+		}, nil
+
+	case typ == lang.Float64 && t.Equal(lang.Int):
+		return &lang.FunctionCall{
+			Name:   "float64",
+			Args:   []lang.Expression{arg},
+			Return: lang.NewTyp(lang.Float64, false),
+		}, nil
+
+	case t.Equal(lang.Anything) && scalarConversions[typ] != "":
+		return &lang.FunctionCall{
+			Name:   "std." + scalarConversions[typ],
+			Args:   []lang.Expression{arg},
+			Return: lang.NewTyp(typ, false),
+		}, nil
+	}
+	return nil, fmt.Errorf("%s cannot be used as %s.", t, typ)
+}
+
+func isScalar(t string) bool {
+	return isNumber(t) || t == lang.Bool || t == lang.Anything
+}
+
+func implode(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, "", errors.New("implode requires one or two arguments.")
+	}
+
+	// Separator can be omitted.
+	if len(args) == 1 {
+		args = []lang.Expression{&lang.Str{Value: `""`}, args[0]}
+	}
+	sep, err := convertArg(args[0], lang.String)
+	if err != nil {
+		return nil, "", fmt.Errorf("Separator: %v", err)
+	}
+	if !IsArray(args[1].Type().String()) {
+		return nil, "", errors.New("Second argument has to be an array.")
+	}
+
+	fc := &lang.FunctionCall{
+		Name:   "std.Implode",
+		Args:   []lang.Expression{sep, args[1]},
+		Return: lang.NewTyp(lang.String, false),
+	}
+
+	fc.SetParent(b)
+	return fc, "std", nil
+}
+
+func sprintf(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) < 1 {
+		return nil, "", errors.New("sprintf requires atleast one argument.")
+	}
+
+	format, err := convertArg(args[0], lang.String)
+	if err != nil {
+		return nil, "", fmt.Errorf("Format: %v", err)
+	}
+
+	fc := &lang.FunctionCall{
+		Name:   "std.Sprintf",
+		Args:   append([]lang.Expression{format}, args[1:]...),
+		Return: lang.NewTyp(lang.String, false),
+	}
+
+	fc.SetParent(b)
+	return fc, "std", nil
+}
@@ -0,0 +1,77 @@
+package p
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lSimul/php2go/lang"
+)
+
+// Date and time functions of PHP, std implements them
+// on top of Go time, so the clock can be replaced in tests.
+
+func mktime(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	if len(args) == 0 || len(args) > 6 {
+		return nil, "", errors.New("mktime requires one to six arguments.")
+	}
+
+	vals := make([]lang.Expression, len(args))
+	for i, a := range args {
+		v, err := convertArg(a, lang.Int)
+		if err != nil {
+			return nil, "", fmt.Errorf("Argument %d: %v", i+1, err)
+		}
+		vals[i] = v
+	}
+
+	fc := &lang.FunctionCall{
+		Name:   "std.Mktime",
+		Args:   vals,
+		Return: lang.NewTyp(lang.Int, false),
+	}
+
+	fc.SetParent(b)
+	return fc, "std", nil
+}
+
+// microtime returns the float with microtime(true),
+// otherwise the string "msec sec" is returned.
+func microtime(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	asNumber, err := boolFlag("microtime", args)
+	if err != nil {
+		return nil, "", err
+	}
+	if asNumber {
+		return stdFunction("std.Microtime", lang.Float64)(b, nil)
+	}
+	return stdFunction("std.MicrotimeString", lang.String)(b, nil)
+}
+
+// hrtime returns nanoseconds with hrtime(true),
+// otherwise the array of seconds and nanoseconds is returned.
+func hrtime(b lang.Block, args []lang.Expression) (*lang.FunctionCall, string, error) {
+	asNumber, err := boolFlag("hrtime", args)
+	if err != nil {
+		return nil, "", err
+	}
+	if asNumber {
+		return stdFunction("std.HrtimeNumber", lang.Int)(b, nil)
+	}
+	return stdFunction("std.Hrtime", ArrayType(lang.Int))(b, nil)
+}
+
+// boolFlag reads the optional bool argument, which changes
+// the return type. Only literals can be used, the type
+// has to be known during the translation.
+func boolFlag(fn string, args []lang.Expression) (bool, error) {
+	switch len(args) {
+	case 0:
+		return false, nil
+	case 1:
+		if c, ok := args[0].(*lang.Const); ok && (c.Value == "true" || c.Value == "false") {
+			return c.Value == "true", nil
+		}
+		return false, fmt.Errorf("Argument of %s has to be true or false.", fn)
+	}
+	return false, fmt.Errorf("%s requires at most one argument.", fn)
+}
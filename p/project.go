@@ -0,0 +1,115 @@
+package p
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/z7zmey/php-parser/node"
+	"github.com/z7zmey/php-parser/node/expr"
+	"github.com/z7zmey/php-parser/node/scalar"
+	"github.com/z7zmey/php-parser/php7"
+	"github.com/z7zmey/php-parser/walker"
+
+	"github.com/lSimul/php2go/lang"
+)
+
+// Entries returns PHP files in the directory root, which are not
+// included by any other file. They are entry points of the project,
+// index.php from the root goes first, the rest is sorted.
+func Entries(root string) ([]string, error) {
+	paths := make([]string, 0)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ".php") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	included := make(map[string]bool)
+	for _, path := range paths {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		parser := php7.NewParser(src, path)
+		parser.Parse()
+		// Files with syntax errors are reported
+		// once they are transpiled.
+		if r, ok := parser.GetRootNode().(*node.Root); ok && r != nil {
+			v := &includes{dir: filepath.Dir(path), found: included}
+			r.Walk(v)
+		}
+	}
+
+	entries := make([]string, 0)
+	for _, path := range paths {
+		if !included[filepath.Clean(path)] {
+			entries = append(entries, path)
+		}
+	}
+	index := filepath.Clean(filepath.Join(root, "index.php"))
+	sort.SliceStable(entries, func(i, j int) bool {
+		if a, b := filepath.Clean(entries[i]) == index, filepath.Clean(entries[j]) == index; a || b {
+			return a
+		}
+		return entries[i] < entries[j]
+	})
+	return entries, nil
+}
+
+// RunProject transpiles every entry point into one program. The first
+// one is run by default, the rest can be chosen the same way as files
+// included by it. Error is returned only if some file cannot be read
+// or parsed.
+func (p *parser) RunProject(entries []string, asServer bool) (*lang.GlobalContext, []Diagnostic, error) {
+	gc, _, err := p.RunFromString(entries[0], asServer)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, e := range entries[1:] {
+		if _, err := p.require(e); err != nil {
+			return nil, nil, err
+		}
+	}
+	return gc, p.diagnostics, nil
+}
+
+// includes collects files included by the plain string path,
+// other includes cannot be resolved without running the code.
+type includes struct {
+	dir   string
+	found map[string]bool
+}
+
+func (v *includes) EnterNode(w walker.Walkable) bool {
+	var path node.Node
+	switch e := w.(type) {
+	case *expr.Include:
+		path = e.Expr
+	case *expr.IncludeOnce:
+		path = e.Expr
+	case *expr.Require:
+		path = e.Expr
+	case *expr.RequireOnce:
+		path = e.Expr
+	}
+	if s, ok := path.(*scalar.String); ok {
+		v.found[filepath.Join(v.dir, strings.Trim(s.Value, `'"`))] = true
+	}
+	return true
+}
+
+func (v *includes) LeaveNode(w walker.Walkable)                  {}
+func (v *includes) EnterChildNode(key string, w walker.Walkable) {}
+func (v *includes) LeaveChildNode(key string, w walker.Walkable) {}
+func (v *includes) EnterChildList(key string, w walker.Walkable) {}
+func (v *includes) LeaveChildList(key string, w walker.Walkable) {}
@@ -89,7 +89,7 @@ func TestResult(t *testing.T) {
 			defer ref.Close()
 
 			p := NewParser(NewNameTranslator(), NewFunctionTranslator())
-			res := p.Run(parsePHP([]byte(s)), "dummy", b)
+			res, _ := p.Run(parsePHP([]byte(s)), "dummy", b)
 			fmt.Println(res.String())
 			ref.WriteString(res.String())
 
@@ -0,0 +1,179 @@
+package p
+
+import (
+	"github.com/lSimul/php2go/lang"
+	"github.com/z7zmey/php-parser/node"
+	"github.com/z7zmey/php-parser/node/expr"
+	"github.com/z7zmey/php-parser/node/expr/assign"
+	"github.com/z7zmey/php-parser/node/expr/binary"
+)
+
+// ternary lowers both "a ? b : c" and "a ?: c", the short
+// one evaluates "a" just once and returns it if it is truthy.
+func (p *fileParser) ternary(b lang.Block, e *expr.Ternary) lang.Expression {
+	if e.IfTrue != nil {
+		cond := p.truthy(b, p.operand(b, e.Condition))
+		return p.choice(cond, p.operand(b, e.IfTrue), p.operand(b, e.IfFalse))
+	}
+
+	return p.keep(p.operand(b, e.Condition), func(v lang.Expression) lang.Expression {
+		return p.truthy(b, v)
+	}, func() lang.Expression {
+		return p.operand(b, e.IfFalse)
+	})
+}
+
+// keep evaluates the value just once and returns it,
+// if it passes the condition. The other value is
+// returned otherwise.
+func (p *fileParser) keep(value lang.Expression, cond func(lang.Expression) lang.Expression, other func() lang.Expression) *lang.Ternary {
+	v := lang.NewVariable("v", value.Type(), false)
+	t := p.choice(cond(lang.NewVarRef(v, v.Type())), lang.NewVarRef(v, v.Type()), other())
+	t.V = v
+	t.Value = value
+	value.SetParent(t)
+	return t
+}
+
+// coalesce returns the left side if it is set. Array items
+// can be missing and values without static types can be null,
+// typed Go variables and properties cannot be null.
+func (p *fileParser) coalesce(b lang.Block, e *binary.Coalesce) lang.Expression {
+	switch l := e.Left.(type) {
+	case *expr.Variable:
+		if b.HasVariable(p.identifierName(l), true) == nil {
+			return p.operand(b, e.Right)
+		}
+
+	case *expr.ArrayDimFetch:
+		cond := p.isset(b, l)
+		return p.choice(cond, p.operand(b, l), p.operand(b, e.Right))
+
+	case *expr.PropertyFetch:
+		p.notNullable(b, l)
+	}
+
+	left := p.operand(b, e.Left)
+	if !left.Type().Equal(lang.Anything) {
+		return left
+	}
+	return p.keep(left, notNull, func() lang.Expression {
+		return p.operand(b, e.Right)
+	})
+}
+
+// coalesceAssign assigns the value only if the variable
+// is not set yet, see coalesce.
+func (p *fileParser) coalesceAssign(b lang.Block, a *assign.Coalesce) lang.Node {
+	as := &assign.Assign{
+		Position:   a.Position,
+		Variable:   a.Variable,
+		Expression: a.Expression,
+	}
+	nif := lang.NewIf(b)
+	nif.True = lang.NewCode(nif)
+	var cond lang.Expression
+	switch v := a.Variable.(type) {
+	case *expr.Variable:
+		if b.HasVariable(p.identifierName(v), true) == nil {
+			return p.complexExpression(b, as)
+		}
+		left := p.operand(b, v)
+		if !left.Type().Equal(lang.Anything) {
+			// Blank const, variable is already set.
+			return &lang.Const{}
+		}
+		cond = isNull(left)
+		// The variable keeps interface{}, the value
+		// does not change its type.
+		r, err := lang.NewAssign(left.(*lang.VarRef).V, p.operand(nif.True, a.Expression))
+		if err != nil {
+			panic(err)
+		}
+		nif.True.AddStatement(r)
+
+	case *expr.ArrayDimFetch:
+		cond = &lang.Negation{Right: p.isset(nif, v)}
+		cond.(*lang.Negation).Right.SetParent(cond)
+		nif.True.AddStatement(p.complexExpression(nif.True, as))
+
+	case *expr.PropertyFetch:
+		p.notNullable(b, v)
+		left := p.operand(b, v)
+		if !left.Type().Equal(lang.Anything) {
+			// Blank const, property is already set.
+			return &lang.Const{}
+		}
+		cond = isNull(left)
+		nif.True.AddStatement(p.complexExpression(nif.True, as))
+
+	default:
+		p.fail(a.Variable, UnsupportedExpression, "Unexpected left side %T.", a.Variable)
+	}
+
+	if err := nif.SetCond(cond); err != nil {
+		panic(err)
+	}
+	return nif
+}
+
+// notNullable warns about typed properties, PHP treats
+// them as unset until they are initialized, Go has zero
+// values in them.
+func (p *fileParser) notNullable(b lang.Block, e *expr.PropertyFetch) {
+	_, f, _ := p.propertyFetch(b, e)
+	if !f.Type().Equal(lang.Anything) {
+		p.record(e, Warning, UnsupportedExpression, "Property of type %s is never null, uninitialized properties have zero values.", f.Type())
+	}
+}
+
+// isNull compares the value without a static type with nil.
+func isNull(e lang.Expression) lang.Expression {
+	c, err := lang.NewBinaryOp("==", e, &lang.Const{Value: "nil"})
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func notNull(e lang.Expression) lang.Expression {
+	c, err := lang.NewBinaryOp("!=", e, &lang.Const{Value: "nil"})
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// operand translates the expression used as a value,
+// assignments and increments are statements in Go.
+func (p *fileParser) operand(b lang.Block, n node.Node) lang.Expression {
+	e := p.expression(b, n)
+	if e == nil {
+		p.fail(n, UnsupportedExpression, "%T cannot be used as a value.", n)
+	}
+	return e
+}
+
+// choice creates the ternary, branches are converted
+// to the same type when possible.
+func (p *fileParser) choice(cond, t, f lang.Expression) *lang.Ternary {
+	t, f, c := convertToMatchingType(t, f)
+	if c {
+		p.funcs.Namespace("std")
+	}
+	return lang.NewTernary(cond, t, f)
+}
+
+// truthy converts the expression to bool, it can
+// be used as a condition then.
+func (p *fileParser) truthy(b lang.Block, e lang.Expression) lang.Expression {
+	if e.Type().Equal(lang.Bool) {
+		return e
+	}
+	t, err := p.funcs.Namespace("std").Call("Truthy", []lang.Expression{e})
+	if err != nil {
+		panic(err)
+	}
+	t.SetParent(b)
+	return t
+}
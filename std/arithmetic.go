@@ -0,0 +1,174 @@
+package std
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/lSimul/php2go/std/array"
+)
+
+// errModuloByZero is passed to panic by Mod,
+// as PHP throws DivisionByZeroError.
+var errModuloByZero = errors.New("Modulo by zero")
+
+// AddInt adds ints, the result is a float
+// when it does not fit into int, like in PHP.
+func AddInt(a, b int) interface{} {
+	s := a + b
+	if (s > a) == (b > 0) {
+		return s
+	}
+	return float64(a) + float64(b)
+}
+
+// SubInt subtracts ints, the result is a float
+// when it does not fit into int, like in PHP.
+func SubInt(a, b int) interface{} {
+	d := a - b
+	if (d < a) == (b > 0) {
+		return d
+	}
+	return float64(a) - float64(b)
+}
+
+// MulInt multiplies ints, the result is a float
+// when it does not fit into int, like in PHP.
+func MulInt(a, b int) interface{} {
+	if r, ok := mulInt(a, b); ok {
+		return r
+	}
+	return float64(a) * float64(b)
+}
+
+// DivInt divides ints, the result is an int only when
+// the division is exact, it is a float otherwise.
+// Division by zero is passed to panic.
+func DivInt(a, b int) interface{} {
+	switch {
+	case b == 0:
+		panic(errDivisionByZero)
+	case a == math.MinInt64 && b == -1:
+		return -float64(a)
+	case a%b == 0:
+		return a / b
+	}
+	return float64(a) / float64(b)
+}
+
+// Add adds values of any type, they are converted to
+// numbers like in PHP. It is used when types of the operands
+// are not known, see AddInt for ints.
+func Add(a, b interface{}) interface{} {
+	return arithmetic("+", a, b)
+}
+
+// Sub subtracts values of any type, like Add.
+func Sub(a, b interface{}) interface{} {
+	return arithmetic("-", a, b)
+}
+
+// Mul multiplies values of any type, like Add.
+func Mul(a, b interface{}) interface{} {
+	return arithmetic("*", a, b)
+}
+
+// Div divides values of any type, like Add.
+// Division by zero is passed to panic.
+func Div(a, b interface{}) interface{} {
+	return arithmetic("/", a, b)
+}
+
+// Mod returns the remainder of values converted to ints,
+// the sign is taken from the dividend, like in PHP.
+// Modulo by zero is passed to panic.
+func Mod(a, b interface{}) int {
+	x, y := ToInt(operand("%", a, b, a)), ToInt(operand("%", a, b, b))
+	if y == 0 {
+		panic(errModuloByZero)
+	}
+	return x % y
+}
+
+func arithmetic(op string, a, b interface{}) interface{} {
+	x := toOperand(operand(op, a, b, a))
+	y := toOperand(operand(op, a, b, b))
+	if !x.isFloat && !y.isFloat {
+		switch op {
+		case "+":
+			return AddInt(x.i, y.i)
+		case "-":
+			return SubInt(x.i, y.i)
+		case "*":
+			return MulInt(x.i, y.i)
+		case "/":
+			return DivInt(x.i, y.i)
+		}
+	}
+	f, g := x.float(), y.float()
+	switch op {
+	case "+":
+		return f + g
+	case "-":
+		return f - g
+	case "*":
+		return f * g
+	}
+	if g == 0 {
+		panic(errDivisionByZero)
+	}
+	return f / g
+}
+
+// operand checks the value of the arithmetic operation.
+// Arrays cannot be used, TypeError is passed to panic
+// like in PHP. Strings, which are not numeric, are reported.
+func operand(op string, a, b, v interface{}) interface{} {
+	switch s := v.(type) {
+	case array.Reader:
+		panic(fmt.Errorf("Unsupported operand types: %s %s %s", typeName(a), op, typeName(b)))
+	case string:
+		if _, ok := numericString(s); !ok {
+			Report(Warning, "A non-numeric value encountered")
+		}
+	}
+	return v
+}
+
+// toOperand converts the checked operand to the number.
+func toOperand(v interface{}) number {
+	switch v := v.(type) {
+	case string:
+		n, _ := numericPrefix(v)
+		return n
+	case float64:
+		return number{f: v, isFloat: true}
+	}
+	return number{i: ToInt(v)}
+}
+
+func (n number) float() float64 {
+	if n.isFloat {
+		return n.f
+	}
+	return float64(n.i)
+}
+
+// typeName returns the name of the type used by PHP errors.
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case int:
+		return "int"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case array.Reader:
+		return "array"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,94 @@
+package std
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lSimul/php2go/std/array"
+)
+
+func TestIntArithmetic(t *testing.T) {
+	// Results out of the int range are floats in PHP, so is
+	// the division, which is not exact.
+	cases := []struct {
+		op   string
+		a, b int
+		out  interface{}
+	}{
+		{"+", 2, 3, 5},
+		{"+", math.MaxInt64, 1, 9.223372036854775808e18},
+		{"+", math.MinInt64, -1, -9.223372036854775808e18},
+		{"+", math.MaxInt64, math.MinInt64, -1},
+		{"-", 2, 3, -1},
+		{"-", math.MinInt64, 1, -9.223372036854775808e18},
+		{"-", 0, math.MinInt64, 9.223372036854775808e18},
+		{"*", -4, 5, -20},
+		{"*", math.MaxInt64, 2, 1.8446744073709552e19},
+		{"*", math.MinInt64, -1, 9.223372036854775808e18},
+		{"/", 6, 3, 2},
+		{"/", 7, 2, 3.5},
+		{"/", -1, 4, -0.25},
+		{"/", math.MinInt64, -1, 9.223372036854775808e18},
+	}
+	funcs := map[string]func(a, b int) interface{}{
+		"+": AddInt,
+		"-": SubInt,
+		"*": MulInt,
+		"/": DivInt,
+	}
+	for _, c := range cases {
+		if out := funcs[c.op](c.a, c.b); out != c.out {
+			t.Errorf("%d %s %d: %v expected, %v found.", c.a, c.op, c.b, c.out, out)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	var msgs []string
+	prev := SetHandler(HandlerFunc(func(l Level, msg string) {
+		msgs = append(msgs, msg)
+	}))
+	defer SetHandler(prev)
+
+	cases := []struct {
+		out, expected interface{}
+		warnings      int
+	}{
+		{Add("3", 4), 7, 0},
+		{Add("1.5", 1), 2.5, 0},
+		{Add(" 2 ", true), 3, 0},
+		{Sub(nil, 2), -2, 0},
+		{Mul(2.5, 2), 5.0, 0},
+		{Div("9", 3), 3, 0},
+		{Div(1.0, 4), 0.25, 0},
+		{Add(interface{}(math.MaxInt64), 1), 9.223372036854775808e18, 0},
+		{Add("12abc", 1), 13, 1},
+		{Mul("abc", 2), 0, 1},
+		{Mod(-7, 3), -1, 0},
+		{Mod("7.9", 2.5), 1, 0},
+	}
+	for i, c := range cases {
+		if c.out != c.expected {
+			t.Errorf("Case %d: %v expected, %v found.", i, c.expected, c.out)
+		}
+	}
+	if len(msgs) != 2 {
+		t.Errorf("Non-numeric strings should be reported, %v found.", msgs)
+	}
+
+	for _, f := range []func(){
+		func() { DivInt(1, 0) },
+		func() { Div(1.5, 0) },
+		func() { Mod(1, "0") },
+		func() { Add(array.NewInt(1), 1) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("Invalid operation should panic.")
+				}
+			}()
+			f()
+		}()
+	}
+}
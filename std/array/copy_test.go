@@ -0,0 +1,133 @@
+package array
+
+import "testing"
+
+func TestCopy(t *testing.T) {
+	// $a = [1, 2]; $b = $a; $b[] = 3;
+	a := NewInt(1, 2)
+	b := a.Copy()
+	b.Add(3)
+	if a.Count() != 2 || b.Count() != 3 {
+		t.Errorf("Copy should not share edits, %d and %d items found.", a.Count(), b.Count())
+	}
+
+	// $c = $a; $a[0] = 5;
+	c := a.Copy()
+	a.Edit(NewScalar(0), 5)
+	if c.At(NewScalar(0)) != 1 || a.At(NewScalar(0)) != 5 {
+		t.Errorf("Original should not share edits, %d and %d found.", c.At(NewScalar(0)), a.At(NewScalar(0)))
+	}
+
+	// $d = $a; unset($d[1]);
+	d := a.Copy()
+	d.Unset(NewScalar(1))
+	if !a.Isset(NewScalar(1)) || d.Isset(NewScalar(1)) {
+		t.Error("Unset should remove the item only from the copy.")
+	}
+
+	// $e = $a; $e["k"] = 1;
+	e := a.Copy().With(NewScalar("k"), 1)
+	if a.Isset(NewScalar("k")) || !e.Isset(NewScalar("k")) {
+		t.Error("With should not change the copied array.")
+	}
+}
+
+func TestCopyEmpty(t *testing.T) {
+	var a String
+	b := a.Copy()
+	b.Add("x")
+	if a.Count() != 0 || b.Count() != 1 {
+		t.Errorf("Empty arrays should not share edits, %d and %d items found.", a.Count(), b.Count())
+	}
+}
+
+func TestIterCopy(t *testing.T) {
+	// foreach ($a as $v) { $a[] = $v; }
+	a := NewInt(1, 2, 3)
+	n := 0
+	for _, v := range a.Iter() {
+		a.Add(v)
+		n++
+	}
+	if n != 3 || a.Count() != 6 {
+		t.Errorf("Loop should iterate the original items, %d iterations and %d items found.", n, a.Count())
+	}
+	if a.d.refs != 0 {
+		t.Errorf("Iter should not hold the array, %d refs found.", a.d.refs)
+	}
+
+	// foreach ($b as $k => $v) { $b[$k] = 0; }
+	b := NewString("x", "y")
+	for _, p := range b.KeyIter() {
+		b.Edit(p.K, "")
+		if p.V == "" {
+			t.Errorf("Item %s should keep its value during the loop.", p.K)
+		}
+	}
+}
+
+func TestRangeRelease(t *testing.T) {
+	// for ($i = 0; $i < 3; $i++) { foreach ($a as $v) {} $a[] = $i; }
+	a := NewInt(1)
+	d := a.d
+	for i := 0; i < 3; i++ {
+		for range a.Range() {
+		}
+		a.Release()
+		a.Add(i)
+	}
+	if a.d != d || a.d.refs != 0 {
+		t.Errorf("Released loops should not clone the array, %d refs left.", a.d.refs)
+	}
+	if a.Count() != 4 {
+		t.Errorf("4 items expected, %d found.", a.Count())
+	}
+
+	// foreach ($b as $v) { $c = $b; $b[] = $v; }
+	b := NewInt(1, 2)
+	var c Int
+	n := 0
+	for _, v := range b.Range() {
+		c = b.Copy()
+		b.Add(v)
+		n++
+	}
+	b.Release()
+	if n != 2 || b.Count() != 4 || c.Count() != 3 {
+		t.Errorf("Loop should iterate the original items, %d iterations, %d and %d items found.", n, b.Count(), c.Count())
+	}
+	c.Add(0)
+	if b.Count() != 4 {
+		t.Errorf("Release should not drop references of copies, %d items found.", b.Count())
+	}
+}
+
+func TestReference(t *testing.T) {
+	// $r = &$a; $r[] = 3;
+	a := NewInt(1, 2)
+	r := &a
+	r.Add(3)
+	if a.Count() != 3 {
+		t.Errorf("Reference should share the array, %d items found.", a.Count())
+	}
+
+	// $b = $a; $r[] = 4;
+	b := a.Copy()
+	r.Add(4)
+	if a.Count() != 4 || b.Count() != 3 {
+		t.Errorf("Reference should not change the copy, %d and %d items found.", a.Count(), b.Count())
+	}
+}
+
+func TestAny(t *testing.T) {
+	// function f(array $a) {} f(["a" => 1, 2]);
+	a := NewInt().With(NewScalar("a"), 1).With(NewScalar(0), 2)
+	b := a.Any()
+	b.Add(3)
+	if a.Count() != 2 || b.Count() != 3 {
+		t.Errorf("Converted array should not share the items, %d and %d items found.", a.Count(), b.Count())
+	}
+	if v := b.At(NewScalar("a")); v != 1 {
+		t.Errorf("Keys should be kept, %v found.", v)
+	}
+}
@@ -0,0 +1,131 @@
+package array
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// entries formats the array like "0=1 k=2".
+func entries(a Reader) string {
+	keys, vals := a.Entries()
+	s := make([]string, len(keys))
+	for i, k := range keys {
+		s[i] = fmt.Sprintf("%s=%v", k, vals[i])
+	}
+	return strings.Join(s, " ")
+}
+
+func TestBounds(t *testing.T) {
+	cases := []struct {
+		n, offset, length int
+		from, to          int
+	}{
+		{5, 1, 2, 1, 3},
+		{5, -2, 5, 3, 5},
+		{5, 1, -1, 1, 4},
+		{5, 7, 1, 5, 5},
+		{5, -7, 2, 0, 2},
+		{5, 3, -4, 3, 3},
+	}
+	for _, c := range cases {
+		from, to := Bounds(c.n, c.offset, c.length)
+		if from != c.from || to != c.to {
+			t.Errorf("Bounds(%d, %d, %d): %d, %d expected, %d, %d found.", c.n, c.offset, c.length, c.from, c.to, from, to)
+		}
+	}
+}
+
+func TestFunctional(t *testing.T) {
+	a := NewInt(1, 2, 3, 4)
+	a.Unset(NewScalar(0))
+
+	if e := entries(a.Filter(func(v int) bool { return v%2 == 0 })); e != "1=2 3=4" {
+		t.Errorf("Filter should keep keys, %s found.", e)
+	}
+	if e := entries(a.MapString(func(v int) string { return fmt.Sprint(v * v) })); e != "1=4 2=9 3=16" {
+		t.Errorf("Map should keep keys, %s found.", e)
+	}
+	if s := a.ReduceInt(func(c, v int) int { return c + v }, 1); s != 10 {
+		t.Errorf("Reduce should start with the initial value, %d found.", s)
+	}
+	if e := entries(a.Values()); e != "0=2 1=3 2=4" {
+		t.Errorf("Values should renumber keys, %s found.", e)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a := NewString().With(NewScalar("a"), "x").With(NewScalar(5), "y")
+	b := NewString().With(NewScalar("a"), "z").With(NewScalar(1), "w")
+	if e := entries(a.Merge(b, NewString("v"))); e != "a=z 0=y 1=w 2=v" {
+		t.Errorf("Merge should renumber int keys and replace string keys, %s found.", e)
+	}
+	if e := entries(a); e != "a=x 5=y" {
+		t.Errorf("Merge should not change the array, %s found.", e)
+	}
+}
+
+func TestSlice(t *testing.T) {
+	a := NewInt(1, 2, 3, 4, 5)
+	if e := entries(a.Slice(1, 2, false)); e != "0=2 1=3" {
+		t.Errorf("Slice should renumber keys, %s found.", e)
+	}
+	if e := entries(a.Slice(-2, 1, true)); e != "3=4" {
+		t.Errorf("Slice should preserve keys, %s found.", e)
+	}
+
+	removed := a.Splice(1, 2, NewInt(7, 8, 9))
+	if e := entries(a); e != "0=1 1=7 2=8 3=9 4=4 5=5" {
+		t.Errorf("Splice should replace the items, %s found.", e)
+	}
+	if e := entries(removed); e != "0=2 1=3" {
+		t.Errorf("Splice should return removed items, %s found.", e)
+	}
+
+	b := a.Copy()
+	a.Splice(-1, 1, NewInt())
+	if a.Count() != 5 || b.Count() != 6 {
+		t.Errorf("Splice should not change copies, %d and %d items found.", a.Count(), b.Count())
+	}
+}
+
+func TestEnds(t *testing.T) {
+	a := NewInt(1, 2, 3)
+	a.Edit(NewScalar("k"), 4)
+	if v := a.Pop(); v != 4 {
+		t.Errorf("Pop should return the last item, %d found.", v)
+	}
+	if v := a.Shift(); v != 1 || entries(a) != "0=2 1=3" {
+		t.Errorf("Shift should return the first item and renumber keys, %d and %s found.", v, entries(a))
+	}
+	if n := a.Unshift(7, 8); n != 4 || entries(a) != "0=7 1=8 2=2 3=3" {
+		t.Errorf("Unshift should prepend the items, %d and %s found.", n, entries(a))
+	}
+	a.Add(5)
+	if e := entries(a); e != "0=7 1=8 2=2 3=3 4=5" {
+		t.Errorf("Next index should follow renumbered keys, %s found.", e)
+	}
+
+	var e Int
+	if v := e.Pop(); v != 0 {
+		t.Errorf("Pop of empty array should return zero value, %d found.", v)
+	}
+}
+
+func TestCombine(t *testing.T) {
+	a := NewInt(1, 2).Combine(NewString("x", "y"))
+	if e := entries(a); e != "x=1 y=2" {
+		t.Errorf("Combine should use values as keys, %s found.", e)
+	}
+	u := NewInt(3, 1, 3, 2, 1).Unique(func(v interface{}) string { return fmt.Sprint(v) })
+	if e := entries(u); e != "0=3 1=1 3=2" {
+		t.Errorf("Unique should keep first items, %s found.", e)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Combine of different sizes should panic.")
+		}
+	}()
+	NewInt(1).Combine(NewString())
+}
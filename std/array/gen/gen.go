@@ -0,0 +1,587 @@
+// Package gen generates typed PHP arrays. Arrays of the basic
+// types are generated into the package array by the array.go
+// script, arrays of structs are generated next to the structs.
+package gen
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+)
+
+// Array returns declarations of the array type name holding values
+// of the type typ. Helpers of the package array are qualified
+// by pkg, it is empty for arrays declared in the package itself.
+func Array(name, typ, pkg string) string {
+	return execute(name, typ, pkg, "")
+}
+
+// NestedArray returns declarations of the array of arrays, the nested
+// arrays are created by the function constructor when they are edited.
+func NestedArray(name, typ, pkg, constructor string) string {
+	return execute(name, typ, pkg, constructor)
+}
+
+// basic are arrays, which can be results of Map and Reduce.
+var basic = []struct {
+	Name, Type string
+}{
+	{"Int", "int"},
+	{"String", "string"},
+	{"Float64", "float64"},
+	{"Bool", "bool"},
+	{"Any", "interface{}"},
+}
+
+func execute(name, typ, pkg, constructor string) string {
+	var b bytes.Buffer
+	err := array.Execute(&b, struct {
+		Name, Data, Type, Pkg, New string
+		Basic                      interface{}
+	}{name, strings.ToLower(name[:1]) + name[1:] + "Data", typ, pkg, constructor, basic})
+	if err != nil {
+		panic(err)
+	}
+	return b.String()
+}
+
+// Arrays are values in PHP, assigned arrays share the data until
+// one of them is edited, the data is cloned then. Arrays sharing
+// the data are counted by refs, the count is not decreased when
+// they are dropped, so the data can be cloned needlessly. Loops
+// started by Range are counted too, Release drops their refs.
+//
+// Items are kept in the insertion order by keys and vals, index
+// points to them. Unset items leave holes there, the item is a hole
+// when index does not point to it. Holes are removed once they make
+// up the half of the array or when the array is iterated, removing
+// them does not change the array, so it is done in shared data too.
+var array = template.Must(template.New("array").Parse(`
+var _ {{.Pkg}}Array = (*{{.Name}})(nil)
+
+type {{.Name}} struct {
+	d *{{.Data}}
+	// iters counts loops over d, which were not released.
+	iters int
+}
+
+type {{.Data}} struct {
+	index     map[{{.Pkg}}Scalar]int
+	keys      []{{.Pkg}}Scalar
+	vals      []{{.Type}}
+	holes     int
+	lastIndex int
+	refs      int
+}
+
+func New{{.Name}}(vals ...{{.Type}}) {{.Name}} {
+	a := {{.Name}}{}
+	a.Add(vals...)
+	return a
+}
+
+func (a {{.Name}}) Copy() {{.Name}} {
+	if a.d != nil {
+		a.d.refs++
+	}
+	a.iters = 0
+	return a
+}
+
+func (a *{{.Name}}) edit() *{{.Data}} {
+	if a.d == nil {
+		a.d = &{{.Data}}{
+			index: make(map[{{.Pkg}}Scalar]int),
+		}
+	} else if a.d.refs > 0 {
+		a.d.refs--
+		a.d.compact()
+		d := &{{.Data}}{
+			index:     make(map[{{.Pkg}}Scalar]int, len(a.d.index)),
+			keys:      make([]{{.Pkg}}Scalar, len(a.d.keys)),
+			vals:      make([]{{.Type}}, len(a.d.vals)),
+			lastIndex: a.d.lastIndex,
+		}
+		for k, i := range a.d.index {
+			d.index[k] = i
+		}
+		copy(d.keys, a.d.keys)
+		{{- if .New}}
+		for i, v := range a.d.vals {
+			d.vals[i] = v.Copy()
+		}
+		{{- else}}
+		copy(d.vals, a.d.vals)
+		{{- end}}
+		a.d = d
+		// Running loops keep the old data.
+		a.iters = 0
+	}
+	return a.d
+}
+
+// compact removes holes left by unset items.
+func (d *{{.Data}}) compact() {
+	if d.holes == 0 {
+		return
+	}
+	keys := make([]{{.Pkg}}Scalar, 0, len(d.index))
+	vals := make([]{{.Type}}, 0, len(d.index))
+	for i, k := range d.keys {
+		if j, ok := d.index[k]; !ok || j != i {
+			continue
+		}
+		d.index[k] = len(keys)
+		keys = append(keys, k)
+		vals = append(vals, d.vals[i])
+	}
+	d.keys, d.vals, d.holes = keys, vals, 0
+}
+
+func (a *{{.Name}}) Add(vals ...{{.Type}}) *{{.Name}} {
+	d := a.edit()
+	for _, v := range vals {
+		k := {{.Pkg}}NewScalar(d.lastIndex)
+		d.add(k, v)
+		d.lastIndex++
+	}
+	return a
+}
+
+func (a *{{.Name}}) Push(vals ...{{.Type}}) int {
+	a.Add(vals...)
+	return len(a.d.index)
+}
+
+func (a *{{.Name}}) Edit(k {{.Pkg}}Scalar, v {{.Type}}) *{{.Name}} {
+	d := a.edit()
+	if i, ok := d.index[k]; ok {
+		d.vals[i] = v
+	} else if i, ok := k.IntValue(); ok && i >= d.lastIndex {
+		d.lastIndex = i
+		a.Add(v)
+	} else {
+		d.add(k, v)
+	}
+	return a
+}
+
+func (a {{.Name}}) With(k {{.Pkg}}Scalar, v {{.Type}}) {{.Name}} {
+	a.Edit(k, v)
+	return a
+}
+
+func (d *{{.Data}}) add(k {{.Pkg}}Scalar, v {{.Type}}) {
+	d.index[k] = len(d.keys)
+	d.keys = append(d.keys, k)
+	d.vals = append(d.vals, v)
+}
+
+func (a {{.Name}}) At(k {{.Pkg}}Scalar) {{.Type}} {
+	if a.d != nil {
+		if i, ok := a.d.index[k]; ok {
+			return a.d.vals[i]
+		}
+	}
+	{{.Pkg}}Notice("Undefined index: " + string(k))
+	var v {{.Type}}
+	return v
+}
+
+// Iter returns values for loops over arrays, which are not held
+// by variables, like items of other arrays. The values are copied,
+// so editing the array in the loop does not change them.
+func (a {{.Name}}) Iter() []{{.Type}} {
+	if a.d == nil {
+		return nil
+	}
+	a.d.compact()
+	vals := make([]{{.Type}}, len(a.d.vals))
+{{- if .New}}
+	for i, v := range a.d.vals {
+		vals[i] = v.Copy()
+	}
+{{- else}}
+	copy(vals, a.d.vals)
+{{- end}}
+	return vals
+}
+
+// Range is Iter of the variable, values are not copied. The loop
+// holds the array instead, editing it clones the array. Release
+// has to be called when the loop ends, so the next edit does not.
+func (a *{{.Name}}) Range() []{{.Type}} {
+	if a.d == nil {
+		return nil
+	}
+	a.d.compact()
+	a.iters++
+	a.d.refs++
+{{- if .New}}
+	for _, v := range a.d.vals {
+		v.Copy()
+	}
+{{- end}}
+	return a.d.vals
+}
+
+// Release drops the reference of the loop started by Range.
+// Loops left by return are not released, the array is only
+// cloned needlessly then. Items of nested arrays keep their
+// references, the loop could edit them.
+func (a *{{.Name}}) Release() {
+	if a.iters == 0 {
+		return
+	}
+	a.iters--
+	if a.d.refs > 0 {
+		a.d.refs--
+	}
+}
+
+type {{.Name}}Pair struct {
+	K {{.Pkg}}Scalar
+	V {{.Type}}
+}
+
+func (a {{.Name}}) KeyIter() []{{.Name}}Pair {
+	if a.d == nil {
+		return nil
+	}
+	a.d.compact()
+	res := make([]{{.Name}}Pair, len(a.d.vals))
+	for i, v := range a.d.vals {
+		res[i] = {{.Name}}Pair{a.d.keys[i], v{{if .New}}.Copy(){{end}}}
+	}
+	return res
+}
+
+func (a {{.Name}}) Isset(k {{.Pkg}}Scalar) bool {
+	if a.d == nil {
+		return false
+	}
+	_, ok := a.d.index[k]
+	return ok
+}
+
+func (a *{{.Name}}) Unset(k {{.Pkg}}Scalar) {
+	if !a.Isset(k) {
+		return
+	}
+	d := a.edit()
+	i := d.index[k]
+	delete(d.index, k)
+
+	// Values of holes are dropped.
+	var v {{.Type}}
+	d.vals[i] = v
+	d.holes++
+	if d.holes > len(d.index) {
+		d.compact()
+	}
+}
+
+func (a {{.Name}}) Count() int {
+	if a.d == nil {
+		return 0
+	}
+	return len(a.d.index)
+}
+
+func (a {{.Name}}) Entries() ([]string, []interface{}) {
+	if a.d == nil {
+		return []string{}, []interface{}{}
+	}
+	a.d.compact()
+	keys := make([]string, len(a.d.keys))
+	vals := make([]interface{}, len(a.d.vals))
+	for i, k := range a.d.keys {
+		keys[i] = string(k)
+		vals[i] = a.d.vals[i]
+	}
+	return keys, vals
+}
+
+// Sort orders the items by cmp, it compares the values, or the keys
+// when byKey is set. Keys are kept when keep is set, the items
+// are indexed from zero otherwise.
+func (a *{{.Name}}) Sort(cmp func(x, y interface{}) int, byKey, keep bool) {
+	d := a.edit()
+	d.compact()
+	if byKey {
+		d.sort(func(i, j int) bool { return cmp(d.keys[i].Value(), d.keys[j].Value()) < 0 }, keep)
+	} else {
+		d.sort(func(i, j int) bool { return cmp(d.vals[i], d.vals[j]) < 0 }, keep)
+	}
+}
+
+// USort orders the values by cmp, like Sort.
+func (a *{{.Name}}) USort(cmp func(x, y {{.Type}}) int, keep bool) {
+	d := a.edit()
+	d.compact()
+	d.sort(func(i, j int) bool { return cmp(d.vals[i], d.vals[j]) < 0 }, keep)
+}
+
+// UKSort orders the keys by cmp, the keys are kept.
+func (a *{{.Name}}) UKSort(cmp func(x, y string) int) {
+	d := a.edit()
+	d.compact()
+	d.sort(func(i, j int) bool { return cmp(string(d.keys[i]), string(d.keys[j])) < 0 }, true)
+}
+
+func (d *{{.Data}}) sort(less func(i, j int) bool, keep bool) {
+	order := {{.Pkg}}Order(len(d.vals), less)
+	keys := make([]{{.Pkg}}Scalar, len(order))
+	vals := make([]{{.Type}}, len(order))
+	for i, o := range order {
+		keys[i], vals[i] = d.keys[o], d.vals[o]
+	}
+	if !keep {
+		for i := range keys {
+			keys[i] = {{.Pkg}}NewScalar(i)
+		}
+		d.lastIndex = len(keys)
+		d.index = make(map[{{.Pkg}}Scalar]int, len(keys))
+	}
+	for i, k := range keys {
+		d.index[k] = i
+	}
+	d.keys, d.vals = keys, vals
+}
+
+// renumber numbers int keys from zero, string keys are kept.
+func (d *{{.Data}}) renumber() {
+	d.index = make(map[{{.Pkg}}Scalar]int, len(d.keys))
+	d.lastIndex = 0
+	for i, k := range d.keys {
+		if _, ok := k.IntValue(); ok {
+			k = {{.Pkg}}NewScalar(d.lastIndex)
+			d.keys[i] = k
+			d.lastIndex++
+		}
+		d.index[k] = i
+	}
+}
+
+// Filter returns items, for which keep returns true, keys are kept.
+func (a {{.Name}}) Filter(keep func({{.Type}}) bool) {{.Name}} {
+	res := {{.Name}}{}
+	for _, p := range a.KeyIter() {
+		if keep(p.V) {
+			res.Edit(p.K, p.V)
+		}
+	}
+	return res
+}
+{{- if ne .Name "Any"}}
+
+// Any converts the array to {{.Pkg}}Any, keys are kept.
+func (a {{.Name}}) Any() {{.Pkg}}Any {
+	res := {{.Pkg}}Any{}
+	for _, p := range a.KeyIter() {
+		res.Edit(p.K, p.V)
+	}
+	return res
+}
+{{- end}}
+{{range .Basic}}
+// Map{{.Name}} returns results of fn for every item, keys are kept.
+func (a {{$.Name}}) Map{{.Name}}(fn func({{$.Type}}) {{.Type}}) {{$.Pkg}}{{.Name}} {
+	res := {{$.Pkg}}{{.Name}}{}
+	for _, p := range a.KeyIter() {
+		res.Edit(p.K, fn(p.V))
+	}
+	return res
+}
+
+// Reduce{{.Name}} passes the result of fn for the previous item
+// to fn with the next item, initial is passed with the first one.
+func (a {{$.Name}}) Reduce{{.Name}}(fn func({{.Type}}, {{$.Type}}) {{.Type}}, initial {{.Type}}) {{.Type}} {
+	carry := initial
+	for _, v := range a.Range() {
+		carry = fn(carry, v)
+	}
+	a.Release()
+	return carry
+}
+{{end}}
+// Values returns the items indexed from zero.
+func (a {{.Name}}) Values() {{.Name}} {
+	res := {{.Name}}{}
+	if a.d == nil {
+		return res
+	}
+	a.d.compact()
+	for _, v := range a.d.vals {
+		res.Add(v{{if .New}}.Copy(){{end}})
+	}
+	return res
+}
+
+// Merge appends items of the arrays, int keys are renumbered,
+// items with string keys replace the ones with the same key.
+func (a {{.Name}}) Merge(arrays ...{{.Name}}) {{.Name}} {
+	res := {{.Name}}{}
+	for _, arr := range append([]{{.Name}}{a}, arrays...) {
+		if arr.d == nil {
+			continue
+		}
+		arr.d.compact()
+		for i, k := range arr.d.keys {
+			v := arr.d.vals[i]{{if .New}}.Copy(){{end}}
+			if _, ok := k.IntValue(); ok {
+				res.Add(v)
+			} else {
+				res.Edit(k, v)
+			}
+		}
+	}
+	return res
+}
+
+// Slice returns length items from the offset, see Bounds. Int
+// keys are renumbered unless preserve is set.
+func (a {{.Name}}) Slice(offset, length int, preserve bool) {{.Name}} {
+	res := {{.Name}}{}
+	if a.d == nil {
+		return res
+	}
+	a.d.compact()
+	from, to := {{.Pkg}}Bounds(len(a.d.vals), offset, length)
+	for i := from; i < to; i++ {
+		k, v := a.d.keys[i], a.d.vals[i]{{if .New}}.Copy(){{end}}
+		if _, ok := k.IntValue(); ok && !preserve {
+			res.Add(v)
+		} else {
+			res.Edit(k, v)
+		}
+	}
+	return res
+}
+
+// Splice replaces length items from the offset, see Bounds, by
+// items of repl. Removed items are returned. Int keys are
+// renumbered, keys of repl are not kept.
+func (a *{{.Name}}) Splice(offset, length int, repl {{.Name}}) {{.Name}} {
+	d := a.edit()
+	d.compact()
+	from, to := {{.Pkg}}Bounds(len(d.vals), offset, length)
+	removed := New{{.Name}}(d.vals[from:to]...)
+	add := repl.Values()
+	if add.d != nil {
+		add.d.compact()
+	}
+
+	n := len(d.vals) - (to - from) + add.Count()
+	keys := make([]{{.Pkg}}Scalar, 0, n)
+	vals := make([]{{.Type}}, 0, n)
+	keys = append(keys, d.keys[:from]...)
+	vals = append(vals, d.vals[:from]...)
+	if add.d != nil {
+		keys = append(keys, add.d.keys...)
+		vals = append(vals, add.d.vals...)
+	}
+	keys = append(keys, d.keys[to:]...)
+	vals = append(vals, d.vals[to:]...)
+	d.keys, d.vals = keys, vals
+	d.renumber()
+	return removed
+}
+
+// Combine returns the items with keys taken from values
+// of keys, both arrays have to have the same size.
+func (a {{.Name}}) Combine(keys {{.Pkg}}Reader) {{.Name}} {
+	if keys.Count() != a.Count() {
+		panic("array_combine(): Argument #1 ($keys) and argument #2 ($values) must have the same number of elements")
+	}
+	res := {{.Name}}{}
+	if a.d == nil {
+		return res
+	}
+	a.d.compact()
+	_, ks := keys.Entries()
+	for i, v := range a.d.vals {
+		res.Edit({{.Pkg}}NewScalar(ks[i]), v{{if .New}}.Copy(){{end}})
+	}
+	return res
+}
+
+// Unique returns the items without the ones, which have the same
+// key as some previous item, keys are kept. PHP uses values
+// converted to strings as keys.
+func (a {{.Name}}) Unique(key func(interface{}) string) {{.Name}} {
+	res := {{.Name}}{}
+	if a.d == nil {
+		return res
+	}
+	a.d.compact()
+	seen := make(map[string]bool, len(a.d.vals))
+	for i, v := range a.d.vals {
+		s := key(v)
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		res.Edit(a.d.keys[i], v{{if .New}}.Copy(){{end}})
+	}
+	return res
+}
+
+// Pop removes the last item and returns it,
+// zero value is returned for empty arrays.
+func (a *{{.Name}}) Pop() {{.Type}} {
+	var v {{.Type}}
+	if a.Count() == 0 {
+		return v
+	}
+	d := a.edit()
+	d.compact()
+	last := len(d.vals) - 1
+	k := d.keys[last]
+	v, d.vals[last] = d.vals[last], v
+	delete(d.index, k)
+	d.keys, d.vals = d.keys[:last], d.vals[:last]
+	if i, ok := k.IntValue(); ok && i == d.lastIndex-1 {
+		d.lastIndex--
+	}
+	return v
+}
+
+// Shift removes the first item and returns it, int keys are
+// renumbered. Zero value is returned for empty arrays.
+func (a *{{.Name}}) Shift() {{.Type}} {
+	var v {{.Type}}
+	if a.Count() == 0 {
+		return v
+	}
+	d := a.edit()
+	d.compact()
+	v, d.vals[0] = d.vals[0], v
+	d.keys, d.vals = d.keys[1:], d.vals[1:]
+	d.renumber()
+	return v
+}
+
+// Unshift puts the values at the beginning of the array, int keys
+// are renumbered. Size of the array is returned.
+func (a *{{.Name}}) Unshift(vals ...{{.Type}}) int {
+	d := a.edit()
+	d.compact()
+	keys := make([]{{.Pkg}}Scalar, len(vals), len(vals)+len(d.keys))
+	for i := range keys {
+		keys[i] = {{.Pkg}}NewScalar(i)
+	}
+	d.keys = append(keys, d.keys...)
+	d.vals = append(append(make([]{{.Type}}, 0, len(d.keys)), vals...), d.vals...)
+	d.renumber()
+	return len(d.vals)
+}
+{{if .New}}
+func (a *{{.Name}}) Sub(k {{.Pkg}}Scalar) *{{.Type}} {
+	if !a.Isset(k) {
+		a.Edit(k, {{.New}}())
+	}
+	d := a.edit()
+	return &d.vals[d.index[k]]
+}
+{{end}}`))
@@ -5,61 +5,166 @@ package array
 var _ Array = (*Int)(nil)
 
 type Int struct {
-	associative map[Scalar]int
-	order       []int
-	lastIndex   int
+	d *intData
+	// iters counts loops over d, which were not released.
+	iters int
+}
+
+type intData struct {
+	index     map[Scalar]int
+	keys      []Scalar
+	vals      []int
+	holes     int
+	lastIndex int
+	refs      int
 }
 
 func NewInt(vals ...int) Int {
-	a := Int{
-		associative: make(map[Scalar]int),
-		order:       make([]int, 0),
-		lastIndex:   0,
-	}
+	a := Int{}
 	a.Add(vals...)
 	return a
 }
 
+func (a Int) Copy() Int {
+	if a.d != nil {
+		a.d.refs++
+	}
+	a.iters = 0
+	return a
+}
+
+func (a *Int) edit() *intData {
+	if a.d == nil {
+		a.d = &intData{
+			index: make(map[Scalar]int),
+		}
+	} else if a.d.refs > 0 {
+		a.d.refs--
+		a.d.compact()
+		d := &intData{
+			index:     make(map[Scalar]int, len(a.d.index)),
+			keys:      make([]Scalar, len(a.d.keys)),
+			vals:      make([]int, len(a.d.vals)),
+			lastIndex: a.d.lastIndex,
+		}
+		for k, i := range a.d.index {
+			d.index[k] = i
+		}
+		copy(d.keys, a.d.keys)
+		copy(d.vals, a.d.vals)
+		a.d = d
+		// Running loops keep the old data.
+		a.iters = 0
+	}
+	return a.d
+}
+
+// compact removes holes left by unset items.
+func (d *intData) compact() {
+	if d.holes == 0 {
+		return
+	}
+	keys := make([]Scalar, 0, len(d.index))
+	vals := make([]int, 0, len(d.index))
+	for i, k := range d.keys {
+		if j, ok := d.index[k]; !ok || j != i {
+			continue
+		}
+		d.index[k] = len(keys)
+		keys = append(keys, k)
+		vals = append(vals, d.vals[i])
+	}
+	d.keys, d.vals, d.holes = keys, vals, 0
+}
+
 func (a *Int) Add(vals ...int) *Int {
+	d := a.edit()
 	for _, v := range vals {
-		k := NewScalar(a.lastIndex)
-		a.add(k, v)
-		a.lastIndex++
+		k := NewScalar(d.lastIndex)
+		d.add(k, v)
+		d.lastIndex++
 	}
 	return a
 }
 
 func (a *Int) Push(vals ...int) int {
 	a.Add(vals...)
-	return len(a.order)
+	return len(a.d.index)
 }
 
 func (a *Int) Edit(k Scalar, v int) *Int {
-	if i, ok := a.associative[k]; ok {
-		a.order[i] = v
-	} else if i, ok := k.IntValue(); ok && i > a.lastIndex {
-		a.lastIndex = i
+	d := a.edit()
+	if i, ok := d.index[k]; ok {
+		d.vals[i] = v
+	} else if i, ok := k.IntValue(); ok && i >= d.lastIndex {
+		d.lastIndex = i
 		a.Add(v)
 	} else {
-		a.add(k, v)
+		d.add(k, v)
 	}
 	return a
 }
 
-func (a *Int) add(k Scalar, v int) {
-	a.order = append(a.order, v)
-	a.associative[k] = len(a.order) - 1
+func (a Int) With(k Scalar, v int) Int {
+	a.Edit(k, v)
+	return a
+}
+
+func (d *intData) add(k Scalar, v int) {
+	d.index[k] = len(d.keys)
+	d.keys = append(d.keys, k)
+	d.vals = append(d.vals, v)
 }
 
 func (a Int) At(k Scalar) int {
-	if v, ok := a.associative[k]; ok {
-		return a.order[v]
+	if a.d != nil {
+		if i, ok := a.d.index[k]; ok {
+			return a.d.vals[i]
+		}
 	}
-	panic("undefined index " + k)
+	Notice("Undefined index: " + string(k))
+	var v int
+	return v
 }
 
+// Iter returns values for loops over arrays, which are not held
+// by variables, like items of other arrays. The values are copied,
+// so editing the array in the loop does not change them.
 func (a Int) Iter() []int {
-	return a.order
+	if a.d == nil {
+		return nil
+	}
+	a.d.compact()
+	vals := make([]int, len(a.d.vals))
+	copy(vals, a.d.vals)
+	return vals
+}
+
+// Range is Iter of the variable, values are not copied. The loop
+// holds the array instead, editing it clones the array. Release
+// has to be called when the loop ends, so the next edit does not.
+func (a *Int) Range() []int {
+	if a.d == nil {
+		return nil
+	}
+	a.d.compact()
+	a.iters++
+	a.d.refs++
+	return a.d.vals
+}
+
+// Release drops the reference of the loop started by Range.
+// Loops left by return are not released, the array is only
+// cloned needlessly then. Items of nested arrays keep their
+// references, the loop could edit them.
+func (a *Int) Release() {
+	if a.iters == 0 {
+		return
+	}
+	a.iters--
+	if a.d.refs > 0 {
+		a.d.refs--
+	}
 }
 
 type IntPair struct {
@@ -68,38 +173,412 @@ type IntPair struct {
 }
 
 func (a Int) KeyIter() []IntPair {
-	res := make([]IntPair, 0, len(a.order))
-	for i, v := range a.order {
-		res = append(res, IntPair{V: v})
-		res[i].V = v
+	if a.d == nil {
+		return nil
 	}
-	for k, v := range a.associative {
-		res[v].K = k
+	a.d.compact()
+	res := make([]IntPair, len(a.d.vals))
+	for i, v := range a.d.vals {
+		res[i] = IntPair{a.d.keys[i], v}
 	}
 	return res
 }
 
 func (a Int) Isset(k Scalar) bool {
-	_, ok := a.associative[k]
+	if a.d == nil {
+		return false
+	}
+	_, ok := a.d.index[k]
 	return ok
 }
 
 func (a *Int) Unset(k Scalar) {
-	i, ok := a.associative[k]
-	if !ok {
+	if !a.Isset(k) {
 		return
 	}
-	delete(a.associative, k)
+	d := a.edit()
+	i := d.index[k]
+	delete(d.index, k)
 
-	copy(a.order[i:], a.order[i+1:])
-	a.order = a.order[:len(a.order)-1]
-	for k, v := range a.associative {
-		if v > i {
-			a.associative[k] = v - 1
-		}
+	// Values of holes are dropped.
+	var v int
+	d.vals[i] = v
+	d.holes++
+	if d.holes > len(d.index) {
+		d.compact()
 	}
 }
 
 func (a Int) Count() int {
-	return len(a.order)
+	if a.d == nil {
+		return 0
+	}
+	return len(a.d.index)
+}
+
+func (a Int) Entries() ([]string, []interface{}) {
+	if a.d == nil {
+		return []string{}, []interface{}{}
+	}
+	a.d.compact()
+	keys := make([]string, len(a.d.keys))
+	vals := make([]interface{}, len(a.d.vals))
+	for i, k := range a.d.keys {
+		keys[i] = string(k)
+		vals[i] = a.d.vals[i]
+	}
+	return keys, vals
+}
+
+// Sort orders the items by cmp, it compares the values, or the keys
+// when byKey is set. Keys are kept when keep is set, the items
+// are indexed from zero otherwise.
+func (a *Int) Sort(cmp func(x, y interface{}) int, byKey, keep bool) {
+	d := a.edit()
+	d.compact()
+	if byKey {
+		d.sort(func(i, j int) bool { return cmp(d.keys[i].Value(), d.keys[j].Value()) < 0 }, keep)
+	} else {
+		d.sort(func(i, j int) bool { return cmp(d.vals[i], d.vals[j]) < 0 }, keep)
+	}
+}
+
+// USort orders the values by cmp, like Sort.
+func (a *Int) USort(cmp func(x, y int) int, keep bool) {
+	d := a.edit()
+	d.compact()
+	d.sort(func(i, j int) bool { return cmp(d.vals[i], d.vals[j]) < 0 }, keep)
+}
+
+// UKSort orders the keys by cmp, the keys are kept.
+func (a *Int) UKSort(cmp func(x, y string) int) {
+	d := a.edit()
+	d.compact()
+	d.sort(func(i, j int) bool { return cmp(string(d.keys[i]), string(d.keys[j])) < 0 }, true)
+}
+
+func (d *intData) sort(less func(i, j int) bool, keep bool) {
+	order := Order(len(d.vals), less)
+	keys := make([]Scalar, len(order))
+	vals := make([]int, len(order))
+	for i, o := range order {
+		keys[i], vals[i] = d.keys[o], d.vals[o]
+	}
+	if !keep {
+		for i := range keys {
+			keys[i] = NewScalar(i)
+		}
+		d.lastIndex = len(keys)
+		d.index = make(map[Scalar]int, len(keys))
+	}
+	for i, k := range keys {
+		d.index[k] = i
+	}
+	d.keys, d.vals = keys, vals
+}
+
+// renumber numbers int keys from zero, string keys are kept.
+func (d *intData) renumber() {
+	d.index = make(map[Scalar]int, len(d.keys))
+	d.lastIndex = 0
+	for i, k := range d.keys {
+		if _, ok := k.IntValue(); ok {
+			k = NewScalar(d.lastIndex)
+			d.keys[i] = k
+			d.lastIndex++
+		}
+		d.index[k] = i
+	}
+}
+
+// Filter returns items, for which keep returns true, keys are kept.
+func (a Int) Filter(keep func(int) bool) Int {
+	res := Int{}
+	for _, p := range a.KeyIter() {
+		if keep(p.V) {
+			res.Edit(p.K, p.V)
+		}
+	}
+	return res
+}
+
+// Any converts the array to Any, keys are kept.
+func (a Int) Any() Any {
+	res := Any{}
+	for _, p := range a.KeyIter() {
+		res.Edit(p.K, p.V)
+	}
+	return res
+}
+
+// MapInt returns results of fn for every item, keys are kept.
+func (a Int) MapInt(fn func(int) int) Int {
+	res := Int{}
+	for _, p := range a.KeyIter() {
+		res.Edit(p.K, fn(p.V))
+	}
+	return res
+}
+
+// ReduceInt passes the result of fn for the previous item
+// to fn with the next item, initial is passed with the first one.
+func (a Int) ReduceInt(fn func(int, int) int, initial int) int {
+	carry := initial
+	for _, v := range a.Range() {
+		carry = fn(carry, v)
+	}
+	a.Release()
+	return carry
+}
+
+// MapString returns results of fn for every item, keys are kept.
+func (a Int) MapString(fn func(int) string) String {
+	res := String{}
+	for _, p := range a.KeyIter() {
+		res.Edit(p.K, fn(p.V))
+	}
+	return res
+}
+
+// ReduceString passes the result of fn for the previous item
+// to fn with the next item, initial is passed with the first one.
+func (a Int) ReduceString(fn func(string, int) string, initial string) string {
+	carry := initial
+	for _, v := range a.Range() {
+		carry = fn(carry, v)
+	}
+	a.Release()
+	return carry
+}
+
+// MapFloat64 returns results of fn for every item, keys are kept.
+func (a Int) MapFloat64(fn func(int) float64) Float64 {
+	res := Float64{}
+	for _, p := range a.KeyIter() {
+		res.Edit(p.K, fn(p.V))
+	}
+	return res
+}
+
+// ReduceFloat64 passes the result of fn for the previous item
+// to fn with the next item, initial is passed with the first one.
+func (a Int) ReduceFloat64(fn func(float64, int) float64, initial float64) float64 {
+	carry := initial
+	for _, v := range a.Range() {
+		carry = fn(carry, v)
+	}
+	a.Release()
+	return carry
+}
+
+// MapBool returns results of fn for every item, keys are kept.
+func (a Int) MapBool(fn func(int) bool) Bool {
+	res := Bool{}
+	for _, p := range a.KeyIter() {
+		res.Edit(p.K, fn(p.V))
+	}
+	return res
+}
+
+// ReduceBool passes the result of fn for the previous item
+// to fn with the next item, initial is passed with the first one.
+func (a Int) ReduceBool(fn func(bool, int) bool, initial bool) bool {
+	carry := initial
+	for _, v := range a.Range() {
+		carry = fn(carry, v)
+	}
+	a.Release()
+	return carry
+}
+
+// MapAny returns results of fn for every item, keys are kept.
+func (a Int) MapAny(fn func(int) interface{}) Any {
+	res := Any{}
+	for _, p := range a.KeyIter() {
+		res.Edit(p.K, fn(p.V))
+	}
+	return res
+}
+
+// ReduceAny passes the result of fn for the previous item
+// to fn with the next item, initial is passed with the first one.
+func (a Int) ReduceAny(fn func(interface{}, int) interface{}, initial interface{}) interface{} {
+	carry := initial
+	for _, v := range a.Range() {
+		carry = fn(carry, v)
+	}
+	a.Release()
+	return carry
+}
+
+// Values returns the items indexed from zero.
+func (a Int) Values() Int {
+	res := Int{}
+	if a.d == nil {
+		return res
+	}
+	a.d.compact()
+	for _, v := range a.d.vals {
+		res.Add(v)
+	}
+	return res
+}
+
+// Merge appends items of the arrays, int keys are renumbered,
+// items with string keys replace the ones with the same key.
+func (a Int) Merge(arrays ...Int) Int {
+	res := Int{}
+	for _, arr := range append([]Int{a}, arrays...) {
+		if arr.d == nil {
+			continue
+		}
+		arr.d.compact()
+		for i, k := range arr.d.keys {
+			v := arr.d.vals[i]
+			if _, ok := k.IntValue(); ok {
+				res.Add(v)
+			} else {
+				res.Edit(k, v)
+			}
+		}
+	}
+	return res
+}
+
+// Slice returns length items from the offset, see Bounds. Int
+// keys are renumbered unless preserve is set.
+func (a Int) Slice(offset, length int, preserve bool) Int {
+	res := Int{}
+	if a.d == nil {
+		return res
+	}
+	a.d.compact()
+	from, to := Bounds(len(a.d.vals), offset, length)
+	for i := from; i < to; i++ {
+		k, v := a.d.keys[i], a.d.vals[i]
+		if _, ok := k.IntValue(); ok && !preserve {
+			res.Add(v)
+		} else {
+			res.Edit(k, v)
+		}
+	}
+	return res
+}
+
+// Splice replaces length items from the offset, see Bounds, by
+// items of repl. Removed items are returned. Int keys are
+// renumbered, keys of repl are not kept.
+func (a *Int) Splice(offset, length int, repl Int) Int {
+	d := a.edit()
+	d.compact()
+	from, to := Bounds(len(d.vals), offset, length)
+	removed := NewInt(d.vals[from:to]...)
+	add := repl.Values()
+	if add.d != nil {
+		add.d.compact()
+	}
+
+	n := len(d.vals) - (to - from) + add.Count()
+	keys := make([]Scalar, 0, n)
+	vals := make([]int, 0, n)
+	keys = append(keys, d.keys[:from]...)
+	vals = append(vals, d.vals[:from]...)
+	if add.d != nil {
+		keys = append(keys, add.d.keys...)
+		vals = append(vals, add.d.vals...)
+	}
+	keys = append(keys, d.keys[to:]...)
+	vals = append(vals, d.vals[to:]...)
+	d.keys, d.vals = keys, vals
+	d.renumber()
+	return removed
+}
+
+// Combine returns the items with keys taken from values
+// of keys, both arrays have to have the same size.
+func (a Int) Combine(keys Reader) Int {
+	if keys.Count() != a.Count() {
+		panic("array_combine(): Argument #1 ($keys) and argument #2 ($values) must have the same number of elements")
+	}
+	res := Int{}
+	if a.d == nil {
+		return res
+	}
+	a.d.compact()
+	_, ks := keys.Entries()
+	for i, v := range a.d.vals {
+		res.Edit(NewScalar(ks[i]), v)
+	}
+	return res
+}
+
+// Unique returns the items without the ones, which have the same
+// key as some previous item, keys are kept. PHP uses values
+// converted to strings as keys.
+func (a Int) Unique(key func(interface{}) string) Int {
+	res := Int{}
+	if a.d == nil {
+		return res
+	}
+	a.d.compact()
+	seen := make(map[string]bool, len(a.d.vals))
+	for i, v := range a.d.vals {
+		s := key(v)
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		res.Edit(a.d.keys[i], v)
+	}
+	return res
+}
+
+// Pop removes the last item and returns it,
+// zero value is returned for empty arrays.
+func (a *Int) Pop() int {
+	var v int
+	if a.Count() == 0 {
+		return v
+	}
+	d := a.edit()
+	d.compact()
+	last := len(d.vals) - 1
+	k := d.keys[last]
+	v, d.vals[last] = d.vals[last], v
+	delete(d.index, k)
+	d.keys, d.vals = d.keys[:last], d.vals[:last]
+	if i, ok := k.IntValue(); ok && i == d.lastIndex-1 {
+		d.lastIndex--
+	}
+	return v
+}
+
+// Shift removes the first item and returns it, int keys are
+// renumbered. Zero value is returned for empty arrays.
+func (a *Int) Shift() int {
+	var v int
+	if a.Count() == 0 {
+		return v
+	}
+	d := a.edit()
+	d.compact()
+	v, d.vals[0] = d.vals[0], v
+	d.keys, d.vals = d.keys[1:], d.vals[1:]
+	d.renumber()
+	return v
+}
+
+// Unshift puts the values at the beginning of the array, int keys
+// are renumbered. Size of the array is returned.
+func (a *Int) Unshift(vals ...int) int {
+	d := a.edit()
+	d.compact()
+	keys := make([]Scalar, len(vals), len(vals)+len(d.keys))
+	for i := range keys {
+		keys[i] = NewScalar(i)
+	}
+	d.keys = append(keys, d.keys...)
+	d.vals = append(append(make([]int, 0, len(d.keys)), vals...), d.vals...)
+	d.renumber()
+	return len(d.vals)
 }
@@ -0,0 +1,17 @@
+//go:build strict
+// +build strict
+
+package array
+
+import "errors"
+
+// Strict builds panic on notices, std replaces
+// these by its strict handler when it is used.
+func init() {
+	Notice = func(msg string) {
+		panic(errors.New("Notice: " + msg))
+	}
+	Deprecated = func(msg string) {
+		panic(errors.New("Deprecated: " + msg))
+	}
+}
@@ -1,59 +1,147 @@
 package array
 
 import (
+	"fmt"
 	"math"
+	"os"
 	"strconv"
 )
 
-// TODO: PHP do not think that it is always a string,
-// but it can be overridden ignoring the type:
-// 0 == "0" == 0.1 == false
-// Part of this issue is resolved using IntValue().
-
 // Scalar well-defines a key for PHP associative array.
 // It is a decorated string which can convert other
 // types to string using PHP common conventions, like
 // false => zero, NULL => "" and so on.
+//
+// PHP keys are either ints or strings, strings holding
+// decimal ints are converted to ints. Scalar of the int
+// is its decimal form, so it is an int key exactly when
+// it is written as a decimal int, like "8", but not "08".
 type Scalar string
 
 // IntValue translates value of the scalar to int,
-// if possible.
+// if the scalar is an int key.
 // This is used to find out what the next index in
 // the array will be, if values are added using Add/Push.
 func (s Scalar) IntValue() (int, bool) {
-	v, err := strconv.Atoi(string(s))
-	if err != nil {
-		return v, false
+	return intKey(string(s))
+}
+
+// Value returns int for int keys and string for the rest.
+func (s Scalar) Value() interface{} {
+	if i, ok := s.IntValue(); ok {
+		return i
 	}
-	return v, true
+	return string(s)
 }
 
-// NewScalar converts primitive data types and
-// NULL to strng, which can be used to index
-// a PHP array.
-func NewScalar(val interface{}) Scalar {
-	// TODO: Pointers can appear here.
+func (s Scalar) String() string {
+	return string(s)
+}
+
+// intKey parses decimal ints without leading zeros,
+// signs other than minus and spaces. "-0" is not
+// an int either.
+func intKey(s string) (int, bool) {
+	digits := s
+	if len(digits) > 0 && digits[0] == '-' {
+		digits = digits[1:]
+	}
+	if digits == "" || digits[0] == '0' && (len(digits) > 1 || len(s) > 1) {
+		return 0, false
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	i, err := strconv.Atoi(s)
+	return i, err == nil
+}
+
+// KeyError is returned for values, which cannot be array
+// keys. PHP throws TypeError when they are used.
+type KeyError struct {
+	Value interface{}
+}
+
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("Illegal offset type %T", e.Value)
+}
+
+// Notice reports PHP notices, like undefined indexes.
+// std replaces it by its handler, it writes to stderr
+// when std is not used.
+var Notice = func(msg string) {
+	fmt.Fprintf(os.Stderr, "Notice: %s\n", msg)
+}
+
+// Deprecated reports use of deprecated PHP features,
+// like float keys losing precision, the same way as Notice.
+var Deprecated = func(msg string) {
+	fmt.Fprintf(os.Stderr, "Deprecated: %s\n", msg)
+}
+
+// ParseScalar converts primitive data types and
+// NULL to the key of a PHP array. Floats are truncated,
+// bools are ints and NULL is an empty string.
+// KeyError is returned for other values.
+func ParseScalar(val interface{}) (Scalar, error) {
 	switch t := val.(type) {
+	case Scalar:
+		return t, nil
+
 	case bool:
 		if t {
-			return Scalar("1")
+			return Scalar("1"), nil
 		}
-		return Scalar("0")
+		return Scalar("0"), nil
 
 	case int:
-		return Scalar(strconv.Itoa(t))
+		return Scalar(strconv.Itoa(t)), nil
 
 	case float64:
-		i := int(math.Floor(t))
-		return Scalar(strconv.Itoa(i))
+		i := floatKey(t)
+		if float64(i) != t && !math.IsNaN(t) && !math.IsInf(t, 0) {
+			Deprecated(fmt.Sprintf("Implicit conversion from float %s to int loses precision", strconv.FormatFloat(t, 'G', -1, 64)))
+		}
+		return Scalar(strconv.Itoa(i)), nil
 
 	case string:
-		return Scalar(t)
+		return Scalar(t), nil
+
+	case nil:
+		return "", nil
+	}
+	return "", &KeyError{val}
+}
+
+// NewScalar converts the value to the key, like ParseScalar.
+// The translator passes only values of scalar types to it,
+// values of unknown types are converted by std.Key. The
+// KeyError is passed to panic, when it is misused.
+func NewScalar(val interface{}) Scalar {
+	s, err := ParseScalar(val)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// floatKey truncates the float, floats out of the int
+// range wrap around, NaN and infinities are zero.
+func floatKey(f float64) int {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
 	}
-	if val != nil {
-		panic(`Only NULL and primitive types can be an array key.`)
+	f = math.Trunc(f)
+	if f >= math.MinInt64 && f < math.MaxInt64 {
+		return int(f)
 	}
-	return ""
+	f = math.Mod(f, 1<<64)
+	if f < 0 {
+		f += 1 << 64
+	}
+	return int(uint64(f))
 }
 
 // Array encapsulates common methods
@@ -61,7 +149,17 @@ func NewScalar(val interface{}) Scalar {
 // because these are strongly typed. Only the ones
 // using only scalar as an argument are here.
 type Array interface {
+	Reader
 	Isset(Scalar) bool
 	Unset(Scalar)
+}
+
+// Reader is implemented by values of arrays,
+// unlike Array it does not need a pointer.
+type Reader interface {
 	Count() int
+
+	// Entries returns keys and values in the order
+	// of the array, std compares arrays by them.
+	Entries() ([]string, []interface{})
 }
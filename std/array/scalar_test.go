@@ -0,0 +1,92 @@
+package array
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestNewScalar(t *testing.T) {
+	// Expected keys are results of PHP 8.
+	cases := []struct {
+		in    interface{}
+		out   Scalar
+		value interface{}
+	}{
+		{1, "1", 1},
+		{-5, "-5", -5},
+		{"1", "1", 1},
+		{"-1", "-1", -1},
+		{"08", "08", "08"},
+		{"-0", "-0", "-0"},
+		{" 1", " 1", " 1"},
+		{"1 ", "1 ", "1 "},
+		{"+1", "+1", "+1"},
+		{"1.5", "1.5", "1.5"},
+		{"9223372036854775808", "9223372036854775808", "9223372036854775808"},
+		{"abc", "abc", "abc"},
+		{true, "1", 1},
+		{false, "0", 0},
+		{nil, "", ""},
+		{1.0, "1", 1},
+		{-1.0, "-1", -1},
+		{math.NaN(), "0", 0},
+		{math.Inf(1), "0", 0},
+		{Scalar("x"), "x", "x"},
+	}
+	for _, c := range cases {
+		s := NewScalar(c.in)
+		if s != c.out {
+			t.Errorf("%#v: '%s' expected, '%s' found.", c.in, c.out, s)
+		}
+		if v := s.Value(); v != c.value {
+			t.Errorf("%#v: %#v expected, %#v found.", c.in, c.value, v)
+		}
+	}
+}
+
+func TestFloatKey(t *testing.T) {
+	var msgs []string
+	defer func(d func(string)) { Deprecated = d }(Deprecated)
+	Deprecated = func(msg string) { msgs = append(msgs, msg) }
+
+	if s := NewScalar(1.7); s != "1" {
+		t.Errorf("'1' expected, '%s' found.", s)
+	}
+	if s := NewScalar(-1.7); s != "-1" {
+		t.Errorf("'-1' expected, '%s' found.", s)
+	}
+	if len(msgs) != 2 || msgs[0] != "Implicit conversion from float 1.7 to int loses precision" {
+		t.Errorf("Deprecation expected for both floats, %q found.", msgs)
+	}
+}
+
+func TestIllegalKey(t *testing.T) {
+	_, err := ParseScalar([]int{1})
+	var ke *KeyError
+	if !errors.As(err, &ke) {
+		t.Fatalf("KeyError expected, %v found.", err)
+	}
+
+	defer func() {
+		if _, ok := recover().(*KeyError); !ok {
+			t.Error("KeyError should be passed to panic.")
+		}
+	}()
+	NewScalar(struct{}{})
+	t.Error("Objects cannot be keys.")
+}
+
+func TestIntKeys(t *testing.T) {
+	// "08" is a string key, it does not move the next index.
+	a := NewInt()
+	a.Edit(NewScalar("08"), 1)
+	a.Edit(NewScalar("5"), 2)
+	a.Add(3)
+	if !a.Isset(NewScalar(6)) || a.Isset(NewScalar(8)) {
+		t.Errorf("Next index should be 6, %v found.", a.KeyIter())
+	}
+	if a.Isset(NewScalar(9)) || !a.Isset(NewScalar("5")) {
+		t.Error("String \"5\" should be the same key as 5.")
+	}
+}
@@ -0,0 +1,27 @@
+package array
+
+// Bounds converts PHP offset and length of the part of n items
+// to the range of positions. Negative offset counts from the end,
+// negative length leaves that many items at the end.
+func Bounds(n, offset, length int) (int, int) {
+	switch {
+	case offset < -n:
+		offset = 0
+	case offset < 0:
+		offset += n
+	case offset > n:
+		offset = n
+	}
+
+	end := n
+	switch {
+	case length < 0:
+		end = n + length
+	case length < n-offset:
+		end = offset + length
+	}
+	if end < offset {
+		end = offset
+	}
+	return offset, end
+}
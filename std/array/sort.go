@@ -0,0 +1,17 @@
+package array
+
+import "sort"
+
+// Order returns positions of n items sorted by less,
+// items equal to each other keep their order, as
+// sorting in PHP 8 is stable.
+func Order(n int, less func(i, j int) bool) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return less(order[i], order[j])
+	})
+	return order
+}
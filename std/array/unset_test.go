@@ -0,0 +1,140 @@
+package array
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestUnsetOrder(t *testing.T) {
+	a := NewString("a", "b", "c", "d")
+	a.Unset(NewScalar(1))
+	a.Edit(NewScalar("x"), "x")
+	a.Unset(NewScalar(0))
+	a.Unset(NewScalar(2))
+	a.Add("e")
+	a.Edit(NewScalar(1), "f")
+
+	keys, vals := a.Entries()
+	exp := []string{"3:d", "x:x", "4:e", "1:f"}
+	if len(keys) != len(exp) || a.Count() != len(exp) {
+		t.Fatalf("%d items expected, %d found.", len(exp), len(keys))
+	}
+	for i, k := range keys {
+		if got := k + ":" + vals[i].(string); got != exp[i] {
+			t.Errorf("%s expected at %d, %s found.", exp[i], i, got)
+		}
+	}
+}
+
+func TestUnsetIter(t *testing.T) {
+	// foreach ($a as $k => $v) { unset($a[$k]); $a[] = $v; }
+	a := NewInt(0, 1, 2, 3, 4)
+	for _, p := range a.KeyIter() {
+		a.Unset(p.K)
+		a.Add(p.V)
+	}
+	for i, v := range a.KeyIter() {
+		if v.K != NewScalar(i+5) || v.V != i {
+			t.Errorf("%d => %d expected, %s => %d found.", i+5, i, v.K, v.V)
+		}
+	}
+}
+
+func TestUnsetEdit(t *testing.T) {
+	// Keys added by Edit move the next index.
+	a := NewInt(1)
+	a.Edit(NewScalar(1), 2)
+	a.Add(3)
+	if a.Count() != 3 || a.At(NewScalar(2)) != 3 {
+		t.Errorf("Three items expected, %v found.", a.Iter())
+	}
+
+	// Removing most of the items keeps the rest reachable.
+	for i := 0; i < 100; i++ {
+		a.Add(i)
+	}
+	for i := 0; i < 102; i++ {
+		a.Unset(NewScalar(i))
+	}
+	if a.Count() != 1 || a.At(NewScalar(102)) != 99 {
+		t.Errorf("Only the last item expected, %v found.", a.Iter())
+	}
+}
+
+// Benchmarks run on growing arrays, time per item
+// has to stay the same for operations to be linear.
+var sizes = []int{10000, 100000, 1000000}
+
+func benchmark(b *testing.B, fn func(b *testing.B, n int)) {
+	for _, n := range sizes {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				fn(b, n)
+			}
+		})
+	}
+}
+
+func filled(b *testing.B, n int) Int {
+	b.StopTimer()
+	defer b.StartTimer()
+	a := NewInt()
+	for i := 0; i < n; i++ {
+		a.Add(i)
+	}
+	return a
+}
+
+func BenchmarkAdd(b *testing.B) {
+	benchmark(b, func(b *testing.B, n int) {
+		a := NewInt()
+		for i := 0; i < n; i++ {
+			a.Add(i)
+		}
+	})
+}
+
+func BenchmarkIter(b *testing.B) {
+	benchmark(b, func(b *testing.B, n int) {
+		a := filled(b, n)
+		sum := 0
+		for _, v := range a.Iter() {
+			sum += v
+		}
+	})
+}
+
+// BenchmarkRange is BenchmarkIter of variables, the values
+// are not copied, Iter copies them for the other arrays.
+func BenchmarkRange(b *testing.B) {
+	benchmark(b, func(b *testing.B, n int) {
+		a := filled(b, n)
+		sum := 0
+		for _, v := range a.Range() {
+			sum += v
+		}
+		a.Release()
+	})
+}
+
+func BenchmarkUnset(b *testing.B) {
+	benchmark(b, func(b *testing.B, n int) {
+		a := filled(b, n)
+		for i := 0; i < n; i++ {
+			a.Unset(NewScalar(i))
+		}
+	})
+}
+
+func BenchmarkUnsetIter(b *testing.B) {
+	benchmark(b, func(b *testing.B, n int) {
+		a := filled(b, n)
+		for _, p := range a.KeyIter() {
+			if p.V%2 == 0 {
+				a.Unset(p.K)
+			}
+		}
+		for range a.KeyIter() {
+		}
+	})
+}
@@ -0,0 +1,183 @@
+package std
+
+import (
+	"errors"
+	"math"
+
+	"github.com/lSimul/php2go/std/array"
+)
+
+// InArray checks if the needle is in the array, values are
+// compared loosely, or strictly with their types when strict
+// is set. It does the same thing as PHP in_array.
+func InArray(needle interface{}, a array.Reader, strict bool) bool {
+	_, found := search(needle, a, strict)
+	return found
+}
+
+// ArraySearch returns the key of the first item equal to
+// the needle, false is returned when there is none.
+// It does the same thing as PHP array_search.
+func ArraySearch(needle interface{}, a array.Reader, strict bool) interface{} {
+	k, found := search(needle, a, strict)
+	if !found {
+		return false
+	}
+	return array.Scalar(k).Value()
+}
+
+func search(needle interface{}, a array.Reader, strict bool) (string, bool) {
+	keys, vals := a.Entries()
+	for i, v := range vals {
+		if strict && v == needle || !strict && LooseEq(v, needle) {
+			return keys[i], true
+		}
+	}
+	return "", false
+}
+
+// Key converts the value without a static type to the key
+// of an array by array.ParseScalar, the translator checks keys
+// of the other types. Illegal keys, like arrays, are reported
+// as warnings and the empty key is used instead.
+func Key(v interface{}) array.Scalar {
+	k, err := array.ParseScalar(v)
+	if err != nil {
+		Report(Warning, err.Error())
+	}
+	return k
+}
+
+// ArrayKeys returns keys of the array, int keys are ints
+// and the rest are strings, like in PHP array_keys.
+func ArrayKeys(a array.Reader) array.Any {
+	keys, _ := a.Entries()
+	res := array.NewAny()
+	for _, k := range keys {
+		res.Add(array.Scalar(k).Value())
+	}
+	return res
+}
+
+// ArrayFlip swaps keys with their values, only ints and strings
+// can be flipped, other items are skipped with a warning.
+// It does the same thing as PHP array_flip.
+func ArrayFlip(a array.Reader) array.Any {
+	keys, vals := a.Entries()
+	res := array.NewAny()
+	for i, v := range vals {
+		switch v.(type) {
+		case int, string:
+			res.Edit(array.NewScalar(v), array.Scalar(keys[i]).Value())
+		default:
+			Report(Warning, "array_flip(): Can only flip string and integer values, entry skipped")
+		}
+	}
+	return res
+}
+
+// ArraySum adds items of the array like numbers, the sum is an int
+// until some item is a float or ints overflow. Arrays are skipped.
+// It does the same thing as PHP array_sum.
+func ArraySum(a array.Reader) interface{} {
+	_, vals := a.Entries()
+	sum := number{}
+	for _, v := range vals {
+		var n number
+		switch v := v.(type) {
+		case array.Reader:
+			continue
+		case string:
+			n, _ = numericPrefix(v)
+		case float64:
+			n = number{f: v, isFloat: true}
+		default:
+			n = number{i: ToInt(v)}
+		}
+		sum = addNumbers(sum, n)
+	}
+	if sum.isFloat {
+		return sum.f
+	}
+	return sum.i
+}
+
+// addNumbers adds ints, the result is a float
+// when one of them is a float or ints overflow.
+func addNumbers(a, b number) number {
+	if !a.isFloat && !b.isFloat {
+		s := a.i + b.i
+		if (s > a.i) == (b.i > 0) {
+			return number{i: s}
+		}
+	}
+	x, y := a.f, b.f
+	if !a.isFloat {
+		x = float64(a.i)
+	}
+	if !b.isFloat {
+		y = float64(b.i)
+	}
+	return number{f: x + y, isFloat: true}
+}
+
+// errStep is passed to panic when the step of the
+// range is zero, as PHP throws ValueError.
+var errStep = errors.New("range(): Argument #3 ($step) cannot be 0")
+
+// Range returns ints from start to end, end is included.
+// Start can be greater than end, the step is always positive.
+// It does the same thing as PHP range.
+func Range(start, end, step int) array.Int {
+	if step == 0 {
+		panic(errStep)
+	}
+	if step < 0 {
+		step = -step
+	}
+	res := array.NewInt()
+	if start <= end {
+		for i := start; i <= end && i >= start; i += step {
+			res.Add(i)
+		}
+	} else {
+		for i := start; i >= end && i <= start; i -= step {
+			res.Add(i)
+		}
+	}
+	return res
+}
+
+// RangeFloat64 is Range of floats.
+func RangeFloat64(start, end, step float64) array.Float64 {
+	if step == 0 || math.IsNaN(step) {
+		panic(errStep)
+	}
+	step = math.Abs(step)
+	n := int(math.Floor(math.Abs(end-start)/step + 1e-9))
+	if start > end {
+		step = -step
+	}
+	res := array.NewFloat64()
+	for i := 0; i <= n; i++ {
+		res.Add(start + float64(i)*step)
+	}
+	return res
+}
+
+// RangeString is Range of letters, only first bytes
+// of start and end are used.
+func RangeString(start, end string, step int) array.String {
+	var s, e byte
+	if start != "" {
+		s = start[0]
+	}
+	if end != "" {
+		e = end[0]
+	}
+	res := array.NewString()
+	for _, c := range Range(int(s), int(e), step).Iter() {
+		res.Add(string([]byte{byte(c)}))
+	}
+	return res
+}
@@ -0,0 +1,108 @@
+package std
+
+import (
+	"testing"
+
+	"github.com/lSimul/php2go/std/array"
+)
+
+func TestSearch(t *testing.T) {
+	a := array.NewAny(1, "2", 3.0)
+	a.Edit(array.NewScalar("k"), "abc")
+
+	if !InArray("1", a, false) || InArray("1", a, true) {
+		t.Error("Strict search should compare types.")
+	}
+	if k := ArraySearch(3, a, false); k != 2 {
+		t.Errorf("Key 2 expected, %v found.", k)
+	}
+	if k := ArraySearch("abc", a, true); k != "k" {
+		t.Errorf("Key k expected, %v found.", k)
+	}
+	if k := ArraySearch(4, a, false); k != false {
+		t.Errorf("False expected, %v found.", k)
+	}
+}
+
+func TestArrayFlip(t *testing.T) {
+	prev := SetHandler(HandlerFunc(func(Level, string) {}))
+	defer SetHandler(prev)
+
+	keys := ArrayKeys(ArrayFlip(array.NewAny("a", 5, 1.5)))
+	exp := []interface{}{"a", 5}
+	for i, k := range keys.Iter() {
+		if i >= len(exp) || k != exp[i] {
+			t.Errorf("%v expected, %v found.", exp, keys.Iter())
+			break
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	var msgs []string
+	prev := SetHandler(HandlerFunc(func(l Level, msg string) {
+		msgs = append(msgs, l.String()+": "+msg)
+	}))
+	defer SetHandler(prev)
+
+	if k := Key("08"); k != "08" {
+		t.Errorf("'08' expected, '%s' found.", k)
+	}
+	if k := Key(true); k != "1" {
+		t.Errorf("'1' expected, '%s' found.", k)
+	}
+	if k := Key(array.NewInt(1)); k != "" {
+		t.Errorf("Empty key expected, '%s' found.", k)
+	}
+	if len(msgs) != 1 || msgs[0] != "Warning: Illegal offset type array.Int" {
+		t.Errorf("Illegal key should be reported, %q found.", msgs)
+	}
+}
+
+func TestArraySum(t *testing.T) {
+	cases := []struct {
+		a   array.Reader
+		sum interface{}
+	}{
+		{array.NewInt(1, 2, 3), 6},
+		{array.NewAny(1, 2.5, "3"), 6.5},
+		{array.NewAny(1, "3abc", array.NewInt(4)), 4},
+		{array.NewInt(1<<62, 1<<62), float64(1 << 63)},
+		{array.NewInt(), 0},
+	}
+	for _, c := range cases {
+		if s := ArraySum(c.a); s != c.sum {
+			t.Errorf("%v expected, %v found.", c.sum, s)
+		}
+	}
+}
+
+func TestRange(t *testing.T) {
+	check := func(exp []interface{}, a array.Reader) {
+		t.Helper()
+		_, vals := a.Entries()
+		if len(vals) != len(exp) {
+			t.Errorf("%v expected, %v found.", exp, vals)
+			return
+		}
+		for i, v := range vals {
+			if v != exp[i] {
+				t.Errorf("%v expected, %v found.", exp, vals)
+				return
+			}
+		}
+	}
+
+	check([]interface{}{1, 3, 5}, Range(1, 5, 2))
+	check([]interface{}{10, 7, 4, 1}, Range(10, 0, -3))
+	check([]interface{}{0.0, 0.25, 0.5, 0.75, 1.0}, RangeFloat64(0, 1, 0.25))
+	check([]interface{}{2.0, 1.5, 1.0, 0.5}, RangeFloat64(2, 0.5, -0.5))
+	check([]interface{}{"a", "c", "e"}, RangeString("a", "e", 2))
+
+	defer func() {
+		if recover() != errStep {
+			t.Error("Zero step should panic.")
+		}
+	}()
+	Range(1, 2, 0)
+}
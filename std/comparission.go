@@ -1,27 +1,17 @@
 package std
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lSimul/php2go/std/array"
+)
+
 // Truthy converts anything to boolean.
 // Used to simulate implicit conversion.
 func Truthy(i interface{}) bool {
-	switch v := i.(type) {
-	case int:
-		return v != 0
-
-	case float64:
-		return v != 0
-
-	case string:
-		return v != ""
-
-	case bool:
-		// Is this even needed?
-		return v
-
-	case Bool:
-		return v.ToBool()
-	}
-
-	return i != nil
+	return ToBool(i)
 }
 
 // Bool is used to add an option
@@ -34,3 +24,192 @@ type Bool interface {
 	// discovered.
 	ToBool() bool
 }
+
+// LooseEq compares values the same way as PHP ==.
+func LooseEq(a, b interface{}) bool {
+	return Compare(a, b) == 0
+}
+
+// Compare compares values the same way as PHP <=>, it
+// returns -1, 0 or 1. Values, which cannot be compared,
+// like arrays with different keys, return 1.
+//
+// See php.net/manual/en/language.operators.comparison.php
+// for the comparison table.
+func Compare(a, b interface{}) int {
+	// Null is an empty string when compared to strings,
+	// otherwise both values are converted to bool.
+	if s, ok := b.(string); ok && a == nil {
+		return compareStrings("", s)
+	}
+	if s, ok := a.(string); ok && b == nil {
+		return compareStrings(s, "")
+	}
+	_, ab := a.(bool)
+	_, bb := b.(bool)
+	if ab || bb || a == nil || b == nil {
+		return compareBools(ToBool(a), ToBool(b))
+	}
+
+	if n, ok := toNumber(a); ok {
+		if m, ok := toNumber(b); ok {
+			return compareNumbers(n, m)
+		}
+		if s, ok := b.(string); ok {
+			return compareNumberString(n, s, false)
+		}
+	}
+	if s, ok := a.(string); ok {
+		if n, ok := toNumber(b); ok {
+			return compareNumberString(n, s, true)
+		}
+		if t, ok := b.(string); ok {
+			return compareStrings(s, t)
+		}
+	}
+
+	aa, isArr := a.(array.Reader)
+	ba, isBrr := b.(array.Reader)
+	switch {
+	case isArr && isBrr:
+		return compareArrays(aa, ba)
+	case isArr:
+		return 1
+	case isBrr:
+		return -1
+	}
+
+	// Objects with __toString are compared as strings.
+	if s, ok := a.(fmt.Stringer); ok {
+		if t, ok := b.(string); ok {
+			return compareStrings(s.String(), t)
+		}
+	}
+	if t, ok := b.(fmt.Stringer); ok {
+		if s, ok := a.(string); ok {
+			return compareStrings(s, t.String())
+		}
+	}
+	if a == b {
+		return 0
+	}
+	return 1
+}
+
+func toNumber(i interface{}) (number, bool) {
+	switch i := i.(type) {
+	case int:
+		return number{i: i}, true
+	case float64:
+		return number{f: i, isFloat: true}, true
+	}
+	return number{}, false
+}
+
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	}
+	return -1
+}
+
+// compareNumbers compares floats the same way as PHP, NaN
+// is not equal to anything and it is greater than anything.
+func compareNumbers(a, b number) int {
+	if !a.isFloat && !b.isFloat {
+		switch {
+		case a.i < b.i:
+			return -1
+		case a.i > b.i:
+			return 1
+		}
+		return 0
+	}
+
+	x, y := a.f, b.f
+	if !a.isFloat {
+		x = float64(a.i)
+	}
+	if !b.isFloat {
+		y = float64(b.i)
+	}
+	switch {
+	case x == y:
+		return 0
+	case x < y:
+		return -1
+	}
+	return 1
+}
+
+// compareNumberString compares the number with the numeric string
+// as numbers, non-numeric strings are compared with the number
+// converted to string. The order of operands is kept for NaN.
+func compareNumberString(n number, s string, swapped bool) int {
+	if m, ok := numericString(s); ok {
+		if swapped {
+			return compareNumbers(m, n)
+		}
+		return compareNumbers(n, m)
+	}
+
+	ns := strconv.Itoa(n.i)
+	if n.isFloat {
+		ns = formatFloat(n.f)
+	}
+	if swapped {
+		return strings.Compare(s, ns)
+	}
+	return strings.Compare(ns, s)
+}
+
+// compareStrings compares numeric strings as numbers,
+// the rest is compared byte by byte.
+func compareStrings(a, b string) int {
+	if n, ok := numericString(a); ok {
+		if m, ok := numericString(b); ok {
+			return compareNumbers(n, m)
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// compareArrays compares arrays by their size first,
+// then values with the same key are compared.
+func compareArrays(a, b array.Reader) int {
+	if c := compareNumbers(number{i: a.Count()}, number{i: b.Count()}); c != 0 {
+		return c
+	}
+
+	keys, vals := a.Entries()
+	bKeys, bVals := b.Entries()
+	index := make(map[string]int, len(bKeys))
+	for i, k := range bKeys {
+		index[k] = i
+	}
+	for i, k := range keys {
+		j, ok := index[k]
+		if !ok {
+			return 1
+		}
+		if c := Compare(vals[i], bVals[j]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// Greater is PHP >, it compares swapped operands like
+// PHP does, so values which cannot be compared are
+// neither greater nor smaller.
+func Greater(a, b interface{}) bool {
+	return Compare(b, a) < 0
+}
+
+// GreaterOrEqual is PHP >=, see Greater.
+func GreaterOrEqual(a, b interface{}) bool {
+	return Compare(b, a) <= 0
+}
@@ -0,0 +1,95 @@
+package std
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lSimul/php2go/std/array"
+)
+
+func TestCompare(t *testing.T) {
+	// Results of <=> in PHP 8, numbers are compared to non-numeric
+	// strings as strings, null and bools are compared as bools.
+	cases := []struct {
+		a, b interface{}
+		out  int
+	}{
+		{1, 2, -1},
+		{2, 1.5, 1},
+		{1, 1.0, 0},
+		{"10", 10, 0},
+		{10, "10", 0},
+		{"1e1", "10", 0},
+		{"abc", "abd", -1},
+		{"10", "9", 1},
+		{"10", "9a", -1},
+		{" 1", "1", 0},
+		{"1 ", "1", 0},
+		{0, "a", -1},
+		{"a", 0, 1},
+		{"1", "01", 0},
+		{"abc", 0, 1},
+		{nil, "", 0},
+		{nil, "0", -1},
+		{nil, false, 0},
+		{nil, 0, 0},
+		{true, "a", 0},
+		{false, "0", 0},
+		{false, "", 0},
+		{true, 2, 0},
+		{-1, false, 1},
+		{math.NaN(), 1, 1},
+		{1, math.NaN(), 1},
+		{math.NaN(), math.NaN(), 1},
+		{array.NewInt(1, 2), array.NewInt(1, 2), 0},
+		{array.NewInt(1, 2), array.NewInt(1, 3), -1},
+		{array.NewInt(1, 2, 3), array.NewInt(5, 6), 1},
+		{array.NewString("1", "2"), array.NewInt(1, 2), 0},
+		{array.NewInt(1), 5, 1},
+		{"a", array.NewInt(), -1},
+		{array.NewInt(), false, 0},
+	}
+	for _, c := range cases {
+		if out := Compare(c.a, c.b); out != c.out {
+			t.Errorf("%#v <=> %#v: '%d' expected, '%d' found.", c.a, c.b, c.out, out)
+		}
+	}
+}
+
+func TestCompareDifferentKeys(t *testing.T) {
+	a := array.NewInt()
+	a.Edit(array.NewScalar("a"), 1)
+	b := array.NewInt()
+	b.Edit(array.NewScalar("b"), 1)
+
+	if Compare(a, b) != 1 || Compare(b, a) != 1 {
+		t.Error("Arrays with different keys should be uncomparable.")
+	}
+	if LooseEq(a, b) {
+		t.Error("Arrays with different keys should not be equal.")
+	}
+}
+
+func TestLooseEq(t *testing.T) {
+	// Numeric strings are equal to numbers, "abc" == 0
+	// is false since PHP 8.
+	cases := []struct {
+		a, b interface{}
+		out  bool
+	}{
+		{"10", 10, true},
+		{"abc", 0, false},
+		{"1e3", "1000", true},
+		{"abc", "ABC", false},
+		{nil, 0, true},
+		{"0", false, true},
+		{"", nil, true},
+		{1.0, 1, true},
+		{math.NaN(), math.NaN(), false},
+	}
+	for _, c := range cases {
+		if out := LooseEq(c.a, c.b); out != c.out {
+			t.Errorf("%#v == %#v: '%t' expected, '%t' found.", c.a, c.b, c.out, out)
+		}
+	}
+}
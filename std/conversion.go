@@ -1,5 +1,12 @@
 package std
 
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/lSimul/php2go/std/array"
+)
+
 // BoolToInt converts b to int
 // the same way like PHP do.
 func BoolToInt(b bool) int {
@@ -15,3 +22,86 @@ func BoolToInt(b bool) int {
 func BoolToFloat64(b bool) float64 {
 	return float64(BoolToInt(b))
 }
+
+// ToFloat64 transfers anything to float64, it does
+// the same thing as (float) in PHP.
+func ToFloat64(s interface{}) float64 {
+	switch s := s.(type) {
+	case float64:
+		return s
+	case float32:
+		return float64(s)
+
+	case int:
+		return float64(s)
+
+	case bool:
+		return BoolToFloat64(s)
+
+	case string:
+		n, _ := numericPrefix(s)
+		if n.isFloat {
+			return n.f
+		}
+		return float64(n.i)
+	}
+	return float64(ToInt(s))
+}
+
+// ToString transfers anything to string, it does the same
+// thing as (string) in PHP. Floats keep 14 significant
+// digits, true is "1" and false is an empty string.
+func ToString(s interface{}) string {
+	switch s := s.(type) {
+	case string:
+		return s
+
+	case int:
+		return strconv.Itoa(s)
+
+	// Not generated by parser.
+	case float32:
+		panic(`Float32 should not be here.`)
+
+	case float64:
+		return formatFloat(s)
+
+	case bool:
+		if s {
+			return "1"
+		}
+		return ""
+
+	case fmt.Stringer:
+		return s.String()
+
+	case array.Reader:
+		return "Array"
+	}
+	return ""
+}
+
+// ToBool transfers anything to bool, it does the same
+// thing as (bool) in PHP. Empty strings and "0" are false.
+func ToBool(s interface{}) bool {
+	switch s := s.(type) {
+	case bool:
+		return s
+
+	case int:
+		return s != 0
+
+	case float64:
+		return s != 0
+
+	case string:
+		return s != "" && s != "0"
+
+	case Bool:
+		return s.ToBool()
+
+	case array.Reader:
+		return s.Count() > 0
+	}
+	return s != nil
+}
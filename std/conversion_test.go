@@ -0,0 +1,119 @@
+package std
+
+import (
+	"math"
+	"testing"
+)
+
+func TestToInt(t *testing.T) {
+	// (int) takes the leading number of strings, out of
+	// range strings saturate, floats wrap around.
+	cases := []struct {
+		in  interface{}
+		out int
+	}{
+		{"", 0},
+		{"12", 12},
+		{"12abc", 12},
+		{"abc12", 0},
+		{" 12", 12},
+		{"\n\t-12 ", -12},
+		{"+7", 7},
+		{"1e3", 1000},
+		{"1.9", 1},
+		{"-1.9", -1},
+		{".5", 0},
+		{"0x1A", 0},
+		{"9999999999999999999", math.MaxInt64},
+		{"-1e100", math.MinInt64},
+		{1.9, 1},
+		{-1.9, -1},
+		{1e20, 7766279631452241920},
+		{math.Inf(1), 0},
+		{math.NaN(), 0},
+		{true, 1},
+		{false, 0},
+		{nil, 0},
+	}
+	for _, c := range cases {
+		if out := ToInt(c.in); out != c.out {
+			t.Errorf("%#v: '%d' expected, '%d' found.", c.in, c.out, out)
+		}
+	}
+}
+
+func TestToFloat64(t *testing.T) {
+	// (float) takes the leading number, "1e" is 1.
+	cases := []struct {
+		in  interface{}
+		out float64
+	}{
+		{"", 0},
+		{"1.5", 1.5},
+		{"1.5abc", 1.5},
+		{"abc1.5", 0},
+		{" -.5", -0.5},
+		{"1e-2", 0.01},
+		{"1e", 1},
+		{"9999999999999999999", 1e19},
+		{3, 3},
+		{true, 1},
+		{nil, 0},
+	}
+	for _, c := range cases {
+		if out := ToFloat64(c.in); out != c.out {
+			t.Errorf("%#v: '%g' expected, '%g' found.", c.in, c.out, out)
+		}
+	}
+}
+
+func TestToString(t *testing.T) {
+	// Floats are printed with precision 14, large
+	// and small ones in the exponent form.
+	cases := []struct {
+		in  interface{}
+		out string
+	}{
+		{"abc", "abc"},
+		{-12, "-12"},
+		{1.0, "1"},
+		{0.1 + 0.2, "0.3"},
+		{1.0 / 3, "0.33333333333333"},
+		{1e15, "1.0E+15"},
+		{1.5e-7, "1.5E-7"},
+		{math.Copysign(0, -1), "-0"},
+		{math.Inf(-1), "-INF"},
+		{true, "1"},
+		{false, ""},
+		{nil, ""},
+	}
+	for _, c := range cases {
+		if out := ToString(c.in); out != c.out {
+			t.Errorf("%#v: '%s' expected, '%s' found.", c.in, c.out, out)
+		}
+	}
+}
+
+func TestToBool(t *testing.T) {
+	// Only "" and "0" of the strings are false.
+	cases := []struct {
+		in  interface{}
+		out bool
+	}{
+		{"", false},
+		{"0", false},
+		{"0.0", true},
+		{" ", true},
+		{"a", true},
+		{0, false},
+		{-1, true},
+		{0.0, false},
+		{0.1, true},
+		{nil, false},
+	}
+	for _, c := range cases {
+		if out := ToBool(c.in); out != c.out {
+			t.Errorf("%#v: '%t' expected, '%t' found.", c.in, c.out, out)
+		}
+	}
+}
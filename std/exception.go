@@ -0,0 +1,320 @@
+package std
+
+// Throwable is implemented by every exception,
+// thrown exceptions are passed to panic.
+// It represents PHP interface with the same name.
+//
+// See php.net/manual/en/class.throwable.php
+// for more details.
+type Throwable interface {
+	error
+
+	GetMessage() string
+	GetCode() int
+	GetPrevious() Throwable
+	GetFile() string
+	GetLine() int
+}
+
+// Exception is the base class of PHP exceptions,
+// classes extending it embed the struct.
+//
+// See php.net/manual/en/class.exception.php
+// for more details.
+type Exception struct {
+	Message  string
+	Code     int
+	Previous Throwable
+
+	// File and Line are set when the exception is thrown.
+	File string
+	Line int
+}
+
+// ExceptionInterface is implemented by the Exception
+// and every struct embedding it.
+type ExceptionInterface interface {
+	Throwable
+	asException() *Exception
+}
+
+func NewException(message string, code int, previous Throwable) *Exception {
+	e := &Exception{}
+	e.Construct(message, code, previous)
+	return e
+}
+
+// Construct is PHP constructor of the exception,
+// subclasses call it as parent::__construct.
+func (e *Exception) Construct(message string, code int, previous Throwable) {
+	e.Message = message
+	e.Code = code
+	e.Previous = previous
+}
+
+func (e *Exception) GetMessage() string {
+	return e.Message
+}
+
+func (e *Exception) GetCode() int {
+	return e.Code
+}
+
+func (e *Exception) GetPrevious() Throwable {
+	return e.Previous
+}
+
+func (e *Exception) GetFile() string {
+	return e.File
+}
+
+func (e *Exception) GetLine() int {
+	return e.Line
+}
+
+func (e *Exception) Error() string {
+	return e.Message
+}
+
+func (e *Exception) asException() *Exception {
+	return e
+}
+
+// Thrown records where the exception was thrown,
+// it is called with every PHP throw.
+func Thrown(t Throwable, file string, line int) Throwable {
+	if e, ok := t.(ExceptionInterface); ok && e.asException().File == "" {
+		e.asException().File = file
+		e.asException().Line = line
+	}
+	return t
+}
+
+// LogicException represents errors in the program logic.
+type LogicException struct {
+	Exception
+}
+
+type LogicExceptionInterface interface {
+	ExceptionInterface
+	asLogicException() *LogicException
+}
+
+func NewLogicException(message string, code int, previous Throwable) *LogicException {
+	e := &LogicException{}
+	e.Construct(message, code, previous)
+	return e
+}
+
+func (e *LogicException) asLogicException() *LogicException {
+	return e
+}
+
+// InvalidArgumentException is thrown if an argument
+// is not of the expected type.
+type InvalidArgumentException struct {
+	LogicException
+}
+
+type InvalidArgumentExceptionInterface interface {
+	LogicExceptionInterface
+	asInvalidArgumentException() *InvalidArgumentException
+}
+
+func NewInvalidArgumentException(message string, code int, previous Throwable) *InvalidArgumentException {
+	e := &InvalidArgumentException{}
+	e.Construct(message, code, previous)
+	return e
+}
+
+func (e *InvalidArgumentException) asInvalidArgumentException() *InvalidArgumentException {
+	return e
+}
+
+// DomainException is thrown if a value does not adhere
+// to a defined valid data domain.
+type DomainException struct {
+	LogicException
+}
+
+type DomainExceptionInterface interface {
+	LogicExceptionInterface
+	asDomainException() *DomainException
+}
+
+func NewDomainException(message string, code int, previous Throwable) *DomainException {
+	e := &DomainException{}
+	e.Construct(message, code, previous)
+	return e
+}
+
+func (e *DomainException) asDomainException() *DomainException {
+	return e
+}
+
+// LengthException is thrown if a length is invalid.
+type LengthException struct {
+	LogicException
+}
+
+type LengthExceptionInterface interface {
+	LogicExceptionInterface
+	asLengthException() *LengthException
+}
+
+func NewLengthException(message string, code int, previous Throwable) *LengthException {
+	e := &LengthException{}
+	e.Construct(message, code, previous)
+	return e
+}
+
+func (e *LengthException) asLengthException() *LengthException {
+	return e
+}
+
+// OutOfRangeException is thrown when an illegal index
+// was requested.
+type OutOfRangeException struct {
+	LogicException
+}
+
+type OutOfRangeExceptionInterface interface {
+	LogicExceptionInterface
+	asOutOfRangeException() *OutOfRangeException
+}
+
+func NewOutOfRangeException(message string, code int, previous Throwable) *OutOfRangeException {
+	e := &OutOfRangeException{}
+	e.Construct(message, code, previous)
+	return e
+}
+
+func (e *OutOfRangeException) asOutOfRangeException() *OutOfRangeException {
+	return e
+}
+
+// RuntimeException represents errors which can only
+// be found on runtime.
+type RuntimeException struct {
+	Exception
+}
+
+type RuntimeExceptionInterface interface {
+	ExceptionInterface
+	asRuntimeException() *RuntimeException
+}
+
+func NewRuntimeException(message string, code int, previous Throwable) *RuntimeException {
+	e := &RuntimeException{}
+	e.Construct(message, code, previous)
+	return e
+}
+
+func (e *RuntimeException) asRuntimeException() *RuntimeException {
+	return e
+}
+
+// OutOfBoundsException is thrown if a value is not
+// a valid key.
+type OutOfBoundsException struct {
+	RuntimeException
+}
+
+type OutOfBoundsExceptionInterface interface {
+	RuntimeExceptionInterface
+	asOutOfBoundsException() *OutOfBoundsException
+}
+
+func NewOutOfBoundsException(message string, code int, previous Throwable) *OutOfBoundsException {
+	e := &OutOfBoundsException{}
+	e.Construct(message, code, previous)
+	return e
+}
+
+func (e *OutOfBoundsException) asOutOfBoundsException() *OutOfBoundsException {
+	return e
+}
+
+// OverflowException is thrown when adding an element
+// to a full container.
+type OverflowException struct {
+	RuntimeException
+}
+
+type OverflowExceptionInterface interface {
+	RuntimeExceptionInterface
+	asOverflowException() *OverflowException
+}
+
+func NewOverflowException(message string, code int, previous Throwable) *OverflowException {
+	e := &OverflowException{}
+	e.Construct(message, code, previous)
+	return e
+}
+
+func (e *OverflowException) asOverflowException() *OverflowException {
+	return e
+}
+
+// RangeException is thrown to indicate range errors
+// during program execution.
+type RangeException struct {
+	RuntimeException
+}
+
+type RangeExceptionInterface interface {
+	RuntimeExceptionInterface
+	asRangeException() *RangeException
+}
+
+func NewRangeException(message string, code int, previous Throwable) *RangeException {
+	e := &RangeException{}
+	e.Construct(message, code, previous)
+	return e
+}
+
+func (e *RangeException) asRangeException() *RangeException {
+	return e
+}
+
+// UnderflowException is thrown when performing an invalid
+// operation on an empty container.
+type UnderflowException struct {
+	RuntimeException
+}
+
+type UnderflowExceptionInterface interface {
+	RuntimeExceptionInterface
+	asUnderflowException() *UnderflowException
+}
+
+func NewUnderflowException(message string, code int, previous Throwable) *UnderflowException {
+	e := &UnderflowException{}
+	e.Construct(message, code, previous)
+	return e
+}
+
+func (e *UnderflowException) asUnderflowException() *UnderflowException {
+	return e
+}
+
+// UnexpectedValueException is thrown if a value does not
+// match with a set of values.
+type UnexpectedValueException struct {
+	RuntimeException
+}
+
+type UnexpectedValueExceptionInterface interface {
+	RuntimeExceptionInterface
+	asUnexpectedValueException() *UnexpectedValueException
+}
+
+func NewUnexpectedValueException(message string, code int, previous Throwable) *UnexpectedValueException {
+	e := &UnexpectedValueException{}
+	e.Construct(message, code, previous)
+	return e
+}
+
+func (e *UnexpectedValueException) asUnexpectedValueException() *UnexpectedValueException {
+	return e
+}
@@ -0,0 +1,270 @@
+package std
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Sprintf formats the values the same way as PHP sprintf. The
+// directives are like "%'*10.2f", with the argument number,
+// flags, width, precision and the specifier. Invalid formats
+// and missing values are passed to panic, as PHP throws
+// ValueError and ArgumentCountError.
+func Sprintf(format string, args ...interface{}) string {
+	b := strings.Builder{}
+	next := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
+		}
+		i++
+		if i < len(format) && format[i] == '%' {
+			b.WriteByte('%')
+			continue
+		}
+
+		d := directive{pad: ' ', precision: -1}
+		arg := next
+		if n, l := digits(format[i:]); l > 0 && i+l < len(format) && format[i+l] == '$' {
+			if n <= 0 {
+				panic(errors.New("Argument number specifier must be greater than zero and less than 2147483647"))
+			}
+			arg = n - 1
+			i += l + 1
+		} else {
+			next++
+		}
+		i = d.parse(format, i)
+		if i >= len(format) {
+			panic(errors.New("Missing format specifier at end of string"))
+		}
+		if arg >= len(args) {
+			panic(fmt.Errorf("%d arguments are required, %d given", arg+2, len(args)+1))
+		}
+		d.format(&b, format[i], args[arg])
+	}
+	return b.String()
+}
+
+// directive is the parsed part of the format between
+// the percent sign and the specifier.
+type directive struct {
+	left      bool
+	sign      bool
+	pad       byte
+	width     int
+	precision int
+}
+
+// parse reads flags, width and precision, position
+// of the specifier is returned.
+func (d *directive) parse(format string, i int) int {
+flags:
+	for ; i < len(format); i++ {
+		switch format[i] {
+		case '-':
+			d.left = true
+		case '+':
+			d.sign = true
+		case '0', ' ':
+			d.pad = format[i]
+		case '\'':
+			if i+1 < len(format) {
+				i++
+				d.pad = format[i]
+			}
+		default:
+			break flags
+		}
+	}
+	n, l := digits(format[i:])
+	d.width = n
+	i += l
+	if i < len(format) && format[i] == '.' {
+		n, l = digits(format[i+1:])
+		d.precision = n
+		i += l + 1
+	}
+	return i
+}
+
+func digits(s string) (int, int) {
+	n, l := 0, 0
+	for l < len(s) && isDigit(s[l]) {
+		n = n*10 + int(s[l]-'0')
+		l++
+	}
+	return n, l
+}
+
+func (d directive) format(b *strings.Builder, spec byte, v interface{}) {
+	switch spec {
+	case 's':
+		s := ToString(v)
+		if d.precision >= 0 && d.precision < len(s) {
+			s = s[:d.precision]
+		}
+		d.append(b, s, false)
+
+	case 'd':
+		i := ToInt(v)
+		s := strconv.Itoa(i)
+		if d.sign && i >= 0 {
+			s = "+" + s
+		}
+		// Ints are not padded by zeros on the right.
+		if d.left && d.pad == '0' {
+			d.pad = ' '
+		}
+		d.append(b, s, i < 0 || d.sign)
+
+	case 'u':
+		d.append(b, strconv.FormatUint(uint64(ToInt(v)), 10), false)
+	case 'b':
+		d.append(b, strconv.FormatUint(uint64(ToInt(v)), 2), false)
+	case 'o':
+		d.append(b, strconv.FormatUint(uint64(ToInt(v)), 8), false)
+	case 'x':
+		d.append(b, strconv.FormatUint(uint64(ToInt(v)), 16), false)
+	case 'X':
+		d.append(b, strings.ToUpper(strconv.FormatUint(uint64(ToInt(v)), 16)), false)
+
+	case 'c':
+		b.WriteByte(byte(ToInt(v)))
+
+	case 'e', 'E', 'f', 'F', 'g', 'G':
+		f := ToFloat64(v)
+		var s string
+		switch {
+		case math.IsNaN(f):
+			s = "NaN"
+		case math.IsInf(f, 0):
+			s = "Inf"
+		default:
+			s = d.float(spec, math.Abs(f))
+		}
+		neg := math.Signbit(f) && !math.IsNaN(f)
+		if neg {
+			s = "-" + s
+		} else if d.sign {
+			s = "+" + s
+		}
+		d.append(b, s, neg || d.sign)
+
+	default:
+		panic(fmt.Errorf("Unknown format specifier \"%c\"", spec))
+	}
+}
+
+// float formats the absolute value of the float.
+func (d directive) float(spec byte, f float64) string {
+	p := d.precision
+	if p < 0 {
+		p = 6
+	}
+	switch spec {
+	case 'f', 'F':
+		// PHP rounds halves up, like round.
+		return strconv.FormatFloat(roundPlaces(f, p), 'f', p, 64)
+	case 'e', 'E':
+		s := strconv.FormatFloat(f, 'e', p, 64)
+		i := strings.IndexByte(s, 'e')
+		return s[:i] + string(spec) + exponent(s[i+1:])
+	}
+
+	// PHP uses the exponent when the number is too long,
+	// the mantissa keeps the decimal point, like "1.0e+6".
+	if p == 0 {
+		p = 1
+	}
+	s := strconv.FormatFloat(f, 'e', p-1, 64)
+	i := strings.IndexByte(s, 'e')
+	m := strings.TrimRight(strings.Replace(s[:i], ".", "", 1), "0")
+	e, _ := strconv.Atoi(s[i+1:])
+	if m == "" {
+		m, e = "0", 0
+	}
+	point := e + 1
+	switch {
+	case point < -3 || point > p:
+		if len(m) == 1 {
+			m += "0"
+		}
+		return m[:1] + "." + m[1:] + string(spec-'g'+'e') + exponent(s[i+1:])
+	case point <= 0:
+		return "0." + strings.Repeat("0", -point) + m
+	case point >= len(m):
+		return m + strings.Repeat("0", point-len(m))
+	}
+	return m[:point] + "." + m[point:]
+}
+
+// exponent drops leading zeros of the exponent.
+func exponent(e string) string {
+	d := strings.TrimLeft(e[1:], "0")
+	if d == "" {
+		d = "0"
+	}
+	return e[:1] + d
+}
+
+// append pads the string to the width. Zeros
+// are added after the sign of the number.
+func (d directive) append(b *strings.Builder, s string, signed bool) {
+	n := d.width - len(s)
+	if n < 0 {
+		n = 0
+	}
+	if !d.left {
+		if signed && d.pad == '0' {
+			b.WriteByte(s[0])
+			s = s[1:]
+		}
+		b.WriteString(strings.Repeat(string(d.pad), n))
+	}
+	b.WriteString(s)
+	if d.left {
+		b.WriteString(strings.Repeat(string(d.pad), n))
+	}
+}
+
+// NumberFormat formats the number rounded to the decimals with
+// thousands separated. It does the same thing as PHP number_format.
+func NumberFormat(num float64, decimals int, decPoint, thousandsSep string) string {
+	switch {
+	case math.IsNaN(num):
+		return "nan"
+	case math.IsInf(num, 1):
+		return "inf"
+	case math.IsInf(num, -1):
+		return "-inf"
+	}
+
+	num = roundPlaces(num, decimals)
+	if decimals < 0 {
+		decimals = 0
+	}
+	s := strconv.FormatFloat(math.Abs(num), 'f', decimals, 64)
+	frac := ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		s, frac = s[:i], decPoint+s[i+1:]
+	}
+
+	b := strings.Builder{}
+	// Rounded zero has no sign.
+	if num < 0 {
+		b.WriteByte('-')
+	}
+	for i := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteString(thousandsSep)
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteString(frac)
+	return b.String()
+}
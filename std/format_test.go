@@ -0,0 +1,69 @@
+package std
+
+import "testing"
+
+func TestSprintf(t *testing.T) {
+	// PHP printf differs from Go: custom padding with ', exponents
+	// without leading zeros, %u of negative ints, rounding half up.
+	cases := []struct {
+		format string
+		args   []interface{}
+		out    string
+	}{
+		{"%s and %d%%", []interface{}{"a", "12abc"}, "a and 12%"},
+		{"%05.2f|%-6s|%6s|%'*8d", []interface{}{3.14159, "ab", "cd", 42}, "03.14|ab    |    cd|******42"},
+		{"%+d|%+d|%05d|%-05d|%+05d", []interface{}{7, -7, -42, -42, 42}, "+7|-7|-0042|-42  |+0042"},
+		{"%x|%X|%o|%b|%c|%u", []interface{}{255, 255, 8, 5, 65, -1}, "ff|FF|10|101|A|18446744073709551615"},
+		{"%e|%.2E|%10.3e|%e", []interface{}{1234.5, 0.000123, -0.000123, 0}, "1.234500e+3|1.23E-4| -1.230e-4|0.000000e+0"},
+		{"%g|%g|%g|%g|%G|%g", []interface{}{0.00001234, 0.0001, 1000000, 100000, 1e-10, 0}, "1.234e-5|0.0001|1.0e+6|100000|1.0E-10|0"},
+		{"%.1f|%.0f|%.2f|%f|%F", []interface{}{0.05, 2.5, 1.005, -1, 1.5}, "0.1|3|1.01|-1.000000|1.500000"},
+		{"%2$s %1$s %s", []interface{}{"a", "b"}, "b a a"},
+		{"%.3s|%5.1s|%-'#5s", []interface{}{"abcdef", "xyz", "ab"}, "abc|    x|ab###"},
+		{"%d|%s|%f", []interface{}{true, false, "1.5"}, "1||1.500000"},
+	}
+	for _, c := range cases {
+		if out := Sprintf(c.format, c.args...); out != c.out {
+			t.Errorf("%s: '%s' expected, '%s' found.", c.format, c.out, out)
+		}
+	}
+
+	for _, format := range []string{"%d %d", "%y", "%5"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s should panic.", format)
+				}
+			}()
+			Sprintf(format, 1)
+		}()
+	}
+}
+
+func TestNumberFormat(t *testing.T) {
+	cases := []struct {
+		num      float64
+		decimals int
+		point    string
+		sep      string
+		out      string
+	}{
+		{1234567.891, 0, ".", ",", "1,234,568"},
+		{1234567.891, 2, ".", ",", "1,234,567.89"},
+		{1234567.891, 2, ",", ".", "1.234.567,89"},
+		{1234.5, 1, " dot ", "", "1234 dot 5"},
+		{0.285, 2, ".", ",", "0.29"},
+		{2.675, 2, ".", ",", "2.68"},
+		{1.005, 2, ".", ",", "1.01"},
+		{-0.004, 2, ".", ",", "0.00"},
+		{-1234.567, 1, ".", ",", "-1,234.6"},
+		{0.5, 0, ".", ",", "1"},
+		{-2.5, 0, ".", ",", "-3"},
+		{1234.5, -2, ".", ",", "1,200"},
+		{100, 2, ".", ",", "100.00"},
+	}
+	for _, c := range cases {
+		if out := NumberFormat(c.num, c.decimals, c.point, c.sep); out != c.out {
+			t.Errorf("%v, %d: '%s' expected, '%s' found.", c.num, c.decimals, c.out, out)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package std
+
+import (
+	"errors"
+	"math"
+
+	"github.com/lSimul/php2go/std/array"
+)
+
+// IntMin is the least int, like PHP_INT_MIN.
+const IntMin = math.MinInt64
+
+// FloatEpsilon is the smallest x, such that 1 + x != 1,
+// like PHP_FLOAT_EPSILON.
+const FloatEpsilon = 2.220446049250313e-16
+
+// AbsInt returns the absolute value of the int. PHP turns
+// the smallest int to a float, it stays negative here.
+func AbsInt(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+// Round rounds the float to the precision, halves away from zero.
+// Negative precision rounds to tens and so on. It does the same
+// thing as PHP round.
+func Round(f float64, precision int) float64 {
+	return roundPlaces(f, precision)
+}
+
+var (
+	errDivisionByZero = errors.New("Division by zero")
+	errIntdiv         = errors.New("Division of PHP_INT_MIN by -1 is not an integer")
+)
+
+// Intdiv divides ints, the result is truncated. Division by zero
+// is passed to panic, as PHP throws DivisionByZeroError.
+func Intdiv(a, b int) int {
+	switch {
+	case b == 0:
+		panic(errDivisionByZero)
+	case a == math.MinInt64 && b == -1:
+		panic(errIntdiv)
+	}
+	return a / b
+}
+
+// PowInt raises the int to the power of the int. The result is
+// an int, unless it overflows or the exponent is negative, it is
+// a float then. It does the same thing as PHP pow.
+func PowInt(base, exp int) interface{} {
+	if exp < 0 {
+		return math.Pow(float64(base), float64(exp))
+	}
+	// Squaring like PHP, so overflowing results are the same.
+	res := 1
+	for exp > 0 {
+		if exp%2 == 1 {
+			exp--
+			r, ok := mulInt(res, base)
+			if !ok {
+				return float64(res) * float64(base) * math.Pow(float64(base), float64(exp))
+			}
+			res = r
+		} else {
+			exp /= 2
+			b, ok := mulInt(base, base)
+			if !ok {
+				return float64(res) * math.Pow(float64(base)*float64(base), float64(exp))
+			}
+			base = b
+		}
+	}
+	return res
+}
+
+// mulInt multiplies the ints, ok is false when they overflow.
+func mulInt(a, b int) (int, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	r := a * b
+	if r/b != a || a == -1 && b == math.MinInt64 || b == -1 && a == math.MinInt64 {
+		return 0, false
+	}
+	return r, true
+}
+
+// Min returns the least value, values are compared like
+// by PHP comparison operators. It does the same thing
+// as PHP min.
+func Min(vals ...interface{}) interface{} {
+	return least("min", -1, vals)
+}
+
+// Max returns the greatest value, like Min.
+func Max(vals ...interface{}) interface{} {
+	return least("max", 1, vals)
+}
+
+// MinArray returns the least item of the array,
+// like PHP min with an array.
+func MinArray(a array.Reader) interface{} {
+	_, vals := a.Entries()
+	return least("min", -1, vals)
+}
+
+// MaxArray returns the greatest item of the array,
+// like PHP max with an array.
+func MaxArray(a array.Reader) interface{} {
+	_, vals := a.Entries()
+	return least("max", 1, vals)
+}
+
+// least returns the first value, which is the least one
+// in the order, empty values are passed to panic as PHP
+// throws ValueError.
+func least(fn string, order int, vals []interface{}) interface{} {
+	if len(vals) == 0 {
+		panic(errors.New(fn + "(): Argument #1 ($value) must contain at least one element"))
+	}
+	res := vals[0]
+	for _, v := range vals[1:] {
+		if Compare(v, res) == order {
+			res = v
+		}
+	}
+	return res
+}
+
+// MinInts returns the least int.
+func MinInts(first int, rest ...int) int {
+	for _, i := range rest {
+		if i < first {
+			first = i
+		}
+	}
+	return first
+}
+
+// MaxInts returns the greatest int.
+func MaxInts(first int, rest ...int) int {
+	for _, i := range rest {
+		if i > first {
+			first = i
+		}
+	}
+	return first
+}
+
+// MinFloats returns the least float, NaN is
+// skipped unless it is the first one.
+func MinFloats(first float64, rest ...float64) float64 {
+	for _, f := range rest {
+		if f < first {
+			first = f
+		}
+	}
+	return first
+}
+
+// MaxFloats returns the greatest float, like MinFloats.
+func MaxFloats(first float64, rest ...float64) float64 {
+	for _, f := range rest {
+		if f > first {
+			first = f
+		}
+	}
+	return first
+}
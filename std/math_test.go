@@ -0,0 +1,99 @@
+package std
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lSimul/php2go/std/array"
+)
+
+func TestPowInt(t *testing.T) {
+	// ** of ints is an int while it fits, a float otherwise,
+	// negative exponents give floats too.
+	cases := []struct {
+		base, exp int
+		out       interface{}
+	}{
+		{2, 10, 1024},
+		{-3, 3, -27},
+		{5, 0, 1},
+		{0, 5, 0},
+		{2, 62, 1 << 62},
+		{-2, 63, math.MinInt64},
+		{2, 63, 9.223372036854775808e18},
+		{-2, 64, 1.8446744073709552e19},
+		{10, 20, 1e20},
+		{2, -1, 0.5},
+	}
+	for _, c := range cases {
+		if out := PowInt(c.base, c.exp); out != c.out {
+			t.Errorf("%d ** %d: %v expected, %v found.", c.base, c.exp, c.out, out)
+		}
+	}
+}
+
+func TestIntdiv(t *testing.T) {
+	if i := Intdiv(-7, 2); i != -3 {
+		t.Errorf("-3 expected, %d found.", i)
+	}
+	for _, c := range [][2]int{{1, 0}, {math.MinInt64, -1}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d / %d should panic.", c[0], c[1])
+				}
+			}()
+			Intdiv(c[0], c[1])
+		}()
+	}
+}
+
+func TestRound(t *testing.T) {
+	cases := []struct {
+		f         float64
+		precision int
+		out       float64
+	}{
+		{2.5, 0, 3},
+		{-2.5, 0, -3},
+		{1.955, 2, 1.96},
+		{5.045, 2, 5.05},
+		{5.055, 2, 5.06},
+		{1234.5678, -2, 1200},
+		{0.285, 2, 0.29},
+		{1e20, 2, 1e20},
+	}
+	for _, c := range cases {
+		if out := Round(c.f, c.precision); out != c.out {
+			t.Errorf("%v, %d: %v expected, %v found.", c.f, c.precision, c.out, out)
+		}
+	}
+}
+
+func TestMinMax(t *testing.T) {
+	cases := []struct {
+		out, exp interface{}
+	}{
+		{Min(3, "2", 4.5), "2"},
+		{Max(1, 2.5), 2.5},
+		{Max("apple", "banana"), "banana"},
+		{Max(1, 1.0), 1},
+		{Min("10", 9), 9},
+		{MinArray(array.NewInt(4, 2, 8)), 2},
+		{MaxArray(array.NewString("a", "c", "b")), "c"},
+		{MinInts(3, 1, 2), 1},
+		{MaxFloats(1.5, -2, 2.5), 2.5},
+	}
+	for i, c := range cases {
+		if c.out != c.exp {
+			t.Errorf("%d. %v expected, %v found.", i, c.exp, c.out)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Empty array should panic.")
+		}
+	}()
+	MaxArray(array.NewInt())
+}
@@ -0,0 +1,92 @@
+package std
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/lSimul/php2go/std/array"
+)
+
+// Level is the severity of the reported problem,
+// PHP continues running after any of them.
+type Level int
+
+const (
+	Notice Level = iota
+	Warning
+	Deprecated
+)
+
+func (l Level) String() string {
+	switch l {
+	case Notice:
+		return "Notice"
+	case Warning:
+		return "Warning"
+	case Deprecated:
+		return "Deprecated"
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// Handler handles notices, warnings and deprecations,
+// like undefined indexes of arrays.
+type Handler interface {
+	Handle(l Level, msg string)
+}
+
+// HandlerFunc is a function used as a Handler.
+type HandlerFunc func(l Level, msg string)
+
+func (f HandlerFunc) Handle(l Level, msg string) {
+	f(l, msg)
+}
+
+// Writer writes the messages the same way PHP does,
+// like "Notice: Undefined index: a".
+type Writer struct {
+	W io.Writer
+}
+
+func (w Writer) Handle(l Level, msg string) {
+	fmt.Fprintf(w.W, "%s: %s\n", l, msg)
+}
+
+// NoticeError is passed to panic by Strict.
+type NoticeError struct {
+	Level   Level
+	Message string
+}
+
+func (e *NoticeError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Level, e.Message)
+}
+
+// Strict panics on every message, so sloppy code is
+// found early. Builds with the strict tag use it.
+var Strict = HandlerFunc(func(l Level, msg string) {
+	panic(&NoticeError{l, msg})
+})
+
+// handler writes to stderr unless it is replaced,
+// see notice_strict.go for strict builds.
+var handler Handler = Writer{os.Stderr}
+
+func init() {
+	array.Notice = func(msg string) { Report(Notice, msg) }
+	array.Deprecated = func(msg string) { Report(Deprecated, msg) }
+}
+
+// SetHandler replaces the handler of the messages,
+// the previous one is returned.
+func SetHandler(h Handler) Handler {
+	prev := handler
+	handler = h
+	return prev
+}
+
+// Report passes the message to the handler.
+func Report(l Level, msg string) {
+	handler.Handle(l, msg)
+}
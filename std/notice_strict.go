@@ -0,0 +1,10 @@
+//go:build strict
+// +build strict
+
+package std
+
+// Strict builds panic on notices, build them
+// by "go build -tags strict".
+func init() {
+	handler = Strict
+}
@@ -0,0 +1,43 @@
+package std
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lSimul/php2go/std/array"
+)
+
+func TestNotice(t *testing.T) {
+	var b bytes.Buffer
+	defer SetHandler(SetHandler(Writer{&b}))
+
+	a := array.NewInt(1)
+	if v := a.At(array.NewScalar("x")); v != 0 {
+		t.Errorf("Zero expected for undefined index, %d found.", v)
+	}
+	s := array.NewString("a")
+	if v := s.At(array.NewScalar(5)); v != "" {
+		t.Errorf("Empty string expected for undefined index, '%s' found.", v)
+	}
+	array.NewScalar(1.5)
+
+	exp := "Notice: Undefined index: x\n" +
+		"Notice: Undefined index: 5\n" +
+		"Deprecated: Implicit conversion from float 1.5 to int loses precision\n"
+	if b.String() != exp {
+		t.Errorf("'%s' expected, '%s' found.", exp, b.String())
+	}
+}
+
+func TestStrict(t *testing.T) {
+	defer SetHandler(SetHandler(Strict))
+	defer func() {
+		e, ok := recover().(*NoticeError)
+		if !ok || e.Level != Notice || e.Message != "Undefined index: x" {
+			t.Errorf("NoticeError expected, %v found.", e)
+		}
+	}()
+	a := array.NewInt()
+	a.At(array.NewScalar("x"))
+	t.Error("Strict handler should panic.")
+}
@@ -1,44 +1,227 @@
 package std
 
 import (
-	"regexp"
+	"math"
 	"strconv"
+	"strings"
+
+	"github.com/lSimul/php2go/std/array"
 )
 
-// ToInt transfers anything to int.
-// The main part is conversion from
-// string to int. It does the same thing
-// as (int) in PHP, that means it tries to
-// parse it as long it looks like an int.
-// BUG(ls): it does not support diferent
-// bases like HEX etc.
+// ToInt transfers anything to int, it does the same thing
+// as (int) in PHP. Strings are parsed as long as they look
+// like a number, "12abc" is 12 and "abc12" is 0.
 func ToInt(s interface{}) int {
 	switch s := s.(type) {
 	case int:
 		return s
 
 	case bool:
-		if s {
-			return 1
-		}
-		return 0
+		return BoolToInt(s)
 
 	case string:
-		if len(s) == 0 {
-			return 0
+		n, _ := numericPrefix(s)
+		if n.isFloat {
+			return floatToIntCapped(n.f)
 		}
-		r, _ := regexp.Compile("[0-9]+")
-		s = r.FindString(s)
-		res, _ := strconv.Atoi(s)
-		return res
+		return n.i
 
 	case float32:
-		return int(s)
+		return floatToInt(float64(s))
 	case float64:
-		return int(s)
+		return floatToInt(s)
+
+	case array.Reader:
+		if s.Count() > 0 {
+			return 1
+		}
+		return 0
 	}
 	if s == nil {
 		return 0
 	}
 	return 1
 }
+
+// two64 is 2^64, floats out of the int range wrap around it.
+const two64 = 1 << 64
+
+// floatToInt truncates the float, values out of the int
+// range wrap around like in PHP 7 and later. NaN and
+// infinities are zero.
+func floatToInt(f float64) int {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	if f >= math.MinInt64 && f < math.MaxInt64 {
+		return int(f)
+	}
+	m := math.Mod(math.Trunc(f), two64)
+	if m < 0 {
+		m += two64
+	}
+	return int(uint64(m))
+}
+
+// floatToIntCapped is used for numeric strings, which are
+// out of the int range, PHP saturates them instead.
+func floatToIntCapped(f float64) int {
+	switch {
+	case math.IsNaN(f):
+		return 0
+	case f >= math.MaxInt64:
+		return math.MaxInt64
+	case f <= math.MinInt64:
+		return math.MinInt64
+	}
+	return int(f)
+}
+
+// number is a value of the numeric string, PHP keeps
+// integers unless they do not fit into int.
+type number struct {
+	i       int
+	f       float64
+	isFloat bool
+}
+
+// numericPrefix parses the number at the beginning of the string,
+// leading whitespace is skipped. Length of the parsed part is
+// returned, it is zero when the string does not start with a number.
+//
+// See php.net/manual/en/language.types.numeric-strings.php
+// for more details.
+func numericPrefix(s string) (number, int) {
+	i := 0
+	for i < len(s) && isSpace(s[i]) {
+		i++
+	}
+	start := i
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	digits := i
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	intEnd := i
+	isFloat := false
+	if i < len(s) && s[i] == '.' {
+		j := i + 1
+		for j < len(s) && isDigit(s[j]) {
+			j++
+		}
+		// Either side of the dot has to have digits.
+		if j > i+1 || intEnd > digits {
+			i = j
+			isFloat = true
+		}
+	}
+	if i == digits {
+		return number{}, 0
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && isDigit(s[j]) {
+			for j < len(s) && isDigit(s[j]) {
+				j++
+			}
+			i = j
+			isFloat = true
+		}
+	}
+
+	if !isFloat {
+		if n, err := strconv.Atoi(s[start:i]); err == nil {
+			return number{i: n}, i
+		}
+	}
+	f, _ := strconv.ParseFloat(s[start:i], 64)
+	return number{f: f, isFloat: true}, i
+}
+
+// numericString parses the string, which is numeric
+// as a whole. Trailing whitespace is allowed.
+func numericString(s string) (number, bool) {
+	n, l := numericPrefix(s)
+	if l == 0 {
+		return n, false
+	}
+	for _, c := range []byte(s[l:]) {
+		if !isSpace(c) {
+			return n, false
+		}
+	}
+	return n, true
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// formatFloat formats the float the same way as PHP does,
+// with the precision of 14 digits.
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "INF"
+	case math.IsInf(f, -1):
+		return "-INF"
+	case math.IsNaN(f):
+		return "NAN"
+	}
+
+	s := strconv.FormatFloat(f, 'G', 14, 64)
+	i := strings.IndexByte(s, 'E')
+	if i < 0 {
+		return s
+	}
+	// PHP keeps the decimal point in the mantissa
+	// and drops leading zeros of the exponent.
+	m, e := s[:i], s[i+1:]
+	if !strings.Contains(m, ".") {
+		m += ".0"
+	}
+	return m + "E" + e[:1] + strings.TrimLeft(e[1:], "0")
+}
+
+// IntMax is the greatest int, like PHP_INT_MAX. It is the
+// default length of substrings, it covers the rest of them.
+const IntMax = math.MaxInt64
+
+// roundPlaces rounds the float to the decimal places, halves
+// away from zero. Negative places round to tens and so on.
+// PHP rounds the decimal form with 15 significant digits,
+// so 0.285 is 0.29, unlike its binary value 0.28499...
+func roundPlaces(f float64, places int) float64 {
+	if math.IsNaN(f) || math.IsInf(f, 0) || f == 0 {
+		return f
+	}
+	s := strconv.FormatFloat(math.Abs(f), 'e', 14, 64)
+	digits := s[:1] + s[2:16]
+	exp, _ := strconv.Atoi(s[17:])
+
+	keep := exp + 1 + places
+	switch {
+	case keep < 0:
+		return math.Copysign(0, f)
+	case keep >= len(digits):
+		return f
+	}
+	n := 0
+	if keep > 0 {
+		n, _ = strconv.Atoi(digits[:keep])
+	}
+	if digits[keep] >= '5' {
+		n++
+	}
+	r, _ := strconv.ParseFloat(strconv.Itoa(n)+"e"+strconv.Itoa(-places), 64)
+	return math.Copysign(r, f)
+}
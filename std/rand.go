@@ -0,0 +1,169 @@
+package std
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sync"
+)
+
+// twister is Mersenne Twister MT19937, the generator of PHP
+// mt_rand. Sequences of the same seeds are the same as in PHP.
+type twister struct {
+	mu     sync.Mutex
+	state  [624]uint32
+	next   int
+	seeded bool
+}
+
+// mt is the generator used by MtRand and Rand.
+var mt = &twister{}
+
+func (t *twister) seed(s uint32) {
+	t.state[0] = s
+	for i := 1; i < len(t.state); i++ {
+		p := t.state[i-1]
+		t.state[i] = 1812433253*(p^(p>>30)) + uint32(i)
+	}
+	t.reload()
+	t.seeded = true
+}
+
+func (t *twister) reload() {
+	const n, m = 624, 397
+	s := &t.state
+	for i := 0; i < n; i++ {
+		y := s[i]&0x80000000 | s[(i+1)%n]&0x7fffffff
+		s[i] = s[(i+m)%n] ^ y>>1
+		if s[(i+1)%n]&1 == 1 {
+			s[i] ^= 0x9908b0df
+		}
+	}
+	t.next = 0
+}
+
+func (t *twister) uint32() uint32 {
+	if !t.seeded {
+		t.seed(randomSeed())
+	}
+	if t.next == len(t.state) {
+		t.reload()
+	}
+	y := t.state[t.next]
+	t.next++
+	y ^= y >> 11
+	y ^= y << 7 & 0x9d2c5680
+	y ^= y << 15 & 0xefc60000
+	return y ^ y>>18
+}
+
+// between returns the number from min to max, both are included.
+// Numbers out of the uniform range are dropped, like in PHP.
+func (t *twister) between(min, max int) int {
+	umax := uint64(max) - uint64(min)
+	var r uint64
+	if umax > math.MaxUint32 {
+		next := func() uint64 { return uint64(t.uint32())<<32 | uint64(t.uint32()) }
+		r = next()
+		if umax != math.MaxUint64 {
+			umax++
+			if umax&(umax-1) != 0 {
+				limit := math.MaxUint64 - math.MaxUint64%umax - 1
+				for r > limit {
+					r = next()
+				}
+			}
+			r %= umax
+		}
+	} else {
+		r = uint64(t.uint32())
+		if umax != math.MaxUint32 {
+			umax++
+			if umax&(umax-1) != 0 {
+				limit := uint64(math.MaxUint32 - math.MaxUint32%umax - 1)
+				for r > limit {
+					r = uint64(t.uint32())
+				}
+			}
+			r %= umax
+		}
+	}
+	return int(uint64(min) + r)
+}
+
+func randomSeed() uint32 {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+// MtSrand seeds the generator of MtRand and Rand,
+// like PHP mt_srand.
+func MtSrand(seed int) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	mt.seed(uint32(seed))
+}
+
+// MtSrandRandom seeds the generator by a random seed,
+// like PHP mt_srand without arguments.
+func MtSrandRandom() {
+	MtSrand(int(randomSeed()))
+}
+
+// MtRand returns a random number from 0 to 2^31 - 1,
+// like PHP mt_rand without arguments.
+func MtRand() int {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	return int(mt.uint32() >> 1)
+}
+
+// MtRandRange returns a random number from min to max. Greater
+// min is passed to panic, as PHP mt_rand throws ValueError.
+func MtRandRange(min, max int) int {
+	if max < min {
+		panic(errors.New("mt_rand(): Argument #2 ($max) must be greater than or equal to argument #1 ($min)"))
+	}
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	return mt.between(min, max)
+}
+
+// RandRange is MtRandRange, bounds can be swapped,
+// like in PHP rand.
+func RandRange(min, max int) int {
+	if max < min {
+		min, max = max, min
+	}
+	return MtRandRange(min, max)
+}
+
+// RandomInt returns a cryptographically secure random number
+// from min to max. Greater min is passed to panic, as PHP
+// random_int throws ValueError.
+func RandomInt(min, max int) int {
+	if min > max {
+		panic(errors.New("random_int(): Argument #1 ($min) must be less than or equal to argument #2 ($max)"))
+	}
+	umax := uint64(max) - uint64(min)
+	b := make([]byte, 8)
+	for {
+		if _, err := rand.Read(b); err != nil {
+			panic(err)
+		}
+		r := binary.LittleEndian.Uint64(b)
+		if umax == math.MaxUint64 {
+			return int(uint64(min) + r)
+		}
+		// Drops the numbers out of the uniform range,
+		// 2^64 % n of them.
+		n := umax + 1
+		if r <= math.MaxUint64-(math.MaxUint64%n+1)%n {
+			return int(uint64(min) + r%n)
+		}
+	}
+}
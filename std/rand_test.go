@@ -0,0 +1,41 @@
+package std
+
+import "testing"
+
+func TestMtRand(t *testing.T) {
+	// PHP 7.1 and later returns the same numbers.
+	MtSrand(1)
+	for _, exp := range []int{895547922, 2141438069} {
+		if i := MtRand(); i != exp {
+			t.Errorf("%d expected, %d found.", exp, i)
+		}
+	}
+
+	MtSrand(3)
+	first := MtRandRange(1, 6)
+	MtSrand(3)
+	if i := MtRandRange(1, 6); i != first {
+		t.Errorf("Same seeds should give the same numbers, %d and %d found.", first, i)
+	}
+
+	for i := 0; i < 1000; i++ {
+		if r := RandRange(10, 1); r < 1 || r > 10 {
+			t.Fatalf("%d is out of the range.", r)
+		}
+		if r := MtRandRange(-5, IntMax); r < -5 {
+			t.Fatalf("%d is out of the range.", r)
+		}
+		if r := RandomInt(-3, 3); r < -3 || r > 3 {
+			t.Fatalf("%d is out of the range.", r)
+		}
+	}
+	// Full range has no rejected numbers.
+	RandomInt(IntMin, IntMax)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Greater min should panic.")
+		}
+	}()
+	MtRandRange(2, 1)
+}
@@ -0,0 +1,63 @@
+package std
+
+import "strings"
+
+// Flags of PHP sort functions, SortFlagCase
+// can be combined with SortString and SortNatural.
+const (
+	SortRegular      = 0
+	SortNumeric      = 1
+	SortString       = 2
+	SortLocaleString = 5
+	SortNatural      = 6
+	SortFlagCase     = 8
+)
+
+// Sorter returns the comparison used by PHP sort functions with
+// the flags. Reversed one is used by rsort, arsort and krsort.
+//
+// See php.net/manual/en/function.sort.php
+// for more details.
+func Sorter(flags int, reverse bool) func(x, y interface{}) int {
+	var cmp func(x, y interface{}) int
+	fold := flags&SortFlagCase != 0
+	switch flags &^ SortFlagCase {
+	case SortNumeric:
+		cmp = func(x, y interface{}) int {
+			return compareNumbers(toNumeric(x), toNumeric(y))
+		}
+
+	case SortString, SortLocaleString:
+		cmp = func(x, y interface{}) int {
+			a, b := ToString(x), ToString(y)
+			if fold {
+				a, b = strings.ToLower(a), strings.ToLower(b)
+			}
+			return strings.Compare(a, b)
+		}
+
+	case SortNatural:
+		cmp = func(x, y interface{}) int {
+			return natCompare(ToString(x), ToString(y), fold)
+		}
+
+	default:
+		cmp = Compare
+	}
+
+	if reverse {
+		return func(x, y interface{}) int {
+			return cmp(y, x)
+		}
+	}
+	return cmp
+}
+
+// toNumeric converts the value to int or float,
+// the same way as PHP arithmetic does.
+func toNumeric(x interface{}) number {
+	if i, ok := x.(int); ok {
+		return number{i: i}
+	}
+	return number{f: ToFloat64(x), isFloat: true}
+}
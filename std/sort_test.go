@@ -0,0 +1,63 @@
+package std
+
+import (
+	"testing"
+
+	"github.com/lSimul/php2go/std/array"
+)
+
+func TestSort(t *testing.T) {
+	// Expected orders are results of PHP 8.
+	cases := []struct {
+		flags   int
+		reverse bool
+		in, out []interface{}
+	}{
+		{SortRegular, false, []interface{}{3, "10", 1, "2", 2.5}, []interface{}{1, "2", 2.5, 3, "10"}},
+		{SortRegular, true, []interface{}{3, "10", 1, "2", 2.5}, []interface{}{"10", 3, 2.5, "2", 1}},
+		{SortNumeric, false, []interface{}{"10", "9", "1e1", "abc"}, []interface{}{"abc", "9", "10", "1e1"}},
+		{SortString, false, []interface{}{10, 9, "a", "B"}, []interface{}{10, 9, "B", "a"}},
+		{SortString | SortFlagCase, false, []interface{}{"b", "A", "a", "B"}, []interface{}{"A", "a", "b", "B"}},
+		{SortNatural, false, []interface{}{"img12", "img10", "IMG2", "img1"}, []interface{}{"IMG2", "img1", "img10", "img12"}},
+		{SortNatural | SortFlagCase, false, []interface{}{"img12", "img10", "IMG2", "img1"}, []interface{}{"img1", "IMG2", "img10", "img12"}},
+	}
+	for _, c := range cases {
+		a := array.NewAny(c.in...)
+		a.Sort(Sorter(c.flags, c.reverse), false, false)
+		for i, v := range a.Iter() {
+			if v != c.out[i] {
+				t.Errorf("Flags %d: %v expected, %v found.", c.flags, c.out, a.Iter())
+				break
+			}
+		}
+	}
+}
+
+func TestSortKeys(t *testing.T) {
+	a := array.NewString()
+	a.Edit(array.NewScalar("peter"), "35")
+	a.Edit(array.NewScalar(10), "37")
+	a.Edit(array.NewScalar(9), "20")
+
+	check := func(fn string, exp ...string) {
+		t.Helper()
+		i := 0
+		for _, p := range a.KeyIter() {
+			if i >= len(exp) || string(p.K)+"="+p.V != exp[i] {
+				t.Errorf("%s: %v expected, %v found.", fn, exp, a.KeyIter())
+				return
+			}
+			i++
+		}
+	}
+
+	a.Sort(Sorter(SortRegular, false), true, true)
+	check("ksort", "9=20", "10=37", "peter=35")
+	a.Sort(Sorter(SortRegular, true), false, true)
+	check("arsort", "10=37", "peter=35", "9=20")
+	a.Sort(Sorter(SortRegular, false), false, false)
+	check("sort", "0=20", "1=35", "2=37")
+
+	a.Add("1")
+	check("sort", "0=20", "1=35", "2=37", "3=1")
+}
@@ -1,44 +1,546 @@
 package std
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/lSimul/php2go/std/array"
+)
 
 // Concat joins two values, interfaces, to one string.
 // Represents PHP's "." concat operator.
 func Concat(left, right interface{}) string {
-	return interfaceToString(left) + interfaceToString(right)
+	return ToString(left) + ToString(right)
 }
 
-func interfaceToString(i interface{}) string {
-	switch i := i.(type) {
-	case int:
-		return fmt.Sprintf("%d", i)
+// StrDec behaves the same way
+// as PHP string--. Numeric strings are decremented
+// as numbers, the rest stays untouched.
+func StrDec(s string) string {
+	if s == "" {
+		return "-1"
+	}
+	n, ok := numericString(s)
+	if !ok {
+		return s
+	}
+	if n.isFloat {
+		return formatFloat(n.f - 1)
+	}
+	if n.i == math.MinInt64 {
+		return formatFloat(float64(n.i) - 1)
+	}
+	return strconv.Itoa(n.i - 1)
+}
 
-	// Not generated by parser.
-	case float32:
-		panic(`Float32 should not be here.`)
+// StrInc behaves the same way
+// as PHP string++. Numeric strings are incremented
+// as numbers, the rest like "Az" to "Ba". Letters
+// and digits carry to the left, other characters
+// stop the increment.
+func StrInc(s string) string {
+	if s == "" {
+		return "1"
+	}
+	if n, ok := numericString(s); ok {
+		if n.isFloat {
+			return formatFloat(n.f + 1)
+		}
+		if n.i == math.MaxInt64 {
+			return formatFloat(float64(n.i) + 1)
+		}
+		return strconv.Itoa(n.i + 1)
+	}
 
-	case float64:
-		return fmt.Sprintf("%g", i)
+	b := []byte(s)
+	var first byte
+	for i := len(b) - 1; i >= 0; i-- {
+		switch c := b[i]; {
+		case c == 'z':
+			b[i], first = 'a', 'a'
+		case c == 'Z':
+			b[i], first = 'A', 'A'
+		case c == '9':
+			b[i], first = '0', '1'
+		case c >= 'a' && c < 'z', c >= 'A' && c < 'Z', c >= '0' && c < '9':
+			b[i]++
+			return string(b)
+		default:
+			return string(b)
+		}
+	}
+	// Carry from the first character.
+	return string(first) + string(b)
+}
 
-	case string:
-		return i
+// Strnatcmp compares strings in the natural order,
+// like "img2" < "img10". It does the same thing
+// as PHP strnatcmp.
+func Strnatcmp(a, b string) int {
+	return natCompare(a, b, false)
+}
 
-	case fmt.Formatter:
-		return i.(fmt.Stringer).String()
+// Strnatcasecmp is case insensitive Strnatcmp.
+func Strnatcasecmp(a, b string) int {
+	return natCompare(a, b, true)
+}
+
+// natCompare compares runs of digits as numbers, whitespace
+// is skipped. Runs starting with zero are compared as
+// fractions, digit by digit.
+func natCompare(a, b string, fold bool) int {
+	if a == "" || b == "" {
+		return compareInts(len(a), len(b))
 	}
-	return ""
+	i, j := skipZeros(a), skipZeros(b)
+	for {
+		for i < len(a) && isSpace(a[i]) {
+			i++
+		}
+		for j < len(b) && isSpace(b[j]) {
+			j++
+		}
+		if i >= len(a) || j >= len(b) {
+			break
+		}
+
+		ca, cb := a[i], b[j]
+		if isDigit(ca) && isDigit(cb) {
+			var r, n int
+			if ca == '0' || cb == '0' {
+				r, n = compareFractions(a[i:], b[j:])
+			} else {
+				r, n = compareDigits(a[i:], b[j:])
+			}
+			if r != 0 {
+				return r
+			}
+			i += n
+			j += n
+			continue
+		}
+
+		if fold {
+			ca, cb = upper(ca), upper(cb)
+		}
+		if ca != cb {
+			return compareInts(int(ca), int(cb))
+		}
+		i++
+		j++
+	}
+	return compareInts(len(a)-i, len(b)-j)
 }
 
-// StrDec behaves the same way
-// as PHP string--.
-// TODO: Implement this.
-func StrDec(s string) string {
-	return s
+// skipZeros skips leading zeros of the number,
+// the last digit is kept.
+func skipZeros(s string) int {
+	i := 0
+	for i+1 < len(s) && s[i] == '0' && isDigit(s[i+1]) {
+		i++
+	}
+	return i
 }
 
-// StrInc behaves the same way
-// as PHP string++.
-// TODO: Implement this.
-func StrInc(s string) string {
-	return s
+// compareDigits compares runs of digits as numbers, the longer
+// one is greater. Length of the runs is returned when they
+// are equal.
+func compareDigits(a, b string) (int, int) {
+	bias := 0
+	for k := 0; ; k++ {
+		da, db := k < len(a) && isDigit(a[k]), k < len(b) && isDigit(b[k])
+		switch {
+		case !da && !db:
+			return bias, k
+		case !da:
+			return -1, k
+		case !db:
+			return 1, k
+		}
+		if bias == 0 {
+			bias = compareInts(int(a[k]), int(b[k]))
+		}
+	}
+}
+
+// compareFractions compares runs of digits digit by digit.
+func compareFractions(a, b string) (int, int) {
+	for k := 0; ; k++ {
+		da, db := k < len(a) && isDigit(a[k]), k < len(b) && isDigit(b[k])
+		switch {
+		case !da && !db:
+			return 0, k
+		case !da:
+			return -1, k
+		case !db:
+			return 1, k
+		}
+		if r := compareInts(int(a[k]), int(b[k])); r != 0 {
+			return r, k
+		}
+	}
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
+
+// Strlen returns the number of bytes of the string,
+// PHP strings are not aware of UTF-8.
+func Strlen(s string) int {
+	return len(s)
+}
+
+// Substr returns the part of the string, IntMax is the
+// length of the rest. It does the same thing as PHP substr.
+func Substr(s string, offset, length int) string {
+	from, to := array.Bounds(len(s), offset, length)
+	return s[from:to]
+}
+
+// errOffset is passed to panic when the offset of the search
+// is out of the haystack, as PHP throws ValueError.
+func errOffset(fn string) error {
+	return fmt.Errorf("%s(): Argument #3 ($offset) must be contained in argument #1 ($haystack)", fn)
+}
+
+// Strpos returns the position of the first needle in the haystack
+// starting at the offset, false is returned when there is none.
+// It does the same thing as PHP strpos.
+func Strpos(haystack, needle string, offset int) interface{} {
+	return strpos("strpos", haystack, needle, offset)
+}
+
+// Stripos is case insensitive Strpos, only ASCII
+// letters are folded, like in PHP 8.
+func Stripos(haystack, needle string, offset int) interface{} {
+	return strpos("stripos", Strtolower(haystack), Strtolower(needle), offset)
+}
+
+func strpos(fn, haystack, needle string, offset int) interface{} {
+	if offset < 0 {
+		offset += len(haystack)
+	}
+	if offset < 0 || offset > len(haystack) {
+		panic(errOffset(fn))
+	}
+	i := strings.Index(haystack[offset:], needle)
+	if i < 0 {
+		return false
+	}
+	return offset + i
+}
+
+// Strrpos returns the position of the last needle in the haystack.
+// Positive offset skips the beginning of the haystack, negative
+// one the end of it. It does the same thing as PHP strrpos.
+func Strrpos(haystack, needle string, offset int) interface{} {
+	from, to := 0, len(haystack)
+	switch {
+	case offset >= 0:
+		if offset > len(haystack) {
+			panic(errOffset("strrpos"))
+		}
+		from = offset
+	default:
+		if -offset > len(haystack) {
+			panic(errOffset("strrpos"))
+		}
+		// The needle can start at the offset at most.
+		if -offset >= len(needle) {
+			to = len(haystack) + offset + len(needle)
+		}
+	}
+	i := strings.LastIndex(haystack[from:to], needle)
+	if i < 0 {
+		return false
+	}
+	return from + i
+}
+
+// StrReplace replaces all occurrences of the search, empty
+// search replaces nothing. It does the same thing as PHP
+// str_replace with strings.
+func StrReplace(search, replace, subject string) string {
+	if search == "" {
+		return subject
+	}
+	return strings.ReplaceAll(subject, search, replace)
+}
+
+// StrRepeat repeats the string, negative count
+// is passed to panic as PHP throws ValueError.
+func StrRepeat(s string, times int) string {
+	if times < 0 {
+		panic(errors.New("str_repeat(): Argument #2 ($times) must be greater than or equal to 0"))
+	}
+	return strings.Repeat(s, times)
+}
+
+// Sides of the string padded by StrPad,
+// they are PHP STR_PAD_* constants.
+const (
+	StrPadLeft  = 0
+	StrPadRight = 1
+	StrPadBoth  = 2
+)
+
+// StrPad pads the string to the length by repeating the pad,
+// the pad is cut when it does not fit. Strings longer than
+// the length are kept. It does the same thing as PHP str_pad.
+func StrPad(s string, length int, pad string, side int) string {
+	if pad == "" {
+		panic(errors.New("str_pad(): Argument #3 ($pad_string) must be a non-empty string"))
+	}
+	n := length - len(s)
+	if n <= 0 {
+		return s
+	}
+	var left int
+	switch side {
+	case StrPadLeft:
+		left = n
+	case StrPadRight:
+	case StrPadBoth:
+		left = n / 2
+	default:
+		panic(errors.New("str_pad(): Argument #4 ($pad_type) must be STR_PAD_LEFT, STR_PAD_RIGHT, or STR_PAD_BOTH"))
+	}
+	return padding(pad, left) + s + padding(pad, n-left)
+}
+
+func padding(pad string, n int) string {
+	return strings.Repeat(pad, n/len(pad)+1)[:n]
+}
+
+// TrimChars are characters trimmed by default,
+// like in PHP trim.
+const TrimChars = " \n\r\t\v\x00"
+
+// Trim strips characters from both ends of the string. Ranges
+// of characters can be listed like "a..z". It does the same
+// thing as PHP trim.
+func Trim(s, chars string) string {
+	return charMask("trim", chars).trim(s, true, true)
+}
+
+// Ltrim strips characters from the beginning of the string,
+// like Trim.
+func Ltrim(s, chars string) string {
+	return charMask("ltrim", chars).trim(s, true, false)
+}
+
+// Rtrim strips characters from the end of the string,
+// like Trim.
+func Rtrim(s, chars string) string {
+	return charMask("rtrim", chars).trim(s, false, true)
+}
+
+// mask is a set of bytes.
+type mask [256]bool
+
+func (m *mask) trim(s string, left, right bool) string {
+	i, j := 0, len(s)
+	for left && i < j && m[s[i]] {
+		i++
+	}
+	for right && j > i && m[s[j-1]] {
+		j--
+	}
+	return s[i:j]
+}
+
+// charMask parses list of characters with ranges, like "a..z".
+// Invalid ranges are skipped with a warning.
+func charMask(fn, chars string) *mask {
+	m := &mask{}
+	for i := 0; i < len(chars); i++ {
+		c := chars[i]
+		switch {
+		case i+3 < len(chars) && chars[i+1] == '.' && chars[i+2] == '.' && chars[i+3] >= c:
+			for r := int(c); r <= int(chars[i+3]); r++ {
+				m[r] = true
+			}
+			i += 3
+		case i+1 < len(chars) && c == '.' && chars[i+1] == '.':
+			Report(Warning, fn+"(): Invalid '..'-range")
+		default:
+			m[c] = true
+		}
+	}
+	return m
+}
+
+// Strtolower lowercases ASCII letters, other bytes are kept,
+// like in PHP 8.
+func Strtolower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c >= 'A' && c <= 'Z' {
+			b[i] = c - 'A' + 'a'
+		}
+	}
+	return string(b)
+}
+
+// Strtoupper uppercases ASCII letters, like Strtolower.
+func Strtoupper(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		b[i] = upper(c)
+	}
+	return string(b)
+}
+
+// Ucfirst uppercases the first byte of the string,
+// if it is an ASCII letter.
+func Ucfirst(s string) string {
+	if s == "" {
+		return s
+	}
+	b := []byte(s)
+	b[0] = upper(b[0])
+	return string(b)
+}
+
+// WordDelimiters separate words by default,
+// like in PHP ucwords.
+const WordDelimiters = " \t\r\n\f\v"
+
+// Ucwords uppercases first letters of the words separated
+// by the delimiters. It does the same thing as PHP ucwords.
+func Ucwords(s, delimiters string) string {
+	b := []byte(s)
+	start := true
+	for i, c := range b {
+		if start {
+			b[i] = upper(c)
+		}
+		start = strings.IndexByte(delimiters, c) >= 0
+	}
+	return string(b)
+}
+
+// Explode splits the string by the separator. Positive limit is
+// the maximum number of parts, the last part holds the rest, and
+// negative limit drops the parts from the end. It does the same
+// thing as PHP explode.
+func Explode(separator, s string, limit int) array.String {
+	if separator == "" {
+		panic(errors.New("explode(): Argument #1 ($separator) cannot be empty"))
+	}
+	var parts []string
+	switch {
+	case limit > 0:
+		parts = strings.SplitN(s, separator, limit)
+	case limit == 0:
+		parts = strings.SplitN(s, separator, 1)
+	default:
+		parts = strings.Split(s, separator)
+		if -limit >= len(parts) {
+			parts = nil
+		} else {
+			parts = parts[:len(parts)+limit]
+		}
+	}
+	return array.NewString(parts...)
+}
+
+// Implode joins values of the array converted to strings,
+// like PHP implode.
+func Implode(separator string, a array.Reader) string {
+	_, vals := a.Entries()
+	parts := make([]string, len(vals))
+	for i, v := range vals {
+		parts[i] = ToString(v)
+	}
+	return strings.Join(parts, separator)
+}
+
+// Nl2br inserts HTML line breaks before the new lines, pairs
+// of "\r\n" and "\n\r" are one new line. It does the same
+// thing as PHP nl2br.
+func Nl2br(s string, xhtml bool) string {
+	br := "<br>"
+	if xhtml {
+		br = "<br />"
+	}
+	b := strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\n' && c != '\r' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteString(br)
+		b.WriteByte(c)
+		if i+1 < len(s) && (s[i+1] == '\n' || s[i+1] == '\r') && s[i+1] != c {
+			i++
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// Wordwrap breaks lines of the text longer than the width at
+// spaces, words longer than the width are broken only when cut
+// is set. It does the same thing as PHP wordwrap, the algorithm
+// is the same as well.
+func Wordwrap(s string, width int, brk string, cut bool) string {
+	if brk == "" {
+		panic(errors.New("wordwrap(): Argument #3 ($break) cannot be empty"))
+	}
+	if width == 0 && cut {
+		panic(errors.New("wordwrap(): Argument #4 ($cut_long_words) cannot be true when argument #2 ($width) is 0"))
+	}
+	b := strings.Builder{}
+	start, space := 0, 0
+	i := 0
+	for ; i < len(s); i++ {
+		switch {
+		// Existing breaks start new lines.
+		case strings.HasPrefix(s[i:], brk) && i+len(brk) < len(s):
+			b.WriteString(s[start : i+len(brk)])
+			i += len(brk) - 1
+			start, space = i+1, i+1
+
+		case s[i] == ' ':
+			if i-start >= width {
+				b.WriteString(s[start:i])
+				b.WriteString(brk)
+				start = i + 1
+			}
+			space = i
+
+		// Long words are cut when there is no space.
+		case i-start >= width && cut && start >= space:
+			b.WriteString(s[start:i])
+			b.WriteString(brk)
+			start, space = i, i
+
+		// The line is broken at the last space.
+		case i-start >= width && start < space:
+			b.WriteString(s[start:space])
+			b.WriteString(brk)
+			start, space = space+1, space+1
+		}
+	}
+	b.WriteString(s[start:])
+	return b.String()
 }
@@ -0,0 +1,262 @@
+package std
+
+import (
+	"testing"
+
+	"github.com/lSimul/php2go/std/array"
+)
+
+func TestStrInc(t *testing.T) {
+	// ++ of numeric strings adds one, the others get
+	// the next letter or digit, like Perl does.
+	cases := []struct {
+		in, out string
+	}{
+		{"", "1"},
+		{"a", "b"},
+		{"z", "aa"},
+		{"Z", "AA"},
+		{"Az", "Ba"},
+		{"zz", "aaa"},
+		{"Zz", "AAa"},
+		{"a9", "b0"},
+		{"9z", "10a"},
+		{"a-z", "a-a"},
+		{"abc-", "abc-"},
+		{"-", "-"},
+		{"9", "10"},
+		{"-5", "-4"},
+		{" 1", "2"},
+		{"1 ", "2"},
+		{"1.5", "2.5"},
+		{".5", "1.5"},
+		{"1e2", "101"},
+		{"5abc", "5abd"},
+		{"9223372036854775807", "9.2233720368548E+18"},
+	}
+	for _, c := range cases {
+		if out := StrInc(c.in); out != c.out {
+			t.Errorf("%q: '%s' expected, '%s' found.", c.in, c.out, out)
+		}
+	}
+}
+
+func TestStrDec(t *testing.T) {
+	// -- changes only numeric strings, "" becomes -1.
+	cases := []struct {
+		in, out string
+	}{
+		{"", "-1"},
+		{"0", "-1"},
+		{"5", "4"},
+		{"10", "9"},
+		{"1.5", "0.5"},
+		{" 3", "2"},
+		{"1e1", "9"},
+		{"a", "a"},
+		{"abc", "abc"},
+		{"Ba", "Ba"},
+		{"5abc", "5abc"},
+		{"-9223372036854775808", "-9.2233720368548E+18"},
+	}
+	for _, c := range cases {
+		if out := StrDec(c.in); out != c.out {
+			t.Errorf("%q: '%s' expected, '%s' found.", c.in, c.out, out)
+		}
+	}
+}
+
+func TestStrnatcmp(t *testing.T) {
+	// Digits are compared as numbers, spaces and zeros leading
+	// the strings are skipped, other runs of digits starting
+	// with 0 are compared like fractions.
+	cases := []struct {
+		a, b      string
+		cmp, fold int
+	}{
+		{"img12", "img10", 1, 1},
+		{"img2", "img10", -1, -1},
+		{"IMG2", "img10", -1, -1},
+		{"img2", "IMG2", 1, 0},
+		{"a01", "a1", -1, -1},
+		{"1.5", "1.10", -1, -1},
+		{"x 1", "x1", 0, 0},
+		{"007", "7", 0, 0},
+		{"", "a", -1, -1},
+		{"abc", "abc", 0, 0},
+	}
+	for _, c := range cases {
+		if r := Strnatcmp(c.a, c.b); r != c.cmp {
+			t.Errorf("strnatcmp(%q, %q): %d expected, %d found.", c.a, c.b, c.cmp, r)
+		}
+		if r := Strnatcasecmp(c.a, c.b); r != c.fold {
+			t.Errorf("strnatcasecmp(%q, %q): %d expected, %d found.", c.a, c.b, c.fold, r)
+		}
+	}
+}
+
+func TestSubstr(t *testing.T) {
+	// Negative offsets count from the end, negative lengths
+	// leave characters out, bounds out of range are clamped.
+	cases := []struct {
+		offset, length int
+		out            string
+	}{
+		{7, IntMax, "World"},
+		{-5, 3, "Wor"},
+		{2, -3, "llo, Wo"},
+		{20, IntMax, ""},
+		{-20, 2, "He"},
+		{5, -10, ""},
+	}
+	for _, c := range cases {
+		if out := Substr("Hello, World", c.offset, c.length); out != c.out {
+			t.Errorf("%d, %d: '%s' expected, '%s' found.", c.offset, c.length, c.out, out)
+		}
+	}
+}
+
+func TestStrpos(t *testing.T) {
+	s := "Hello, World"
+	cases := []struct {
+		fn  string
+		pos interface{}
+		exp interface{}
+	}{
+		{"strpos", Strpos(s, "o", 0), 4},
+		{"strpos", Strpos(s, "o", 5), 8},
+		{"strpos", Strpos(s, "o", -4), 8},
+		{"strpos", Strpos(s, "x", 0), false},
+		{"strpos", Strpos(s, "", 3), 3},
+		{"stripos", Stripos(s, "WORLD", 0), 7},
+		{"strrpos", Strrpos(s, "o", 0), 8},
+		{"strrpos", Strrpos(s, "o", -5), 4},
+		{"strrpos", Strrpos(s, "l", 4), 10},
+		{"strrpos", Strrpos(s, "He", -12), 0},
+	}
+	for i, c := range cases {
+		if c.pos != c.exp {
+			t.Errorf("%d. %s: %v expected, %v found.", i, c.fn, c.exp, c.pos)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Offset out of the haystack should panic.")
+		}
+	}()
+	Strpos(s, "o", 13)
+}
+
+func TestStrPad(t *testing.T) {
+	cases := []struct {
+		length int
+		pad    string
+		side   int
+		out    string
+	}{
+		{3, "0", StrPadLeft, "005"},
+		{4, " ", StrPadRight, "5   "},
+		{6, "-=", StrPadBoth, "-=5-=-"},
+		{0, "x", StrPadLeft, "5"},
+	}
+	for _, c := range cases {
+		if out := StrPad("5", c.length, c.pad, c.side); out != c.out {
+			t.Errorf("'%s' expected, '%s' found.", c.out, out)
+		}
+	}
+}
+
+func TestTrim(t *testing.T) {
+	prev := SetHandler(HandlerFunc(func(Level, string) {}))
+	defer SetHandler(prev)
+
+	cases := []struct {
+		out, exp string
+	}{
+		{Trim(" \t\nxx \x00", TrimChars), "xx"},
+		{Ltrim("0012", "0"), "12"},
+		{Rtrim("abc123", "0..9"), "abc"},
+		{Trim("[a]", "[]"), "a"},
+		{Trim("abcz", "a..c"), "z"},
+		{Trim("..a..", ".."), "a"},
+		{Trim("é", "\xc3"), "\xa9"},
+	}
+	for _, c := range cases {
+		if c.out != c.exp {
+			t.Errorf("%q expected, %q found.", c.exp, c.out)
+		}
+	}
+}
+
+func TestCase(t *testing.T) {
+	cases := []struct {
+		out, exp string
+	}{
+		{Strtolower("ÁBC Def"), "Ábc def"},
+		{Strtoupper("abc déf"), "ABC DéF"},
+		{Ucfirst("hello"), "Hello"},
+		{Ucfirst(""), ""},
+		{Ucfirst("élan"), "élan"},
+		{Ucwords("hello big-world", " -"), "Hello Big-World"},
+		{Ucwords("a b\tc", WordDelimiters), "A B\tC"},
+	}
+	for _, c := range cases {
+		if c.out != c.exp {
+			t.Errorf("'%s' expected, '%s' found.", c.exp, c.out)
+		}
+	}
+}
+
+func TestExplode(t *testing.T) {
+	cases := []struct {
+		limit int
+		out   string
+	}{
+		{IntMax, "a|b||c"},
+		{2, "a|b,,c"},
+		{0, "a,b,,c"},
+		{-1, "a|b|"},
+		{-4, ""},
+	}
+	for _, c := range cases {
+		parts := Explode(",", "a,b,,c", c.limit)
+		if out := Implode("|", parts); out != c.out {
+			t.Errorf("%d: '%s' expected, '%s' found.", c.limit, c.out, out)
+		}
+	}
+	if out := Implode(", ", array.NewAny(1, 2.5, true, nil)); out != "1, 2.5, 1, " {
+		t.Errorf("Values should be converted to strings, '%s' found.", out)
+	}
+}
+
+func TestNl2br(t *testing.T) {
+	if out := Nl2br("a\nb\r\nc\n\rd\n\ne", true); out != "a<br />\nb<br />\r\nc<br />\n\rd<br />\n<br />\ne" {
+		t.Errorf("Unexpected %q.", out)
+	}
+	if out := Nl2br("x\ny", false); out != "x<br>\ny" {
+		t.Errorf("Unexpected %q.", out)
+	}
+}
+
+func TestWordwrap(t *testing.T) {
+	// Examples of PHP documentation.
+	cases := []struct {
+		s     string
+		width int
+		brk   string
+		cut   bool
+		out   string
+	}{
+		{"The quick brown fox sat over the lazy dog", 15, "<br />\n", false, "The quick brown<br />\nfox sat over<br />\nthe lazy dog"},
+		{"A very long woooooooooooord.", 8, "\n", true, "A very\nlong\nwooooooo\nooooord."},
+		{"A very long woooooooooooooooooord. and something", 8, "\n", false, "A very\nlong\nwoooooooooooooooooord.\nand\nsomething"},
+		{"short\ntext here", 5, "\n", false, "short\ntext\nhere"},
+		{"", 5, "\n", true, ""},
+	}
+	for _, c := range cases {
+		if out := Wordwrap(c.s, c.width, c.brk, c.cut); out != c.out {
+			t.Errorf("%q expected, %q found.", c.out, out)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package std
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Strtotime parses the English description of the date, relative
+// dates are relative to the base timestamp. False is returned when
+// the description is not understood. Common formats are supported:
+//
+//	now, today, midnight, noon, tomorrow, yesterday, @1234567890
+//	2021-03-04, 2021/03/04, 03/04/2021, 04.03.2021, 4 March 2021,
+//	March 4, 2021, 2021-03-04T10:20:30+02:00, 10:20, 10:20:30, 5pm
+//	+1 day, -2 weeks 3 hours, 2 days ago, next month, last year
+//	monday, next friday, last sunday, first/last day of next month
+//
+// It does the same thing as PHP strtotime.
+func Strtotime(s string, base int) interface{} {
+	loc := defaultLocation()
+	p := dateParser{t: time.Unix(int64(base), 0).In(loc), weekday: -1}
+	if !p.parse(strings.ToLower(s)) {
+		return false
+	}
+	return int(p.result().Unix())
+}
+
+// dateTokens are the parts of descriptions, dates and times
+// are single tokens. Commas separate nothing.
+var dateTokens = regexp.MustCompile(`^(?:` +
+	`@-?\d+|` +
+	`\d{4}-\d{1,2}-\d{1,2}(?:t\d{1,2}:\d{2}(?::\d{2}(?:\.\d+)?)?(?:z|[+-]\d{2}:?\d{2})?)?|` +
+	`\d{4}/\d{1,2}/\d{1,2}|\d{1,2}/\d{1,2}/\d{4}|\d{1,2}[.-]\d{1,2}[.-]\d{4}|` +
+	`\d{1,2}:\d{2}(?::\d{2}(?:\.\d+)?)?(?:z|[+-]\d{2}:?\d{2})?|` +
+	`[+-]?\d+(?:st|nd|rd|th)?|` +
+	`[a-z]+\.?)`)
+
+var (
+	isoDateTime = regexp.MustCompile(`^(\d{4})-(\d{1,2})-(\d{1,2})(?:t(.*))?$`)
+	slashDate   = regexp.MustCompile(`^(\d{4})/(\d{1,2})/(\d{1,2})$`)
+	usDate      = regexp.MustCompile(`^(\d{1,2})/(\d{1,2})/(\d{4})$`)
+	euDate      = regexp.MustCompile(`^(\d{1,2})[.-](\d{1,2})[.-](\d{4})$`)
+	clockTime   = regexp.MustCompile(`^(\d{1,2}):(\d{2})(?::(\d{2})(?:\.\d+)?)?(z|[+-]\d{2}:?\d{2})?$`)
+)
+
+// dateUnits are lengths of relative units, months and
+// years are counted separately, they have no fixed length.
+var dateUnits = map[string]time.Duration{
+	"sec":       time.Second,
+	"second":    time.Second,
+	"min":       time.Minute,
+	"minute":    time.Minute,
+	"hour":      time.Hour,
+	"day":       24 * time.Hour,
+	"week":      7 * 24 * time.Hour,
+	"fortnight": 14 * 24 * time.Hour,
+	"month":     0,
+	"year":      0,
+}
+
+// dateParser keeps the parsed parts of the description,
+// they are applied to the base time in the PHP order.
+type dateParser struct {
+	t time.Time
+
+	hasDate          bool
+	year, month, day int
+	hasTime          bool
+	hour, min, sec   int
+	loc              *time.Location
+
+	// Relative parts, days and shorter units are kept as days
+	// and seconds, so months overflow the same way as in PHP.
+	years, months, days, secs int
+
+	weekday int
+	// weekdayRel is 0 for "monday", 1 for "next monday"
+	// and -1 for "last monday".
+	weekdayRel int
+
+	// dayOf is 1 for "first day of" and -1 for "last day of".
+	dayOf int
+}
+
+func (p *dateParser) parse(s string) bool {
+	var tokens []string
+	for s = strings.TrimLeft(s, " \t\n,"); s != ""; s = strings.TrimLeft(s, " \t\n,") {
+		t := dateTokens.FindString(s)
+		if t == "" {
+			return false
+		}
+		tokens = append(tokens, strings.TrimSuffix(t, "."))
+		s = s[len(t):]
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		next := func(n int) string {
+			if i+n < len(tokens) {
+				return tokens[i+n]
+			}
+			return ""
+		}
+
+		switch {
+		case t == "now":
+
+		case t == "today" || t == "midnight":
+			p.setTime(0, 0, 0)
+
+		case t == "noon":
+			p.setTime(12, 0, 0)
+
+		case t == "tomorrow" || t == "yesterday":
+			p.days += 1
+			if t == "yesterday" {
+				p.days -= 2
+			}
+			p.setTime(0, 0, 0)
+
+		case t[0] == '@':
+			ts, err := strconv.ParseInt(t[1:], 10, 64)
+			if err != nil {
+				return false
+			}
+			p.t = time.Unix(ts, 0).UTC()
+			p.loc = time.UTC
+
+		case (t == "first" || t == "last") && next(1) == "day" && next(2) == "of":
+			p.dayOf = 1
+			if t == "last" {
+				p.dayOf = -1
+			}
+			i += 2
+
+		case t == "next" || t == "last" || t == "previous" || t == "this":
+			n := map[string]int{"next": 1, "last": -1, "previous": -1, "this": 0}[t]
+			if wd, ok := weekday(next(1)); ok {
+				p.weekday, p.weekdayRel = wd, n
+				p.setTime(0, 0, 0)
+			} else if !p.relative(n, next(1)) {
+				return false
+			}
+			i++
+
+		case t == "ago":
+			p.years, p.months, p.days, p.secs = -p.years, -p.months, -p.days, -p.secs
+
+		case t == "am" || t == "pm":
+			if !p.hasTime || p.hour < 1 || p.hour > 12 {
+				return false
+			}
+			p.hour %= 12
+			if t == "pm" {
+				p.hour += 12
+			}
+
+		case isNumberToken(t):
+			n, _ := strconv.Atoi(strings.TrimLeft(t, "+"))
+			if m, ok := month(next(1)); ok && t[0] != '+' && t[0] != '-' {
+				// 4 March 2021
+				p.setDate(p.t.Year(), m, n)
+				i++
+				if y, ok := yearToken(next(1)); ok {
+					p.year = y
+					i++
+				}
+			} else if next(1) == "am" || next(1) == "pm" {
+				p.setTime(n, 0, 0)
+			} else if p.relative(n, next(1)) {
+				i++
+			} else {
+				return false
+			}
+
+		default:
+			if !p.absolute(t) {
+				return false
+			}
+			if m, ok := month(t); ok {
+				// March 4, 2021 or March 2021
+				p.setDate(p.t.Year(), m, p.t.Day())
+				day := false
+				if d, err := strconv.Atoi(strings.TrimRight(next(1), "stndrh")); err == nil && d <= 31 && len(next(1)) <= 4 {
+					p.day = d
+					day = true
+					i++
+				}
+				if y, ok := yearToken(next(1)); ok {
+					p.year = y
+					if !day {
+						p.day = 1
+					}
+					i++
+				}
+			}
+		}
+	}
+	return true
+}
+
+// absolute parses dates, times, time zones and days of the week.
+func (p *dateParser) absolute(t string) bool {
+	if m := isoDateTime.FindStringSubmatch(t); m != nil {
+		p.setDate(atoi(m[1]), time.Month(atoi(m[2])), atoi(m[3]))
+		if m[4] != "" {
+			return p.clock(m[4])
+		}
+		return p.validDate()
+	}
+	if m := slashDate.FindStringSubmatch(t); m != nil {
+		p.setDate(atoi(m[1]), time.Month(atoi(m[2])), atoi(m[3]))
+		return p.validDate()
+	}
+	if m := usDate.FindStringSubmatch(t); m != nil {
+		p.setDate(atoi(m[3]), time.Month(atoi(m[1])), atoi(m[2]))
+		return p.validDate()
+	}
+	if m := euDate.FindStringSubmatch(t); m != nil {
+		p.setDate(atoi(m[3]), time.Month(atoi(m[2])), atoi(m[1]))
+		return p.validDate()
+	}
+	if clockTime.MatchString(t) {
+		return p.clock(t)
+	}
+	if t == "utc" || t == "gmt" || t == "z" {
+		p.loc = time.UTC
+		return true
+	}
+	if wd, ok := weekday(t); ok {
+		p.weekday, p.weekdayRel = wd, 0
+		p.setTime(0, 0, 0)
+		return true
+	}
+	_, ok := month(t)
+	return ok
+}
+
+// clock parses the time with the optional offset of the time zone.
+func (p *dateParser) clock(t string) bool {
+	m := clockTime.FindStringSubmatch(t)
+	if m == nil {
+		return false
+	}
+	p.setTime(atoi(m[1]), atoi(m[2]), atoi(m[3]))
+	if p.hour > 24 || p.min > 59 || p.sec > 60 {
+		return false
+	}
+	switch z := m[4]; {
+	case z == "z":
+		p.loc = time.UTC
+	case z != "":
+		z = strings.Replace(z, ":", "", 1)
+		o := atoi(z[1:3])*3600 + atoi(z[3:])*60
+		if z[0] == '-' {
+			o = -o
+		}
+		p.loc = time.FixedZone("", o)
+	}
+	return true
+}
+
+// relative adds n units, unknown units are not added.
+func (p *dateParser) relative(n int, unit string) bool {
+	unit = strings.TrimSuffix(unit, "s")
+	d, ok := dateUnits[unit]
+	if !ok {
+		return false
+	}
+	switch unit {
+	case "year":
+		p.years += n
+	case "month":
+		p.months += n
+	default:
+		if d >= 24*time.Hour {
+			p.days += n * int(d/(24*time.Hour))
+		} else {
+			p.secs += n * int(d/time.Second)
+		}
+	}
+	return true
+}
+
+func (p *dateParser) setDate(y int, m time.Month, d int) {
+	p.hasDate = true
+	p.year, p.month, p.day = y, int(m), d
+}
+
+func (p *dateParser) setTime(h, m, s int) {
+	p.hasTime = true
+	p.hour, p.min, p.sec = h, m, s
+}
+
+func (p *dateParser) validDate() bool {
+	return p.month >= 1 && p.month <= 12 && p.day >= 1 && p.day <= 31
+}
+
+// result applies the parsed parts to the base time. Dates without
+// times are at midnight, relative parts are added and the day of
+// the week is searched for the last.
+func (p *dateParser) result() time.Time {
+	t := p.t
+	if p.loc != nil {
+		t = t.In(p.loc)
+	}
+	y, m, d := t.Date()
+	h, min, s := t.Clock()
+	if p.hasDate {
+		y, m, d = p.year, time.Month(p.month), p.day
+		h, min, s = 0, 0, 0
+	}
+	if p.hasTime {
+		h, min, s = p.hour, p.min, p.sec
+	}
+	y += p.years
+	m += time.Month(p.months)
+	switch p.dayOf {
+	case 1:
+		d = 1
+	case -1:
+		d = daysIn(m, y)
+	}
+	t = time.Date(y, m, d+p.days, h, min, s+p.secs, 0, t.Location())
+
+	if p.weekday >= 0 {
+		diff := (p.weekday - int(t.Weekday()) + 7) % 7
+		switch {
+		case p.weekdayRel > 0 && diff == 0:
+			diff = 7
+		case p.weekdayRel < 0:
+			diff -= 7
+		}
+		t = t.AddDate(0, 0, diff)
+	}
+	return t
+}
+
+func isNumberToken(t string) bool {
+	t = strings.TrimLeft(t, "+-")
+	for _, c := range []byte(t) {
+		if !isDigit(c) {
+			return false
+		}
+	}
+	return t != ""
+}
+
+// yearToken parses the year of four digits.
+func yearToken(t string) (int, bool) {
+	if len(t) != 4 {
+		return 0, false
+	}
+	y, err := strconv.Atoi(t)
+	return y, err == nil
+}
+
+func atoi(s string) int {
+	i, _ := strconv.Atoi(s)
+	return i
+}
+
+// weekday parses English names of the days and their abbreviations.
+func weekday(s string) (int, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		n := strings.ToLower(d.String())
+		if s == n || s == n[:3] {
+			return int(d), true
+		}
+	}
+	return 0, false
+}
+
+// month parses English names of the months and their abbreviations.
+func month(s string) (time.Month, bool) {
+	for m := time.January; m <= time.December; m++ {
+		n := strings.ToLower(m.String())
+		if s == n || s == n[:3] || s == "sept" && m == time.September {
+			return m, true
+		}
+	}
+	return 0, false
+}
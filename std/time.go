@@ -1,15 +1,359 @@
 package std
 
-import "time"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	// PHP has its own database of time zones too,
+	// programs do not depend on the system one.
+	_ "time/tzdata"
+
+	"github.com/lSimul/php2go/std/array"
+)
+
+// Clock returns the current time, see SetClock.
+type Clock func() time.Time
+
+var (
+	timeMu sync.Mutex
+	clock  Clock = time.Now
+	// location is the default time zone,
+	// PHP uses UTC unless it is set.
+	location = time.UTC
+)
+
+// hrStart is the point hrtime is measured from.
+var hrStart = time.Now()
+
+// SetClock replaces the source of the current time, the previous
+// one is returned. Tests use it to run with the fixed time.
+func SetClock(c Clock) Clock {
+	timeMu.Lock()
+	defer timeMu.Unlock()
+	prev := clock
+	clock = c
+	return prev
+}
+
+// now returns the current time in the default time zone.
+func now() time.Time {
+	timeMu.Lock()
+	defer timeMu.Unlock()
+	return clock().In(location)
+}
+
+func defaultLocation() *time.Location {
+	timeMu.Lock()
+	defer timeMu.Unlock()
+	return location
+}
+
+// Time returns the current Unix timestamp.
+// It does the same thing as PHP time.
+func Time() int {
+	return int(now().Unix())
+}
 
 // Microtime returns current Unix timestamp
-// with microseconds.
-// It represents PHP function with the same name,
-// with one big difference, this value is always
-// float.
+// with microseconds. It is PHP microtime(true).
 //
 // See php.net/manual/en/function.microtime.php
 // for more details.
 func Microtime() float64 {
-	return float64(time.Now().UnixNano()) / 1000000000
+	t := now()
+	return float64(t.Unix()) + float64(t.Nanosecond()/1000)/1000000
+}
+
+// MicrotimeString returns microseconds and seconds of the
+// current Unix timestamp, like "0.65432100 1234567890".
+// It is PHP microtime().
+func MicrotimeString() string {
+	t := now()
+	usec := t.Nanosecond() / 1000
+	return fmt.Sprintf("%.8f %d", float64(usec)/1000000, t.Unix())
+}
+
+// Hrtime returns seconds and nanoseconds from an arbitrary
+// point in time. It does the same thing as PHP hrtime().
+func Hrtime() array.Int {
+	ns := HrtimeNumber()
+	return array.NewInt(ns/1000000000, ns%1000000000)
+}
+
+// HrtimeNumber returns nanoseconds from an arbitrary
+// point in time. It is PHP hrtime(true).
+func HrtimeNumber() int {
+	timeMu.Lock()
+	defer timeMu.Unlock()
+	return int(clock().Sub(hrStart))
+}
+
+// DateDefaultTimezoneSet sets the time zone used by date
+// functions, invalid names are reported and false is returned.
+// It does the same thing as PHP date_default_timezone_set.
+func DateDefaultTimezoneSet(tz string) bool {
+	loc, err := time.LoadLocation(tz)
+	if err != nil || tz == "" || tz == "Local" {
+		Report(Notice, fmt.Sprintf("date_default_timezone_set(): Timezone ID '%s' is invalid", tz))
+		return false
+	}
+	timeMu.Lock()
+	defer timeMu.Unlock()
+	location = loc
+	return true
+}
+
+// DateDefaultTimezoneGet returns the name of the time zone used
+// by date functions. It does the same thing as PHP
+// date_default_timezone_get.
+func DateDefaultTimezoneGet() string {
+	return defaultLocation().String()
+}
+
+// Checkdate reports if the date is valid in the Gregorian calendar.
+// It does the same thing as PHP checkdate.
+func Checkdate(month, day, year int) bool {
+	return month >= 1 && month <= 12 && year >= 1 && year <= 32767 &&
+		day >= 1 && day <= daysIn(time.Month(month), year)
+}
+
+func daysIn(m time.Month, year int) int {
+	return time.Date(year, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// Mktime returns the Unix timestamp of the date, omitted values
+// are taken from the current date. Values out of their ranges
+// are added to the date, years 0-69 are 2000-2069 and 70-100
+// are 1970-2000. It does the same thing as PHP mktime.
+func Mktime(hour int, rest ...int) int {
+	t := now()
+	vals := []int{hour, t.Minute(), t.Second(), int(t.Month()), t.Day(), t.Year()}
+	copy(vals[1:], rest)
+	year := vals[5]
+	switch {
+	case year >= 0 && year < 70:
+		year += 2000
+	case year >= 70 && year <= 100:
+		year += 1900
+	}
+	return int(time.Date(year, time.Month(vals[3]), vals[4], vals[0], vals[1], vals[2], 0, t.Location()).Unix())
+}
+
+// Date formats the Unix timestamp in the default time zone,
+// every format character of PHP is supported, the rest is
+// copied. Backslash escapes the format characters.
+// It does the same thing as PHP date.
+//
+// See php.net/manual/en/datetime.format.php
+// for more details.
+func Date(format string, timestamp int) string {
+	return formatDate(format, time.Unix(int64(timestamp), 0).In(defaultLocation()))
+}
+
+func formatDate(format string, t time.Time) string {
+	s := strings.Builder{}
+	pad := func(i int) {
+		fmt.Fprintf(&s, "%02d", i)
+	}
+	for i := 0; i < len(format); i++ {
+		switch c := format[i]; c {
+		// Day
+		case 'd':
+			pad(t.Day())
+		case 'D':
+			s.WriteString(t.Weekday().String()[:3])
+		case 'j':
+			s.WriteString(strconv.Itoa(t.Day()))
+		case 'l':
+			s.WriteString(t.Weekday().String())
+		case 'N':
+			s.WriteString(strconv.Itoa(isoWeekday(t)))
+		case 'S':
+			s.WriteString(ordinalSuffix(t.Day()))
+		case 'w':
+			s.WriteString(strconv.Itoa(int(t.Weekday())))
+		case 'z':
+			s.WriteString(strconv.Itoa(t.YearDay() - 1))
+
+		// Week
+		case 'W':
+			_, w := t.ISOWeek()
+			pad(w)
+
+		// Month
+		case 'F':
+			s.WriteString(t.Month().String())
+		case 'm':
+			pad(int(t.Month()))
+		case 'M':
+			s.WriteString(t.Month().String()[:3])
+		case 'n':
+			s.WriteString(strconv.Itoa(int(t.Month())))
+		case 't':
+			s.WriteString(strconv.Itoa(daysIn(t.Month(), t.Year())))
+
+		// Year
+		case 'L':
+			if daysIn(time.February, t.Year()) == 29 {
+				s.WriteByte('1')
+			} else {
+				s.WriteByte('0')
+			}
+		case 'o':
+			y, _ := t.ISOWeek()
+			s.WriteString(strconv.Itoa(y))
+		case 'X':
+			if t.Year() >= 0 {
+				s.WriteByte('+')
+			}
+			s.WriteString(year(t.Year()))
+		case 'x':
+			if t.Year() >= 10000 {
+				s.WriteByte('+')
+			}
+			s.WriteString(year(t.Year()))
+		case 'Y':
+			s.WriteString(year(t.Year()))
+		case 'y':
+			pad(t.Year() % 100)
+
+		// Time
+		case 'a':
+			if t.Hour() < 12 {
+				s.WriteString("am")
+			} else {
+				s.WriteString("pm")
+			}
+		case 'A':
+			if t.Hour() < 12 {
+				s.WriteString("AM")
+			} else {
+				s.WriteString("PM")
+			}
+		case 'B':
+			fmt.Fprintf(&s, "%03d", swatch(t.Unix()))
+		case 'g':
+			s.WriteString(strconv.Itoa(hour12(t.Hour())))
+		case 'G':
+			s.WriteString(strconv.Itoa(t.Hour()))
+		case 'h':
+			pad(hour12(t.Hour()))
+		case 'H':
+			pad(t.Hour())
+		case 'i':
+			pad(t.Minute())
+		case 's':
+			pad(t.Second())
+		case 'u':
+			fmt.Fprintf(&s, "%06d", t.Nanosecond()/1000)
+		case 'v':
+			fmt.Fprintf(&s, "%03d", t.Nanosecond()/1000000)
+
+		// Timezone
+		case 'e':
+			s.WriteString(t.Location().String())
+		case 'I':
+			if t.IsDST() {
+				s.WriteByte('1')
+			} else {
+				s.WriteByte('0')
+			}
+		case 'O':
+			s.WriteString(offset(t, ""))
+		case 'P':
+			s.WriteString(offset(t, ":"))
+		case 'p':
+			if _, o := t.Zone(); o == 0 {
+				s.WriteByte('Z')
+			} else {
+				s.WriteString(offset(t, ":"))
+			}
+		case 'T':
+			n, _ := t.Zone()
+			s.WriteString(n)
+		case 'Z':
+			_, o := t.Zone()
+			s.WriteString(strconv.Itoa(o))
+
+		// Full Date/Time
+		case 'c':
+			s.WriteString(formatDate(`Y-m-d\TH:i:sP`, t))
+		case 'r':
+			s.WriteString(formatDate("D, d M Y H:i:s O", t))
+		case 'U':
+			s.WriteString(strconv.FormatInt(t.Unix(), 10))
+
+		case '\\':
+			if i+1 < len(format) {
+				i++
+				s.WriteByte(format[i])
+			}
+		default:
+			s.WriteByte(c)
+		}
+	}
+	return s.String()
+}
+
+// isoWeekday returns 1 for Monday and 7 for Sunday.
+func isoWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
+// ordinalSuffix returns English suffix of the day, like "st" in 1st.
+func ordinalSuffix(day int) string {
+	switch {
+	case day >= 11 && day <= 13:
+		return "th"
+	case day%10 == 1:
+		return "st"
+	case day%10 == 2:
+		return "nd"
+	case day%10 == 3:
+		return "rd"
+	}
+	return "th"
+}
+
+// year has at least four digits, years BCE have minus.
+func year(y int) string {
+	if y < 0 {
+		return fmt.Sprintf("-%04d", -y)
+	}
+	return fmt.Sprintf("%04d", y)
+}
+
+func hour12(h int) int {
+	if h%12 == 0 {
+		return 12
+	}
+	return h % 12
+}
+
+// swatch returns Swatch Internet time, there are thousand beats
+// in the day and they are counted in the time zone UTC+1.
+func swatch(unix int64) int64 {
+	b := (unix%86400 + 3600) * 10
+	if b < 0 {
+		b += 864000
+	}
+	return b / 864 % 1000
+}
+
+// offset formats the offset of the time zone, like +0200
+// or +02:00 with the colon as the separator.
+func offset(t time.Time, sep string) string {
+	_, o := t.Zone()
+	sign := '+'
+	if o < 0 {
+		sign = '-'
+		o = -o
+	}
+	return fmt.Sprintf("%c%02d%s%02d", sign, o/3600, sep, o%3600/60)
 }
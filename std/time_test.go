@@ -0,0 +1,176 @@
+package std
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lSimul/php2go/std/array"
+)
+
+// sunday is Sunday 2001-09-09 01:46:40 UTC.
+const sunday = 1000000000
+
+func TestDate(t *testing.T) {
+	cases := []struct {
+		format, out string
+	}{
+		{"Y-m-d H:i:s", "2001-09-09 01:46:40"},
+		{"D, d M Y", "Sun, 09 Sep 2001"},
+		{"l jS F y", "Sunday 9th September 01"},
+		{"N w z t L", "7 0 251 30 0"},
+		{"W o X x", "36 2001 +2001 2001"},
+		{"g G h H a A B", "1 1 01 01 am AM 115"},
+		{"U u v", "1000000000 000000 000"},
+		{"e T P O p Z I", "UTC UTC +00:00 +0000 Z 0 0"},
+		{"c", "2001-09-09T01:46:40+00:00"},
+		{"r", "Sun, 09 Sep 2001 01:46:40 +0000"},
+		{`\Y\e\s Y`, "Yes 2001"},
+	}
+	for _, c := range cases {
+		if out := Date(c.format, sunday); out != c.out {
+			t.Errorf("%s: %q expected, %q found.", c.format, c.out, out)
+		}
+	}
+
+	if !DateDefaultTimezoneSet("Europe/Prague") {
+		t.Fatal("Europe/Prague should be a valid time zone.")
+	}
+	defer DateDefaultTimezoneSet("UTC")
+	if out := Date("H:i T I P e", sunday); out != "03:46 CEST 1 +02:00 Europe/Prague" {
+		t.Errorf("Time in Prague expected, %q found.", out)
+	}
+	if out := DateDefaultTimezoneGet(); out != "Europe/Prague" {
+		t.Errorf("Europe/Prague expected, %q found.", out)
+	}
+
+	var msgs []string
+	prev := SetHandler(HandlerFunc(func(l Level, msg string) {
+		msgs = append(msgs, msg)
+	}))
+	defer SetHandler(prev)
+	if DateDefaultTimezoneSet("Mars/Base") || len(msgs) != 1 {
+		t.Errorf("Invalid time zone should be reported, %v found.", msgs)
+	}
+}
+
+func TestClock(t *testing.T) {
+	prev := SetClock(func() time.Time {
+		return time.Unix(sunday, 654321000)
+	})
+	defer SetClock(prev)
+
+	if ts := Time(); ts != sunday {
+		t.Errorf("%d expected, %d found.", sunday, ts)
+	}
+	if f := Microtime(); f != 1000000000.654321 {
+		t.Errorf("1000000000.654321 expected, %v found.", f)
+	}
+	if s := MicrotimeString(); s != "0.65432100 1000000000" {
+		t.Errorf("\"0.65432100 1000000000\" expected, %q found.", s)
+	}
+
+	cases := []struct {
+		out, expected int
+	}{
+		{Mktime(0), sunday - 3600},
+		{Mktime(12, 0, 0, 2, 30, 2020), int(time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC).Unix())},
+		{Mktime(0, 0, 0, 1, 1, 70), 0},
+		{Mktime(0, 0, 0, 1, 1, 5), int(time.Date(2005, 1, 1, 0, 0, 0, 0, time.UTC).Unix())},
+	}
+	for i, c := range cases {
+		if c.out != c.expected {
+			t.Errorf("Mktime %d: %d expected, %d found.", i, c.expected, c.out)
+		}
+	}
+
+	SetClock(func() time.Time {
+		return hrStart.Add(1500 * time.Millisecond)
+	})
+	if hr := Hrtime(); hr.At(array.NewScalar(0)) != 1 || hr.At(array.NewScalar(1)) != 500000000 {
+		t.Errorf("[1, 500000000] expected, %v found.", hr)
+	}
+	if hr := HrtimeNumber(); hr != 1500000000 {
+		t.Errorf("1500000000 expected, %d found.", hr)
+	}
+}
+
+func TestCheckdate(t *testing.T) {
+	cases := []struct {
+		month, day, year int
+		valid            bool
+	}{
+		{2, 29, 2020, true},
+		{2, 29, 2021, false},
+		{2, 29, 2000, true},
+		{2, 29, 1900, false},
+		{12, 31, 32767, true},
+		{1, 1, 0, false},
+		{13, 1, 2020, false},
+		{4, 31, 2020, false},
+	}
+	for _, c := range cases {
+		if Checkdate(c.month, c.day, c.year) != c.valid {
+			t.Errorf("%d/%d/%d should be %t.", c.month, c.day, c.year, c.valid)
+		}
+	}
+}
+
+func TestStrtotime(t *testing.T) {
+	const today = sunday - 6400
+	const day = 86400
+	utc := func(y int, m time.Month, d, h, min, s int) int {
+		return int(time.Date(y, m, d, h, min, s, 0, time.UTC).Unix())
+	}
+	cases := []struct {
+		s   string
+		out interface{}
+	}{
+		{"now", sunday},
+		{"today", today},
+		{"Midnight", today},
+		{"tomorrow", today + day},
+		{"yesterday noon", today - day/2},
+		{"+1 day", sunday + day},
+		{"+1 week 2 days", sunday + 9*day},
+		{"2 days ago", sunday - 2*day},
+		{"-3 hours 15 minutes", sunday - 3*3600 + 15*60},
+		{"next month", utc(2001, 10, 9, 1, 46, 40)},
+		{"last year", utc(2000, 9, 9, 1, 46, 40)},
+		{"next monday", today + day},
+		{"last sunday", today - 7*day},
+		{"sunday", today},
+		{"Sat", today + 6*day},
+		{"first day of next month", utc(2001, 10, 1, 1, 46, 40)},
+		{"last day of this month", utc(2001, 9, 30, 1, 46, 40)},
+		{"2021-03-04", utc(2021, 3, 4, 0, 0, 0)},
+		{"2021-03-04 10:20:30", utc(2021, 3, 4, 10, 20, 30)},
+		{"2021-03-04T10:20:30+02:00", utc(2021, 3, 4, 8, 20, 30)},
+		{"2021/03/04", utc(2021, 3, 4, 0, 0, 0)},
+		{"03/04/2021", utc(2021, 3, 4, 0, 0, 0)},
+		{"04.03.2021", utc(2021, 3, 4, 0, 0, 0)},
+		{"March 4, 2021 5pm", utc(2021, 3, 4, 17, 0, 0)},
+		{"4 March 2021 10:20", utc(2021, 3, 4, 10, 20, 0)},
+		{"Mar 2021", utc(2021, 3, 1, 0, 0, 0)},
+		{"10:30pm", utc(2001, 9, 9, 22, 30, 0)},
+		{"@86400", 86400},
+		{"2021-01-31 +1 month", utc(2021, 3, 3, 0, 0, 0)},
+		{"garbage", false},
+		{"2021-13-01", false},
+		{"+1 lightyear", false},
+		{"13pm", false},
+	}
+	for _, c := range cases {
+		if out := Strtotime(c.s, sunday); out != c.out {
+			t.Errorf("%s: %v expected, %v found.", c.s, c.out, out)
+		}
+	}
+
+	if !DateDefaultTimezoneSet("America/New_York") {
+		t.Fatal("America/New_York should be a valid time zone.")
+	}
+	defer DateDefaultTimezoneSet("UTC")
+	// The base is Saturday 21:46:40 in New York.
+	if out := Strtotime("today", sunday); out != utc(2001, 9, 8, 4, 0, 0) {
+		t.Errorf("Midnight in New York expected, %v found.", out)
+	}
+}